@@ -0,0 +1,126 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Allowlist is a manifest of the operations a client is allowed to send, identified by the
+// hex-encoded SHA-256 hash of their document, as used by Automatic Persisted Queries and
+// persisted query manifests. See Client.WithAllowlist.
+type Allowlist struct {
+	hashes map[string]struct{}
+}
+
+// NewAllowlist returns an Allowlist permitting the documents with the given hashes. Hashes
+// are compared case-insensitively.
+func NewAllowlist(hashes ...string) *Allowlist {
+	allowlist := &Allowlist{hashes: make(map[string]struct{}, len(hashes))}
+	for _, hash := range hashes {
+		allowlist.hashes[strings.ToLower(hash)] = struct{}{}
+	}
+	return allowlist
+}
+
+// AllowlistOf returns an Allowlist permitting the given documents, typically the operations
+// embedded in the application.
+func AllowlistOf(documents ...string) *Allowlist {
+	hashes := make([]string, len(documents))
+	for i, document := range documents {
+		hashes[i] = queryHash(document)
+	}
+	return NewAllowlist(hashes...)
+}
+
+// LoadAllowlist reads an allowlist manifest in JSON format: either an array of hashes, an
+// object mapping hashes to documents, or an Apollo persisted query manifest whose operations
+// list their "id" and "body". When the manifest holds documents, their hashes are allowed
+// along with the listed IDs, so that manifests keyed by arbitrary IDs work as well.
+func LoadAllowlist(reader io.Reader) (*Allowlist, error) {
+	var manifest json.RawMessage
+	err := json.NewDecoder(reader).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	var hashes []string
+	err = json.Unmarshal(manifest, &hashes)
+	if err == nil {
+		return NewAllowlist(hashes...), nil
+	}
+
+	var documents map[string]json.RawMessage
+	err = json.Unmarshal(manifest, &documents)
+	if err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+	if operations, ok := documents["operations"]; ok {
+		var entries []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		}
+		err = json.Unmarshal(operations, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("decoding manifest operations: %w", err)
+		}
+		for _, entry := range entries {
+			hashes = append(hashes, entry.ID, queryHash(entry.Body))
+		}
+		return NewAllowlist(hashes...), nil
+	}
+	for hash, raw := range documents {
+		var document string
+		err = json.Unmarshal(raw, &document)
+		if err != nil {
+			return nil, fmt.Errorf("decoding manifest document %q: %w", hash, err)
+		}
+		hashes = append(hashes, hash, queryHash(document))
+	}
+	return NewAllowlist(hashes...), nil
+}
+
+// Allows reports whether the document is part of the allowlist.
+func (allowlist *Allowlist) Allows(document string) bool {
+	_, ok := allowlist.hashes[queryHash(document)]
+	return ok
+}
+
+// WithAllowlist restricts the client to the operations of the allowlist, to block ad-hoc
+// queries in production builds. Requests, batches and subscriptions whose document is not
+// part of it fail with ErrOperationNotAllowed without reaching the endpoint. Documents are
+// hashed as sent, registered fragments included (see RegisterFragment), but before any
+// normalization. Unlike strict trusted documents (see WithTrustedDocuments), the allowed
+// documents are still sent in full, so that the endpoint needs no manifest. Passing nil
+// lifts the restriction. The updated Client is returned.
+func (client *Client) WithAllowlist(allowlist *Allowlist) *Client {
+	client.allowlist = allowlist
+	return client
+}
+
+// checkAllowlist returns an ErrOperationNotAllowed when the parent client has an allowlist
+// that does not contain the document.
+func (request Request) checkAllowlist(document string) error {
+	if request.client == nil || request.client.allowlist == nil {
+		return nil
+	}
+	hash := queryHash(document)
+	_, ok := request.client.allowlist.hashes[hash]
+	if !ok {
+		return &ErrOperationNotAllowed{OperationName: request.operationName, Hash: hash}
+	}
+	return nil
+}
+
+// allowlisted is the Handler stage failing the requests whose document is not allowed.
+func allowlisted(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		err := request.checkAllowlist(request.Request)
+		if err != nil {
+			return Response{}, err
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,142 @@
+package ggql
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// persistedQueryVersion is the version of the Automatic Persisted Queries protocol.
+const persistedQueryVersion = 1
+
+// PersistedQuery enables Automatic Persisted Queries for the request. The request is first
+// sent with only the SHA-256 hash of the query in extensions.persistedQuery, and the full
+// query is sent along with the hash only when the server does not know it yet.
+// The modified Request is returned.
+func (request Request) PersistedQuery() Request {
+	request.persisted = true
+	return request
+}
+
+// WithPersistedQueries enables Automatic Persisted Queries for every request executed
+// through the client. The updated Client is returned.
+func (client *Client) WithPersistedQueries() *Client {
+	client.persistedQueries = true
+	return client
+}
+
+// usesPersistedQuery reports whether the request should be sent as an Automatic Persisted Query.
+// Requests carrying uploads are always sent in full since their files can only be read once.
+func (request Request) usesPersistedQuery() bool {
+	if !request.persisted && (request.client == nil || !request.client.persistedQueries) {
+		return false
+	}
+	_, uploads := extractUploads(request.Variables)
+	return len(uploads) == 0
+}
+
+// sendPersisted sends the payload following the Automatic Persisted Queries protocol.
+// The full query is only sent when the server reports that the hash is unknown, and the
+// persisted query extension is dropped altogether if the server does not support it. When
+// the client has a CacheStore, the queries known by the endpoint and its support of the
+// protocol are recorded in it, see WithCacheStore.
+func (request Request) sendPersisted(ctx context.Context, c content) (Response, error) {
+	query := c.Query
+	hash := queryHash(query)
+	extensions := make(map[string]any, len(c.Extensions)+1)
+	for key, value := range c.Extensions {
+		extensions[key] = value
+	}
+	extensions["persistedQuery"] = map[string]any{
+		"version":    persistedQueryVersion,
+		"sha256Hash": hash,
+	}
+	registry := request.persistedQueryRegistry()
+	registered := persistedQueryKey(request.Endpoint, hash)
+	unsupported := persistedQueryKey(request.Endpoint, "")
+	known := registry != nil && registryHas(registry, registered)
+
+	c.Extensions = extensions
+	switch {
+	case registry == nil || known:
+		c.Query = ""
+	case registryHas(registry, unsupported):
+		c.Extensions = withoutPersistedQuery(extensions)
+		return request.send(ctx, c)
+	}
+	response, err := request.send(ctx, c)
+	if err != nil {
+		return response, err
+	}
+
+	switch {
+	case c.Query == "" && hasErrorCode(response, "PERSISTED_QUERY_NOT_FOUND", "PersistedQueryNotFound"):
+		c.Query = query
+		response, err = request.send(ctx, c)
+	case hasErrorCode(response, "PERSISTED_QUERY_NOT_SUPPORTED", "PersistedQueryNotSupported"):
+		if registry != nil {
+			_ = registry.Delete(registered)
+			_ = registry.Set(unsupported, CacheEntry{})
+		}
+		c.Query = query
+		c.Extensions = withoutPersistedQuery(extensions)
+		return request.send(ctx, c)
+	}
+	if registry != nil && !known && err == nil {
+		_ = registry.Set(registered, CacheEntry{})
+	}
+	return response, err
+}
+
+// persistedQueryRegistry returns the store recording the persisted queries known by the
+// endpoints, or nil when the client has no CacheStore.
+func (request Request) persistedQueryRegistry() CacheStore {
+	if request.client == nil {
+		return nil
+	}
+	return request.client.cacheStore
+}
+
+// persistedQueryKey returns the registry key recording that the endpoint knows the query
+// with the given hash or, for an empty hash, that it doesn't support the protocol.
+func persistedQueryKey(endpoint, hash string) string {
+	if hash == "" {
+		return "apq-unsupported\x00" + endpoint
+	}
+	return "apq\x00" + endpoint + "\x00" + hash
+}
+
+// registryHas reports whether the registry holds key. Failures of the store are reported as a
+// missing key, the protocol recovering from outdated registries.
+func registryHas(registry CacheStore, key string) bool {
+	_, ok, err := registry.Get(key)
+	return ok && err == nil
+}
+
+// withoutPersistedQuery returns the extensions without the persisted query extension, or nil
+// when no other extension remains.
+func withoutPersistedQuery(extensions map[string]any) map[string]any {
+	delete(extensions, "persistedQuery")
+	if len(extensions) == 0 {
+		return nil
+	}
+	return extensions
+}
+
+// queryHash returns the hex-encoded SHA-256 hash of the query, as expected by the
+// Automatic Persisted Queries protocol.
+func queryHash(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// hasErrorCode reports whether one of the response's errors carries the given extension code
+// or, for servers that don't set one, the given message.
+func hasErrorCode(response Response, code, message string) bool {
+	for _, err := range response.Errors {
+		if err.Extensions["code"] == code || err.Message == message {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package ggql
+
+import (
+	"context"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+)
+
+// DoAsync sends the request like DoCtx in a new goroutine and returns a channel receiving its
+// result. The channel is buffered and closed once the result is sent, so the caller may
+// select on several requests at once, or abandon the result without leaking the goroutine.
+// Cancelling the context aborts the in-flight request.
+func (request Request) DoAsync(ctx context.Context) <-chan mo.Result[gjson.Result] {
+	result := make(chan mo.Result[gjson.Result], 1)
+	go func() {
+		defer close(result)
+		result <- request.DoCtx(ctx)
+	}()
+	return result
+}
@@ -0,0 +1,93 @@
+package ggql
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+)
+
+// TokenSource returns the token sent in the Authorization header of a request. It is invoked
+// for every request, so implementations can cache and refresh expiring tokens.
+type TokenSource func(ctx context.Context) (string, error)
+
+// authorizer computes the value of the Authorization header of a request.
+type authorizer func(ctx context.Context) (string, error)
+
+// WithBearerToken authenticates the request with a bearer token obtained from source right
+// before the request is sent. This makes rotating or expiring tokens, such as OAuth2 client
+// credentials, work without manual header juggling. Like the deduplication of the client,
+// its response cache is bypassed by requests carrying their own credentials. The modified
+// Request is returned.
+func (request Request) WithBearerToken(source TokenSource) Request {
+	request.auth = bearerAuthorizer(source)
+	return request
+}
+
+// WithBasicAuth authenticates the request with HTTP basic authentication. Like
+// WithBearerToken, it makes the request bypass the client's response cache and
+// deduplication. The modified Request is returned.
+func (request Request) WithBasicAuth(username, password string) Request {
+	request.auth = basicAuthorizer(username, password)
+	return request
+}
+
+// WithBearerToken authenticates every request executed through the client with a bearer
+// token obtained from source. Authentication set on a request takes precedence.
+// The updated Client is returned.
+func (client *Client) WithBearerToken(source TokenSource) *Client {
+	client.auth = bearerAuthorizer(source)
+	return client
+}
+
+// WithBasicAuth authenticates every request executed through the client with HTTP basic
+// authentication. Authentication set on a request takes precedence.
+// The updated Client is returned.
+func (client *Client) WithBasicAuth(username, password string) *Client {
+	client.auth = basicAuthorizer(username, password)
+	return client
+}
+
+// bearerAuthorizer returns an authorizer producing "Bearer <token>" values from source.
+func bearerAuthorizer(source TokenSource) authorizer {
+	return func(ctx context.Context) (string, error) {
+		token, err := source(ctx)
+		if err != nil {
+			return "", err
+		}
+		return "Bearer " + token, nil
+	}
+}
+
+// basicAuthorizer returns an authorizer producing HTTP basic authentication values.
+func basicAuthorizer(username, password string) authorizer {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return func(context.Context) (string, error) {
+		return "Basic " + credentials, nil
+	}
+}
+
+// resolveAuth returns the authorizer of the request or, when it has none, of its parent
+// Client, or nil when neither authenticates requests.
+func (request Request) resolveAuth() authorizer {
+	if request.auth == nil && request.client != nil {
+		return request.client.auth
+	}
+	return request.auth
+}
+
+// authorize sets the Authorization header computed by the authorizer of the request or,
+// when it has none, of its parent Client.
+func (request Request) authorize(ctx context.Context, header http.Header) error {
+	auth := request.resolveAuth()
+	if auth == nil {
+		return nil
+	}
+
+	value, err := auth(ctx)
+	if err != nil {
+		return fmt.Errorf("obtaining credentials: %w", err)
+	}
+	header.Set("Authorization", value)
+	return nil
+}
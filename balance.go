@@ -0,0 +1,139 @@
+package ggql
+
+import (
+	"context"
+	"sync"
+)
+
+// BalanceStrategy selects the endpoint of a request among the endpoints of a client, see
+// Client.WithLoadBalancing.
+type BalanceStrategy int
+
+// Strategies of the load balancer.
+const (
+	// RoundRobin sends the requests to the endpoints in turn.
+	RoundRobin BalanceStrategy = iota
+	// LeastPending sends each request to the endpoint with the fewest requests in flight,
+	// in turn among equally loaded endpoints.
+	LeastPending
+)
+
+// loadBalancer spreads the requests of a client among equivalent endpoints.
+type loadBalancer struct {
+	strategy   BalanceStrategy
+	maxPending int
+	endpoints  []string
+	client     *Client
+
+	mu      sync.Mutex
+	next    int
+	pending []int
+	// released is closed and replaced each time a request ends, waking up the requests
+	// waiting for a free endpoint.
+	released chan struct{}
+}
+
+// WithLoadBalancing spreads the requests sent to the client's endpoint among endpoints,
+// a pool of equivalent GraphQL servers or gateways, following strategy. Each attempt of a
+// retried request is balanced anew. When maxPending is positive, no endpoint serves more
+// than maxPending requests at once: requests wait for a free endpoint, or until their
+// context is done. With a circuit breaker, endpoints whose circuit is open are skipped while
+// another endpoint is available. Requests sent to another endpoint than the client's are
+// not balanced. Fewer than two endpoints disable the balancing. The updated Client is
+// returned.
+func (client *Client) WithLoadBalancing(strategy BalanceStrategy, maxPending int, endpoints ...string) *Client {
+	if len(endpoints) < 2 {
+		client.balancer = nil
+		return client
+	}
+	client.balancer = &loadBalancer{
+		strategy:   strategy,
+		maxPending: maxPending,
+		endpoints:  append([]string(nil), endpoints...),
+		client:     client,
+		pending:    make([]int, len(endpoints)),
+		released:   make(chan struct{}),
+	}
+	return client
+}
+
+// middleware returns a Handler sending the requests for the client's endpoint to the
+// endpoint selected by the balancer.
+func (balancer *loadBalancer) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		if request.Endpoint != balancer.client.Endpoint {
+			return next(ctx, request)
+		}
+		index, err := balancer.acquire(ctx)
+		if err != nil {
+			return Response{}, err
+		}
+		defer balancer.release(index)
+		request.Endpoint = balancer.endpoints[index]
+		return next(ctx, request)
+	}
+}
+
+// acquire selects an endpoint with a free slot, waiting for one if needed, and returns its
+// index.
+func (balancer *loadBalancer) acquire(ctx context.Context) (int, error) {
+	for {
+		balancer.mu.Lock()
+		index := balancer.pick()
+		if index >= 0 {
+			balancer.pending[index]++
+			balancer.mu.Unlock()
+			return index, nil
+		}
+		released := balancer.released
+		balancer.mu.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// pick returns the index of the endpoint selected by the strategy, or -1 if every endpoint
+// is full. Endpoints with an open circuit are only selected when no other endpoint is
+// available. It must be called with balancer.mu held.
+func (balancer *loadBalancer) pick() int {
+	best, fallback := -1, -1
+	count := len(balancer.endpoints)
+	for offset := 0; offset < count; offset++ {
+		index := (balancer.next + offset) % count
+		if balancer.maxPending > 0 && balancer.pending[index] >= balancer.maxPending {
+			continue
+		}
+		if balancer.client.CircuitState(balancer.endpoints[index]) == CircuitOpen {
+			if fallback < 0 {
+				fallback = index
+			}
+			continue
+		}
+		if best < 0 || (balancer.strategy == LeastPending && balancer.pending[index] < balancer.pending[best]) {
+			best = index
+		}
+		if balancer.strategy == RoundRobin {
+			break
+		}
+	}
+	if best < 0 {
+		best = fallback
+	}
+	if best >= 0 {
+		balancer.next = (best + 1) % count
+	}
+	return best
+}
+
+// release frees the slot taken on the endpoint at index.
+func (balancer *loadBalancer) release(index int) {
+	balancer.mu.Lock()
+	defer balancer.mu.Unlock()
+	balancer.pending[index]--
+	close(balancer.released)
+	balancer.released = make(chan struct{})
+}
@@ -0,0 +1,172 @@
+package ggql
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"io"
+	"net/http"
+)
+
+// BatchRequest represents several GraphQL operations sent together in a single HTTP call,
+// using the JSON array batching format understood by Apollo Server and compatible servers.
+type BatchRequest struct {
+	Requests []Request
+}
+
+// Batch groups the provided requests into a BatchRequest. The endpoint, headers and
+// *http.Client of the first request are used to send the whole batch. The batch goes
+// through the rate limit and circuit breaker of the client of the first request, taking a
+// single token, but not through the rest of its middleware chain.
+func Batch(requests ...Request) BatchRequest {
+	return BatchRequest{Requests: requests}
+}
+
+// Do sends the batch as a single HTTP POST request whose body is a JSON array of operations,
+// and returns the results in the same order as the requests.
+// It returns an error if the batch is empty, if one of the requests has no query/mutation,
+// or if the server does not answer with one result per operation.
+func (batch BatchRequest) Do() mo.Result[[]gjson.Result] {
+	return batch.DoCtx(context.Background())
+}
+
+// DoCtx behaves like Do but binds the outgoing HTTP request to the provided context.
+func (batch BatchRequest) DoCtx(ctx context.Context) mo.Result[[]gjson.Result] {
+	return mo.TupleToResult(batch.do(ctx, false))
+}
+
+// Execute is the (value, error) counterpart of DoCtx. Like Request.Execute, it fails with an
+// ErrHTTPStatus when the endpoint answers with a non-2xx HTTP status, unless the first
+// request ignores the status.
+func (batch BatchRequest) Execute(ctx context.Context) ([]gjson.Result, error) {
+	return batch.do(ctx, true)
+}
+
+// do performs the HTTP exchange of the batch and splits the response array. execute selects
+// the HTTP status policy of the Execute family.
+func (batch BatchRequest) do(ctx context.Context, execute bool) ([]gjson.Result, error) {
+	if len(batch.Requests) == 0 {
+		return nil, errors.New("no request in batch")
+	}
+
+	contents := make([]content, len(batch.Requests))
+	for i, request := range batch.Requests {
+		if request.Request == "" {
+			return nil, fmt.Errorf("no query/mutation provided for request %d", i)
+		}
+		contents[i] = content{
+			Query:         request.client.withFragments(request.Request),
+			OperationName: request.operationName,
+			Variables:     request.Variables,
+		}
+		err := request.checkAllowlist(contents[i].Query)
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", i, err)
+		}
+		_, err = request.trustedDocument(&contents[i])
+		if err != nil {
+			return nil, fmt.Errorf("request %d: %w", i, err)
+		}
+	}
+
+	first := batch.Requests[0]
+	err := first.checkRequiredHeaders()
+	if err != nil {
+		return nil, err
+	}
+	ctx, end, err := first.client.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer end()
+	if execute {
+		first = first.failingOnHTTPStatus()
+	}
+	if timeout := first.resolveTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	payloads := make([]encodedContent, len(contents))
+	for i, c := range contents {
+		payloads[i], err = c.encoded()
+		if err != nil {
+			return nil, fmt.Errorf("encoding request: %w", err)
+		}
+	}
+	reqBuf := getBuffer()
+	defer putBuffer(reqBuf)
+	err = first.resolveCodec().Encode(reqBuf, payloads)
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+	compressed, err := first.compressBody(reqBuf)
+	if err != nil {
+		return nil, fmt.Errorf("compressing request: %w", err)
+	}
+
+	payload := bytes.Clone(reqBuf.Bytes())
+
+	// The exchange goes through the rate limit and circuit breaker of the client, which
+	// protect the endpoint whatever the number of operations.
+	handler := Handler(func(ctx context.Context, first Request) (Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, first.Endpoint, bytes.NewReader(payload))
+		if err != nil {
+			return Response{}, &ErrTransport{Op: "creating request", Err: err}
+		}
+		req.Header = first.header()
+		req.Header.Set("Content-Type", "application/json")
+		if compressed {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		err = first.authorize(ctx, req.Header)
+		if err != nil {
+			return Response{}, err
+		}
+		acceptEncoding(req.Header)
+		err = first.sign(ctx, req)
+		if err != nil {
+			return Response{}, err
+		}
+
+		res, err := first.resolveHTTPClient().Do(req)
+		if err != nil {
+			return Response{}, &ErrTransport{Op: "sending request", Err: err}
+		}
+		defer func(Body io.ReadCloser) {
+			_ = Body.Close()
+		}(res.Body)
+
+		body, err := readBody(res, first.resolveMaxResponseSize())
+		if err != nil {
+			return Response{}, err
+		}
+		response := Response{StatusCode: res.StatusCode, Header: res.Header, Body: body}
+		return response, first.checkHTTPStatus(res, body)
+	})
+	if first.client != nil && first.client.breaker != nil {
+		handler = first.client.breaker.middleware(handler)
+	}
+	if first.client != nil && first.client.limiter != nil {
+		handler = first.client.limiter.middleware(handler)
+	}
+	response, err := handler(ctx, first)
+	if err != nil {
+		return nil, err
+	}
+	body := response.Body
+
+	parsed := gjson.Parse(bytesString(body))
+	if !parsed.IsArray() {
+		return nil, &ErrDecode{Err: errors.New("batch response is not an array")}
+	}
+	results := parsed.Array()
+	if len(results) != len(contents) {
+		return nil, &ErrDecode{Err: fmt.Errorf("batch response has %d results, expected %d", len(results), len(contents))}
+	}
+	return results, nil
+}
@@ -0,0 +1,55 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBatchTakesRateLimitToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[{"data":{"a":1}},{"data":{"b":2}}]`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL).WithRateLimitNoWait(0.001, 1)
+	batch := Batch(client.NewRequest().Query(`{ a }`), client.NewRequest().Query(`{ b }`))
+
+	_, err := batch.Execute(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = batch.Execute(context.Background())
+	var limited *ErrRateLimited
+	if !errors.As(err, &limited) {
+		t.Errorf("got error %v, want an *ErrRateLimited", err)
+	}
+}
+
+func TestBatchOpensCircuit(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL).WithCircuitBreaker(1, time.Minute)
+	batch := Batch(client.NewRequest().Query(`{ a }`))
+
+	_, err := batch.Execute(context.Background())
+	var status *ErrHTTPStatus
+	if !errors.As(err, &status) {
+		t.Fatalf("got error %v, want an *ErrHTTPStatus", err)
+	}
+	_, err = batch.Execute(context.Background())
+	var open *ErrCircuitOpen
+	if !errors.As(err, &open) {
+		t.Errorf("got error %v, want an *ErrCircuitOpen", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("got %d server hits, want 1", hits.Load())
+	}
+}
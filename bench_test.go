@@ -0,0 +1,85 @@
+package ggql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// benchmarkBody is a response body of a realistic size: a list of 100 users.
+var benchmarkBody = func() []byte {
+	var builder strings.Builder
+	builder.WriteString(`{"data":{"users":[`)
+	for i := 0; i < 100; i++ {
+		if i > 0 {
+			builder.WriteString(",")
+		}
+		builder.WriteString(`{"id":"user-` + strconv.Itoa(i) + `","name":"Ada Lovelace","email":"ada@example.com","active":true}`)
+	}
+	builder.WriteString(`]}}`)
+	return []byte(builder.String())
+}()
+
+// BenchmarkDo measures a whole exchange with a local server, from the encoding of the
+// request payload to the parsing of the response.
+func BenchmarkDo(b *testing.B) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(benchmarkBody)
+	}))
+	defer server.Close()
+
+	request := NewClient(server.URL).NewRequest().
+		Query(`query Users($first: Int!) { users(first: $first) { id name email active } }`).
+		AddVariable("first", 100)
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkBody)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		response, err := request.ExecuteResponse(ctx)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(response.Data.Get("users").Array()) != 100 {
+			b.Fatal("unexpected response")
+		}
+	}
+}
+
+// BenchmarkParseResponse measures the parsing of a response body, which shares its memory
+// with the results instead of copying it.
+func BenchmarkParseResponse(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkBody)))
+	for i := 0; i < b.N; i++ {
+		response, err := parseResponse(benchmarkBody)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !response.Data.Exists() {
+			b.Fatal("unexpected response")
+		}
+	}
+}
+
+// BenchmarkEncodeContent measures the encoding of a request payload into a pooled buffer.
+func BenchmarkEncodeContent(b *testing.B) {
+	c := content{
+		Query:     `query Users($first: Int!) { users(first: $first) { id name email active } }`,
+		Variables: map[string]any{"first": 100, "filter": map[string]any{"active": true}},
+	}
+	codec := JSONCodec{}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf := getBuffer()
+		_, err := encodeContent(buf, c, codec)
+		if err != nil {
+			b.Fatal(err)
+		}
+		putBuffer(buf)
+	}
+}
@@ -0,0 +1,190 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// CircuitState is the state of the circuit breaker of an endpoint.
+type CircuitState int
+
+// States of a circuit breaker. A closed circuit lets requests through; an open circuit
+// rejects them with ErrCircuitOpen; a half-open circuit lets a single probe request through
+// to decide whether the circuit closes again or reopens.
+const (
+	CircuitClosed CircuitState = iota
+	CircuitOpen
+	CircuitHalfOpen
+)
+
+// String returns the name of the state.
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// circuitBreaker tracks one circuit per endpoint.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	circuits  map[string]*circuit
+}
+
+// circuit holds the state of the breaker of a single endpoint.
+type circuit struct {
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// WithCircuitBreaker enables a circuit breaker on every endpoint reached by the client.
+// After threshold consecutive failures, the circuit of the endpoint opens and requests fail
+// immediately with ErrCircuitOpen instead of reaching the endpoint. Once cooldown has
+// elapsed, a single probe request is let through: its success closes the circuit, its
+// failure reopens it for another cooldown.
+// Transport errors, timeouts and 5xx HTTP statuses count as failures; GraphQL errors and
+// requests canceled by the caller do not. Batches count as a single request (see Batch),
+// while subscriptions are not guarded. A zero or negative threshold disables the breaker.
+// The updated Client is returned.
+func (client *Client) WithCircuitBreaker(threshold int, cooldown time.Duration) *Client {
+	if threshold <= 0 {
+		client.breaker = nil
+		return client
+	}
+	client.breaker = &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		circuits:  make(map[string]*circuit),
+	}
+	return client
+}
+
+// CircuitState returns the state of the circuit of the given endpoint. Endpoints never
+// reached, and every endpoint when the breaker is disabled, are reported as closed.
+func (client *Client) CircuitState(endpoint string) CircuitState {
+	if client.breaker == nil {
+		return CircuitClosed
+	}
+	client.breaker.mu.Lock()
+	defer client.breaker.mu.Unlock()
+	c, ok := client.breaker.circuits[endpoint]
+	if !ok {
+		return CircuitClosed
+	}
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= client.breaker.cooldown {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// middleware returns a Handler rejecting the requests to endpoints whose circuit is open
+// and recording the outcome of the requests passed to next.
+func (breaker *circuitBreaker) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		probe, err := breaker.allow(request.Endpoint)
+		if err != nil {
+			return Response{}, err
+		}
+
+		response, err := next(ctx, request)
+		switch {
+		case isCircuitFailure(response, err):
+			breaker.failure(request.Endpoint, probe)
+		case err != nil && probe:
+			// The probe was canceled by the caller: let another request probe the endpoint.
+			breaker.release(request.Endpoint)
+		case err == nil:
+			breaker.success(request.Endpoint)
+		}
+		return response, err
+	}
+}
+
+// allow reports whether a request to endpoint may be sent, and whether it is the probe of
+// a half-open circuit.
+func (breaker *circuitBreaker) allow(endpoint string) (bool, error) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	c, ok := breaker.circuits[endpoint]
+	if !ok {
+		c = &circuit{}
+		breaker.circuits[endpoint] = c
+	}
+
+	switch c.state {
+	case CircuitOpen:
+		if time.Since(c.openedAt) < breaker.cooldown {
+			return false, &ErrCircuitOpen{Endpoint: endpoint, RetryAt: c.openedAt.Add(breaker.cooldown)}
+		}
+		c.state = CircuitHalfOpen
+		c.probing = true
+		return true, nil
+	case CircuitHalfOpen:
+		if c.probing {
+			return false, &ErrCircuitOpen{Endpoint: endpoint}
+		}
+		c.probing = true
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// success closes the circuit of endpoint.
+func (breaker *circuitBreaker) success(endpoint string) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	c := breaker.circuits[endpoint]
+	c.state = CircuitClosed
+	c.failures = 0
+	c.probing = false
+}
+
+// failure records a failed request to endpoint, opening its circuit once the threshold
+// is reached or when the failed request was the probe of a half-open circuit.
+func (breaker *circuitBreaker) failure(endpoint string, probe bool) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	c := breaker.circuits[endpoint]
+	c.failures++
+	if probe || c.failures >= breaker.threshold {
+		c.state = CircuitOpen
+		c.openedAt = time.Now()
+		c.probing = false
+	}
+}
+
+// release frees the probe slot of the half-open circuit of endpoint.
+func (breaker *circuitBreaker) release(endpoint string) {
+	breaker.mu.Lock()
+	defer breaker.mu.Unlock()
+	breaker.circuits[endpoint].probing = false
+}
+
+// isCircuitFailure reports whether the outcome of a request counts as a failure of the
+// endpoint.
+func isCircuitFailure(response Response, err error) bool {
+	var httpStatus *ErrHTTPStatus
+	switch {
+	case err == nil:
+		return response.StatusCode >= 500
+	case errors.As(err, &httpStatus):
+		return httpStatus.Code >= 500
+	case errors.Is(err, context.Canceled):
+		return false
+	default:
+		return ErrorClass(err) == ErrorClassTransport || ErrorClass(err) == ErrorClassTimeout
+	}
+}
@@ -0,0 +1,284 @@
+package ggql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// OperationBuilder builds a GraphQL operation document programmatically. It is created by Query,
+// Mutation or Subscription with a first root field, to which Arg, Alias and Select apply.
+// Like Request, OperationBuilder is an immutable value: every method returns a modified copy.
+//
+//	document := ggql.Query("user").Name("GetUser").Var("id", "ID!").Arg("id", "$id").
+//		Select("name", ggql.Field("posts").Select("title")).String()
+//	// query GetUser($id: ID!) { user(id: $id) { name posts { title } } }
+type OperationBuilder struct {
+	kind      string
+	name      string
+	variables []variableDefinition
+	fields    []FieldBuilder
+}
+
+// variableDefinition is a variable declared by an operation, with its GraphQL type.
+type variableDefinition struct {
+	name, kind string
+	value      any
+}
+
+// FieldBuilder builds a field of a selection set, see Field.
+type FieldBuilder struct {
+	name       string
+	alias      string
+	arguments  []argument
+	selections []any
+}
+
+// argument is an argument passed to a field.
+type argument struct {
+	name  string
+	value any
+}
+
+// Enum is an argument value rendered as a GraphQL enum value rather than as a string.
+type Enum string
+
+// Query starts a query operation whose first root field is field.
+func Query(field string) OperationBuilder {
+	return OperationBuilder{kind: "query", fields: []FieldBuilder{Field(field)}}
+}
+
+// Mutation starts a mutation operation whose first root field is field.
+func Mutation(field string) OperationBuilder {
+	return OperationBuilder{kind: "mutation", fields: []FieldBuilder{Field(field)}}
+}
+
+// Subscription starts a subscription operation whose first root field is field.
+func Subscription(field string) OperationBuilder {
+	return OperationBuilder{kind: "subscription", fields: []FieldBuilder{Field(field)}}
+}
+
+// Field starts a field, to be passed to Select or OperationBuilder.And.
+func Field(name string) FieldBuilder {
+	return FieldBuilder{name: name}
+}
+
+// Name sets the name of the operation. The modified OperationBuilder is returned.
+func (operation OperationBuilder) Name(name string) OperationBuilder {
+	operation.name = name
+	return operation
+}
+
+// Var declares a variable of the operation with its GraphQL type, such as "ID!" or
+// "[String!]". Declared variables are referenced in arguments as "$name".
+// The modified OperationBuilder is returned.
+func (operation OperationBuilder) Var(name, graphQLType string) OperationBuilder {
+	operation.variables = append(operation.variables[:len(operation.variables):len(operation.variables)], variableDefinition{name: name, kind: graphQLType})
+	return operation
+}
+
+// VarDefault behaves like Var and sets the default value of the variable.
+// The modified OperationBuilder is returned.
+func (operation OperationBuilder) VarDefault(name, graphQLType string, value any) OperationBuilder {
+	operation.variables = append(operation.variables[:len(operation.variables):len(operation.variables)], variableDefinition{name: name, kind: graphQLType, value: value})
+	return operation
+}
+
+// Arg adds an argument to the first root field, see FieldBuilder.Arg.
+// The modified OperationBuilder is returned.
+func (operation OperationBuilder) Arg(name string, value any) OperationBuilder {
+	operation.fields = append([]FieldBuilder(nil), operation.fields...)
+	operation.fields[0] = operation.fields[0].Arg(name, value)
+	return operation
+}
+
+// Alias sets the alias of the first root field. The modified OperationBuilder is returned.
+func (operation OperationBuilder) Alias(alias string) OperationBuilder {
+	operation.fields = append([]FieldBuilder(nil), operation.fields...)
+	operation.fields[0] = operation.fields[0].Alias(alias)
+	return operation
+}
+
+// Select adds selections to the first root field, see FieldBuilder.Select.
+// The modified OperationBuilder is returned.
+func (operation OperationBuilder) Select(selections ...any) OperationBuilder {
+	operation.fields = append([]FieldBuilder(nil), operation.fields...)
+	operation.fields[0] = operation.fields[0].Select(selections...)
+	return operation
+}
+
+// And adds other root fields to the operation. The modified OperationBuilder is returned.
+func (operation OperationBuilder) And(fields ...FieldBuilder) OperationBuilder {
+	operation.fields = append(operation.fields[:len(operation.fields):len(operation.fields)], fields...)
+	return operation
+}
+
+// String renders the operation as a GraphQL document, on a single line.
+func (operation OperationBuilder) String() string {
+	var builder strings.Builder
+	builder.WriteString(operation.kind)
+	if operation.name != "" {
+		builder.WriteString(" ")
+		builder.WriteString(operation.name)
+	}
+	if len(operation.variables) > 0 {
+		builder.WriteString("(")
+		for i, variable := range operation.variables {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString("$" + variable.name + ": " + variable.kind)
+			if variable.value != nil {
+				builder.WriteString(" = ")
+				writeValue(&builder, variable.value)
+			}
+		}
+		builder.WriteString(")")
+	}
+	builder.WriteString(" ")
+	writeSelections(&builder, fieldSelections(operation.fields))
+	return builder.String()
+}
+
+// Alias sets the alias of the field. The modified FieldBuilder is returned.
+func (field FieldBuilder) Alias(alias string) FieldBuilder {
+	field.alias = alias
+	return field
+}
+
+// Arg adds an argument to the field. Strings starting with "$" reference variables, Enum
+// values are rendered as enum values, and other values are rendered as GraphQL literals:
+// strings, numbers, booleans, nil, slices, and maps and structs as input objects. The fields
+// of structs are named and omitted like with VariablesFromStruct.
+// The modified FieldBuilder is returned.
+func (field FieldBuilder) Arg(name string, value any) FieldBuilder {
+	field.arguments = append(field.arguments[:len(field.arguments):len(field.arguments)], argument{name: name, value: value})
+	return field
+}
+
+// Select adds selections to the field. A selection is either a field name, a FieldBuilder,
+// or a fragment spread such as "...UserFields". The modified FieldBuilder is returned.
+func (field FieldBuilder) Select(selections ...any) FieldBuilder {
+	field.selections = append(field.selections[:len(field.selections):len(field.selections)], selections...)
+	return field
+}
+
+// String renders the field and its selections.
+func (field FieldBuilder) String() string {
+	var builder strings.Builder
+	writeField(&builder, field)
+	return builder.String()
+}
+
+// fieldSelections converts fields to a list of selections.
+func fieldSelections(fields []FieldBuilder) []any {
+	selections := make([]any, len(fields))
+	for i, field := range fields {
+		selections[i] = field
+	}
+	return selections
+}
+
+// writeSelections renders a selection set.
+func writeSelections(builder *strings.Builder, selections []any) {
+	builder.WriteString("{")
+	for _, selection := range selections {
+		builder.WriteString(" ")
+		switch s := selection.(type) {
+		case FieldBuilder:
+			writeField(builder, s)
+		case *FieldBuilder:
+			writeField(builder, *s)
+		default:
+			fmt.Fprint(builder, s)
+		}
+	}
+	builder.WriteString(" }")
+}
+
+// writeField renders a field with its alias, arguments and selections.
+func writeField(builder *strings.Builder, field FieldBuilder) {
+	if field.alias != "" {
+		builder.WriteString(field.alias + ": ")
+	}
+	builder.WriteString(field.name)
+	if len(field.arguments) > 0 {
+		builder.WriteString("(")
+		for i, argument := range field.arguments {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(argument.name + ": ")
+			writeValue(builder, argument.value)
+		}
+		builder.WriteString(")")
+	}
+	if len(field.selections) > 0 {
+		builder.WriteString(" ")
+		writeSelections(builder, field.selections)
+	}
+}
+
+// writeValue renders a value as a GraphQL literal or variable reference.
+func writeValue(builder *strings.Builder, value any) {
+	switch v := value.(type) {
+	case nil:
+		builder.WriteString("null")
+	case Enum:
+		builder.WriteString(string(v))
+	case string:
+		if strings.HasPrefix(v, "$") {
+			builder.WriteString(v)
+			return
+		}
+		encoded, _ := json.Marshal(v)
+		builder.Write(encoded)
+	case []any:
+		builder.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			writeValue(builder, item)
+		}
+		builder.WriteString("]")
+	case []string:
+		items := make([]any, len(v))
+		for i, item := range v {
+			items[i] = item
+		}
+		writeValue(builder, items)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		builder.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				builder.WriteString(", ")
+			}
+			builder.WriteString(key + ": ")
+			writeValue(builder, v[key])
+		}
+		builder.WriteString("}")
+	default:
+		// Structs, typed maps and slices are converted like variables, so that they are
+		// rendered as input objects and lists, with unquoted field names.
+		converted := variableValue(reflect.ValueOf(v))
+		switch converted.(type) {
+		case map[string]any, []any:
+			writeValue(builder, converted)
+			return
+		}
+		encoded, err := json.Marshal(converted)
+		if err != nil {
+			fmt.Fprint(builder, v)
+			return
+		}
+		builder.Write(encoded)
+	}
+}
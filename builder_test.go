@@ -0,0 +1,44 @@
+package ggql
+
+import (
+	"testing"
+)
+
+func TestFieldArgumentLiterals(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type input struct {
+		ID       int              `json:"id"`
+		Name     string           `json:"name,omitempty"`
+		Secret   string           `json:"-"`
+		Address  *address         `json:"address"`
+		Tags     []string         `json:"tags"`
+		Labels   map[string]int   `json:"labels"`
+		Optional Optional[string] `json:"optional"`
+	}
+
+	for _, test := range []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"variable", "$id", `$id`},
+		{"string", "a \"b\"", `"a \"b\""`},
+		{"enum", Enum("ACTIVE"), `ACTIVE`},
+		{"typed slice", []int{1, 2}, `[1, 2]`},
+		{"typed map", map[string]bool{"b": true, "a": false}, `{a: false, b: true}`},
+		{"pointer", &[]string{"x"}, `["x"]`},
+		{
+			"struct",
+			input{ID: 1, Secret: "s", Address: &address{City: "Paris"}, Labels: map[string]int{"x": 1}},
+			`{address: {city: "Paris"}, id: 1, labels: {x: 1}, tags: null}`,
+		},
+	} {
+		got := Query("f").Arg("v", test.value).Select("x").String()
+		want := `query { f(v: ` + test.want + `) { x } }`
+		if got != want {
+			t.Errorf("%s: got %s, want %s", test.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package ggql
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// Priorities of requests, see Request.WithPriority. Any other value may be used: requests of
+// higher priority are scheduled first.
+const (
+	PriorityBackground = -10
+	PriorityNormal     = 0
+	PriorityHigh       = 10
+)
+
+// bulkhead caps the number of requests of a client in flight at once. The requests waiting
+// for a slot are queued by priority.
+type bulkhead struct {
+	mu           sync.Mutex
+	limit        int
+	inFlight     int
+	maxQueued    int
+	queueTimeout time.Duration
+	waiters      waiterQueue
+	sequence     uint64
+}
+
+// waiter is a request queued for a slot. Its ready channel is closed when the slot of a
+// completed request is handed over to it.
+type waiter struct {
+	priority int
+	sequence uint64
+	ready    chan struct{}
+	index    int
+}
+
+// WithMaxConcurrency limits the requests of the client in flight at once to n, protecting
+// the endpoint and the local process from unbounded fan-out. Requests exceeding the limit
+// wait for a request in flight to complete, or until their context is done; waiting
+// requests get the freed slots by decreasing priority, then in arrival order (see
+// Request.WithPriority). Each attempt of a retried request takes its own slot, and
+// responses served from the client's cache don't take any. Batches and subscriptions are
+// not limited. A zero or negative n disables the limit. The updated Client is returned.
+func (client *Client) WithMaxConcurrency(n int) *Client {
+	return client.WithMaxConcurrencyQueue(n, -1, 0)
+}
+
+// WithMaxConcurrencyQueue behaves like WithMaxConcurrency, but bounds the queue of the
+// requests waiting for a slot: at most maxQueued requests wait, a negative maxQueued
+// leaving the queue unbounded and zero failing every request exceeding the limit, and they
+// wait for queueTimeout at most when positive. Requests rejected by the limit fail with
+// ErrConcurrencyLimited. The updated Client is returned.
+func (client *Client) WithMaxConcurrencyQueue(n, maxQueued int, queueTimeout time.Duration) *Client {
+	if n <= 0 {
+		client.bulkhead = nil
+		return client
+	}
+	client.bulkhead = &bulkhead{
+		limit:        n,
+		maxQueued:    maxQueued,
+		queueTimeout: queueTimeout,
+	}
+	return client
+}
+
+// WithPriority sets the priority of the request in the queue of the client's concurrency
+// limit, see Client.WithMaxConcurrency: when every slot is taken, requests of higher
+// priority, such as user-facing reads, get the freed slots before the queued requests of
+// lower priority, such as background refreshes. Requests have PriorityNormal by default.
+// The priority has no effect without concurrency limit. The modified Request is returned.
+func (request Request) WithPriority(priority int) Request {
+	request.priority = priority
+	return request
+}
+
+// middleware returns a Handler taking a slot before calling next and releasing it once next
+// returns.
+func (bulkhead *bulkhead) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		err := bulkhead.acquire(ctx, request.priority)
+		if err != nil {
+			return Response{}, err
+		}
+		defer bulkhead.release()
+		return next(ctx, request)
+	}
+}
+
+// acquire takes a slot, queuing for one with priority when they are all taken.
+func (bulkhead *bulkhead) acquire(ctx context.Context, priority int) error {
+	bulkhead.mu.Lock()
+	if bulkhead.inFlight < bulkhead.limit {
+		bulkhead.inFlight++
+		bulkhead.mu.Unlock()
+		return nil
+	}
+	if bulkhead.maxQueued >= 0 && len(bulkhead.waiters) >= bulkhead.maxQueued {
+		bulkhead.mu.Unlock()
+		return &ErrConcurrencyLimited{Limit: bulkhead.limit}
+	}
+	bulkhead.sequence++
+	waiter := &waiter{priority: priority, sequence: bulkhead.sequence, ready: make(chan struct{})}
+	heap.Push(&bulkhead.waiters, waiter)
+	bulkhead.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if bulkhead.queueTimeout > 0 {
+		timer := time.NewTimer(bulkhead.queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	var err error
+	select {
+	case <-waiter.ready:
+		return nil
+	case <-timeout:
+		err = &ErrConcurrencyLimited{Limit: bulkhead.limit, Waited: bulkhead.queueTimeout}
+	case <-ctx.Done():
+		err = &ErrConcurrencyLimited{Limit: bulkhead.limit, Err: ctx.Err()}
+	}
+
+	bulkhead.mu.Lock()
+	defer bulkhead.mu.Unlock()
+	if waiter.index < 0 {
+		// The slot was handed over while giving up: pass it on.
+		bulkhead.handOver()
+	} else {
+		heap.Remove(&bulkhead.waiters, waiter.index)
+	}
+	return err
+}
+
+// release frees a slot, handing it over to the first queued request if any.
+func (bulkhead *bulkhead) release() {
+	bulkhead.mu.Lock()
+	defer bulkhead.mu.Unlock()
+	bulkhead.handOver()
+}
+
+// handOver gives the slot of a completed request to the first queued request, or frees it
+// when none is queued. It must be called with the lock held.
+func (bulkhead *bulkhead) handOver() {
+	if len(bulkhead.waiters) == 0 {
+		bulkhead.inFlight--
+		return
+	}
+	waiter := heap.Pop(&bulkhead.waiters).(*waiter)
+	close(waiter.ready)
+}
+
+// waiterQueue is a heap of waiters ordered by decreasing priority, then arrival order.
+type waiterQueue []*waiter
+
+// Len implements heap.Interface.
+func (queue waiterQueue) Len() int {
+	return len(queue)
+}
+
+// Less implements heap.Interface.
+func (queue waiterQueue) Less(i, j int) bool {
+	if queue[i].priority != queue[j].priority {
+		return queue[i].priority > queue[j].priority
+	}
+	return queue[i].sequence < queue[j].sequence
+}
+
+// Swap implements heap.Interface.
+func (queue waiterQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+	queue[i].index = i
+	queue[j].index = j
+}
+
+// Push implements heap.Interface.
+func (queue *waiterQueue) Push(x any) {
+	waiter := x.(*waiter)
+	waiter.index = len(*queue)
+	*queue = append(*queue, waiter)
+}
+
+// Pop implements heap.Interface.
+func (queue *waiterQueue) Pop() any {
+	old := *queue
+	waiter := old[len(old)-1]
+	old[len(old)-1] = nil
+	waiter.index = -1
+	*queue = old[:len(old)-1]
+	return waiter
+}
@@ -0,0 +1,313 @@
+package ggql
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// responseCache is a cache of query responses keyed by endpoint, operation and variables,
+// kept in a CacheStore. Entries expire once their time to live has elapsed, and are then
+// served stale during the stale window while being refreshed in the background.
+type responseCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	stale      time.Duration
+	store      CacheStore
+	refreshing map[string]bool
+}
+
+// cacheWindow is the freshness window of a request set by WithCacheTTL.
+type cacheWindow struct {
+	ttl, stale time.Duration
+}
+
+// WithCache enables a response cache on the client, kept in memory unless another store is
+// set with WithCacheStore. Successful query responses without GraphQL errors nor non-2xx
+// HTTP status are cached for the given time to live, keyed by a hash of the endpoint, query,
+// operation name, variables and of the headers identifying the caller (see
+// WithCacheKeyHeaders). Mutations, subscriptions and requests authenticated with their own
+// credentials (see Request.WithBearerToken) are never cached.
+// A zero or negative ttl disables the cache. The updated Client is returned.
+func (client *Client) WithCache(ttl time.Duration) *Client {
+	if ttl <= 0 {
+		client.cache = nil
+		return client
+	}
+	store := client.cacheStore
+	if store == nil {
+		store = NewMemoryCacheStore()
+	}
+	client.cache = &responseCache{
+		ttl:        ttl,
+		stale:      client.cacheStale,
+		store:      store,
+		refreshing: make(map[string]bool),
+	}
+	return client
+}
+
+// defaultCacheKeyHeaders are the headers identifying the caller of a request, on which the
+// keys of the cached responses depend.
+var defaultCacheKeyHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// WithCacheKeyHeaders adds headers identifying the caller of a request, such as a tenant or
+// API key header, to the ones the keys of the client's caches and deduplicated queries
+// depend on: Authorization, Proxy-Authorization and Cookie. Requests differing by these
+// headers never share a response, while the other headers, such as the trace propagation
+// headers set by middleware, are left out of the keys. Successive calls add to the headers.
+// The updated Client is returned.
+func (client *Client) WithCacheKeyHeaders(names ...string) *Client {
+	client.cacheKeyHeaders = append(client.cacheKeyHeaders, names...)
+	return client
+}
+
+// WithStaleWhileRevalidate extends the response cache enabled by WithCache with a
+// stale-while-revalidate mode, for consumers sensitive to latency: once the time to live of
+// a cached response has elapsed, the response is still served immediately during the given
+// window, while a single background request per operation refreshes it. Responses older
+// than their time to live and window are no longer served. Background refreshes are bounded
+// by the timeout of the request that triggered them, and are waited for by Close. A zero or
+// negative window disables the mode. The window applies whether WithCache is called before
+// or after. The updated Client is returned.
+func (client *Client) WithStaleWhileRevalidate(window time.Duration) *Client {
+	if window < 0 {
+		window = 0
+	}
+	client.cacheStale = window
+	if client.cache != nil {
+		client.cache.mu.Lock()
+		client.cache.stale = window
+		client.cache.mu.Unlock()
+	}
+	return client
+}
+
+// WithCacheTTL sets the freshness window of the request's response in the client's response
+// cache, overriding the time to live set by WithCache and the window set by
+// WithStaleWhileRevalidate, so that volatile and stable queries can be cached differently.
+// A zero or negative ttl keeps the response out of the cache, like NoCache; a zero stale
+// window disables the stale-while-revalidate mode for the request. It has no effect unless
+// the client has a response cache. The modified Request is returned.
+func (request Request) WithCacheTTL(ttl, stale time.Duration) Request {
+	request.cacheWindow = &cacheWindow{ttl: ttl, stale: max(stale, 0)}
+	return request
+}
+
+// Invalidate removes the cached responses of the given requests from the client's caches.
+// Responses are keyed by the headers identifying the caller as well, so the requests must
+// carry the ones they were sent with, see WithCacheKeyHeaders.
+func (client *Client) Invalidate(requests ...Request) {
+	if client.normalized != nil {
+		for _, request := range requests {
+			key, err := cacheKey(request)
+			if err == nil {
+				client.normalized.invalidate(key)
+			}
+		}
+	}
+	if client.cache == nil {
+		return
+	}
+	store := client.cache.backend()
+	for _, request := range requests {
+		key, err := cacheKey(request)
+		if err == nil {
+			_ = store.Delete(key)
+		}
+	}
+}
+
+// PurgeCache removes every response from the client's caches, including the responses stored
+// for revalidation by WithETagCache, and clears the store set by WithCacheStore.
+func (client *Client) PurgeCache() {
+	if client.etags != nil {
+		client.etags.mu.Lock()
+		client.etags.entries = make(map[string]*list.Element)
+		client.etags.order.Init()
+		client.etags.mu.Unlock()
+	}
+	if client.normalized != nil {
+		client.normalized.purge()
+	}
+	store := client.cacheStore
+	if client.cache != nil {
+		store = client.cache.backend()
+	}
+	if store != nil {
+		_ = store.Clear()
+	}
+}
+
+// NoCache makes the request bypass the client's response cache: the request is always sent
+// to the endpoint and its response is not stored. The modified Request is returned.
+func (request Request) NoCache() Request {
+	request.noCache = true
+	return request
+}
+
+// middleware returns a Handler serving cached responses for cacheable requests and storing
+// the successful responses returned by next.
+func (cache *responseCache) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		if request.noCache || request.auth != nil || operationType(request.Request, request.operationName) != "query" {
+			return next(ctx, request)
+		}
+		window := cache.window(request)
+		if window.ttl <= 0 {
+			return next(ctx, request)
+		}
+		key, err := cacheKey(request)
+		if err != nil {
+			return next(ctx, request)
+		}
+
+		if response, fresh, ok := cache.get(key); ok {
+			if !fresh {
+				cache.refresh(ctx, key, request, window, next)
+			}
+			return response, nil
+		}
+
+		response, err := next(ctx, request)
+		cache.set(key, window, response, err)
+		return response, err
+	}
+}
+
+// window returns the freshness window of the request: the one set by WithCacheTTL, or the
+// one of the cache.
+func (cache *responseCache) window(request Request) cacheWindow {
+	if request.cacheWindow != nil {
+		return *request.cacheWindow
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cacheWindow{ttl: cache.ttl, stale: cache.stale}
+}
+
+// refresh sends the request in the background to replace the stale response stored under key,
+// unless a refresh of key is already running. The refresh is detached from the cancellation
+// of ctx but keeps the timeout of the request, and is tracked by the lifecycle of the client
+// so that Close waits for it.
+func (cache *responseCache) refresh(ctx context.Context, key string, request Request, window cacheWindow, next Handler) {
+	cache.mu.Lock()
+	if cache.refreshing[key] {
+		cache.mu.Unlock()
+		return
+	}
+	cache.refreshing[key] = true
+	cache.mu.Unlock()
+
+	ctx, end, err := request.client.begin(context.WithoutCancel(ctx))
+	if err != nil {
+		cache.mu.Lock()
+		delete(cache.refreshing, key)
+		cache.mu.Unlock()
+		return
+	}
+	go func() {
+		defer end()
+		if timeout := request.resolveTimeout(); timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		response, err := next(ctx, request)
+		cache.set(key, window, response, err)
+		cache.mu.Lock()
+		delete(cache.refreshing, key)
+		cache.mu.Unlock()
+	}()
+}
+
+// backend returns the store of the cache.
+func (cache *responseCache) backend() CacheStore {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	return cache.store
+}
+
+// get returns the cached response stored under key and whether it is still fresh, if it is
+// fresh or within its stale window. Entries that cannot be read are treated as missing.
+func (cache *responseCache) get(key string) (response Response, fresh, ok bool) {
+	store := cache.backend()
+	entry, ok, err := store.Get(key)
+	if err != nil || !ok {
+		return Response{}, false, false
+	}
+	now := time.Now()
+	if now.After(entry.StaleUntil) {
+		_ = store.Delete(key)
+		return Response{}, false, false
+	}
+	response, err = parseResponse(entry.Body)
+	if err != nil {
+		return Response{}, false, false
+	}
+	response.StatusCode = entry.StatusCode
+	response.Header = entry.Header
+	return response, !now.After(entry.Expires), true
+}
+
+// set stores the response under key for the given window if the request succeeded without
+// GraphQL errors nor non-2xx HTTP status. Failures of the store are ignored, the response
+// being fetched again by the next request.
+func (cache *responseCache) set(key string, window cacheWindow, response Response, err error) {
+	if err != nil || response.HasErrors() || response.StatusCode >= 300 {
+		return
+	}
+	body := response.Body
+	if len(body) == 0 {
+		// Responses built by custom transports may only carry their parsed form.
+		body = []byte(response.Raw.Raw)
+	}
+	expires := time.Now().Add(window.ttl)
+	_ = cache.backend().Set(key, CacheEntry{
+		Body:       body,
+		StatusCode: response.StatusCode,
+		Header:     response.Header,
+		Expires:    expires,
+		StaleUntil: expires.Add(window.stale),
+	})
+}
+
+// cacheKey returns the hex-encoded SHA-256 hash identifying the request's operation and the
+// caller sending it: its endpoint, query, operation name, variables and the headers
+// identifying the caller, set on the client, the request or per call. Requests sent with
+// different credentials, cookies or tenant headers thus never share an entry.
+func cacheKey(request Request) (string, error) {
+	variables, err := json.Marshal(request.Variables)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	for _, part := range [][]byte{[]byte(request.Endpoint), []byte(request.Request), []byte(request.operationName), variables, []byte(callerKey(request))} {
+		hash.Write(part)
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// callerKey returns a deterministic representation of the headers of the request
+// identifying its caller, see WithCacheKeyHeaders.
+func callerKey(request Request) string {
+	names := defaultCacheKeyHeaders
+	if request.client != nil {
+		names = append(names[:len(names):len(names)], request.client.cacheKeyHeaders...)
+	}
+	header := request.header()
+	caller := make(http.Header, len(names))
+	for _, name := range names {
+		values := header.Values(name)
+		if len(values) > 0 {
+			caller[http.CanonicalHeaderKey(name)] = values
+		}
+	}
+	return headerKey(caller)
+}
@@ -0,0 +1,99 @@
+package ggql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer answers every request with the number of requests received so far.
+func countingServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count := hits.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"hits":` + strconv.Itoa(int(count)) + `}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCacheKeysOnCallerHeaders(t *testing.T) {
+	var hits atomic.Int32
+	client := NewClient(countingServer(t, &hits).URL).WithCache(time.Minute).WithCacheKeyHeaders("X-Tenant")
+	query := client.NewRequest().Query(`{ hits }`)
+
+	for _, test := range []struct {
+		name string
+		req  Request
+		want int64
+	}{
+		{"first caller", query.AddHeader("Authorization", "Bearer a"), 1},
+		{"other token", query.AddHeader("Authorization", "Bearer b"), 2},
+		{"first caller again", query.AddHeader("Authorization", "Bearer a"), 1},
+		{"other cookie", query.AddHeader("Authorization", "Bearer a").AddHeader("Cookie", "session=c"), 3},
+		{"listed header", query.AddHeader("Authorization", "Bearer a").AddHeader("X-Tenant", "acme"), 4},
+	} {
+		response, err := test.req.ExecuteResponse(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := response.Data.Get("hits").Int()
+		if got != test.want {
+			t.Errorf("%s: got response %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCacheIgnoresOtherHeaders(t *testing.T) {
+	var hits atomic.Int32
+	var traces atomic.Int32
+	tracing := func(next Handler) Handler {
+		return func(ctx context.Context, request Request) (Response, error) {
+			trace := strconv.Itoa(int(traces.Add(1)))
+			return next(ctx, request.AddHeader("Traceparent", "00-"+trace+"-01"))
+		}
+	}
+	client := NewClient(countingServer(t, &hits).URL).WithCache(time.Minute).Use(tracing)
+
+	for range 3 {
+		_, err := client.NewRequest().Query(`{ hits }`).ExecuteResponse(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hits.Load() != 1 {
+		t.Errorf("got %d server hits, want 1", hits.Load())
+	}
+}
+
+func TestCachesBypassRequestCredentials(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		client func(url string) *Client
+	}{
+		{"response cache", func(url string) *Client { return NewClient(url).WithCache(time.Minute) }},
+		{"normalized cache", func(url string) *Client { return NewClient(url).WithNormalizedCache(time.Minute) }},
+	} {
+		var hits atomic.Int32
+		client := test.client(countingServer(t, &hits).URL)
+		for _, token := range []string{"a", "b"} {
+			source := TokenSource(func(context.Context) (string, error) {
+				return token, nil
+			})
+			response, err := client.NewRequest().Query(`{ hits }`).WithBearerToken(source).ExecuteResponse(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if response.Data.Get("hits").Int() != int64(hits.Load()) {
+				t.Errorf("%s: token %s got a cached response", test.name, token)
+			}
+		}
+		if hits.Load() != 2 {
+			t.Errorf("%s: got %d server hits, want 2", test.name, hits.Load())
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package ggql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheFileExtension is the extension of the files written by the file cache store.
+const cacheFileExtension = ".ggqlcache"
+
+// CacheEntry is an entry of a CacheStore: a cached response with its HTTP status and
+// headers, the time it expires, and the end of its stale window (see
+// WithStaleWhileRevalidate). Entries of the persisted query registry have no body and never
+// expire.
+type CacheEntry struct {
+	Body       []byte      `json:"body,omitempty"`
+	StatusCode int         `json:"status,omitempty"`
+	Header     http.Header `json:"header,omitempty"`
+	Expires    time.Time   `json:"expires"`
+	StaleUntil time.Time   `json:"staleUntil"`
+}
+
+// CacheStore is the backend of the response cache enabled by WithCache and of the registry
+// of Automatic Persisted Queries. Expired entries are removed by the cache when it reads
+// them. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the entry stored under key, and whether it exists.
+	Get(key string) (CacheEntry, bool, error)
+
+	// Set stores the entry under key, replacing any previous one.
+	Set(key string, entry CacheEntry) error
+
+	// Delete removes the entry stored under key, if any.
+	Delete(key string) error
+
+	// Clear removes every entry.
+	Clear() error
+}
+
+// WithCacheStore sets the backend of the client's response cache (see WithCache), which
+// defaults to an in-memory store, such as NewFileCacheStore to keep the cached responses
+// across restarts in CLI tools and desktop applications. The store also backs a registry
+// of the Automatic Persisted Queries known by the endpoints (see WithPersistedQueries):
+// queries missing from the registry are sent in full along with their hash right away
+// instead of after a failed attempt with the hash only, and endpoints that don't support
+// the protocol are remembered, so that restarted processes don't repeat the round trips
+// already made. PurgeCache clears the registry as well. The store applies whether WithCache
+// is called before or after. Passing nil restores the default. The updated Client is
+// returned.
+func (client *Client) WithCacheStore(store CacheStore) *Client {
+	client.cacheStore = store
+	if client.cache != nil {
+		if store == nil {
+			store = NewMemoryCacheStore()
+		}
+		client.cache.mu.Lock()
+		client.cache.store = store
+		client.cache.mu.Unlock()
+	}
+	return client
+}
+
+// memoryCacheStore is the CacheStore returned by NewMemoryCacheStore.
+type memoryCacheStore struct {
+	mu      sync.Mutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCacheStore returns a CacheStore keeping the entries in memory. It is the default
+// store of the response cache.
+func NewMemoryCacheStore() CacheStore {
+	return &memoryCacheStore{entries: make(map[string]CacheEntry)}
+}
+
+// Get implements CacheStore.
+func (store *memoryCacheStore) Get(key string) (CacheEntry, bool, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	entry, ok := store.entries[key]
+	return entry, ok, nil
+}
+
+// Set implements CacheStore.
+func (store *memoryCacheStore) Set(key string, entry CacheEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.entries[key] = entry
+	return nil
+}
+
+// Delete implements CacheStore.
+func (store *memoryCacheStore) Delete(key string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	delete(store.entries, key)
+	return nil
+}
+
+// Clear implements CacheStore.
+func (store *memoryCacheStore) Clear() error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.entries = make(map[string]CacheEntry)
+	return nil
+}
+
+// fileCacheStore is the CacheStore returned by NewFileCacheStore.
+type fileCacheStore struct {
+	dir string
+}
+
+// NewFileCacheStore returns a CacheStore keeping every entry in its own JSON file in the
+// directory dir, named after the hash of its key, so that cached responses survive
+// restarts. Files are replaced atomically, which lets several processes share the
+// directory. The directory is created if it does not exist.
+func NewFileCacheStore(dir string) (CacheStore, error) {
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return nil, err
+	}
+	return &fileCacheStore{dir: dir}, nil
+}
+
+// Get implements CacheStore.
+func (store *fileCacheStore) Get(key string) (CacheEntry, bool, error) {
+	data, err := os.ReadFile(store.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return CacheEntry{}, false, nil
+	}
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+	var entry CacheEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return CacheEntry{}, false, err
+	}
+	return entry, true, nil
+}
+
+// Set implements CacheStore. The file is written to a temporary file first, then renamed.
+func (store *fileCacheStore) Set(key string, entry CacheEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	temp, err := os.CreateTemp(store.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func(name string) {
+		_ = os.Remove(name)
+	}(temp.Name())
+	_, err = temp.Write(data)
+	err = errors.Join(err, temp.Close())
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), store.path(key))
+}
+
+// Delete implements CacheStore.
+func (store *fileCacheStore) Delete(key string) error {
+	err := os.Remove(store.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Clear implements CacheStore. Only the files of the store are removed from the directory.
+func (store *fileCacheStore) Clear() error {
+	entries, err := os.ReadDir(store.dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && strings.HasSuffix(entry.Name(), cacheFileExtension) {
+			err = os.Remove(filepath.Join(store.dir, entry.Name()))
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// path returns the path of the file storing the entry of key.
+func (store *fileCacheStore) path(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return filepath.Join(store.dir, hex.EncodeToString(hash[:])+cacheFileExtension)
+}
@@ -0,0 +1,126 @@
+package ggql
+
+import (
+	"crypto/tls"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Client holds the configuration shared by every request sent to a single GraphQL endpoint.
+// It is meant to be created once and reused, so that the endpoint, authentication headers
+// and HTTP settings don't have to be re-specified for every query.
+type Client struct {
+	Endpoint   string
+	Headers    map[string]string
+	HTTPClient *http.Client
+	Timeout    time.Duration
+
+	middleware       []Middleware
+	requestHooks     []RequestHook
+	responseHooks    []ResponseHook
+	persistedQueries bool
+	cache            *responseCache
+	cacheStale       time.Duration
+	cacheStore       CacheStore
+	cacheKeyHeaders  []string
+	etags            *etagCache
+	normalized       *normalizedCache
+	breaker          *circuitBreaker
+	balancer         *loadBalancer
+	limiter          *rateLimiter
+	bulkhead         *bulkhead
+	retry            *RetryPolicy
+	idempotency      *idempotency
+	fingerprint      fingerprintHeader
+	validator        *validator
+	variableChecker  *variableChecker
+	complexity       *ComplexityLimit
+	fragments        *fragmentRegistry
+	signer           *sigV4Signer
+	requestSigner    RequestSigner
+	trusted          *TrustedDocuments
+	allowlist        *Allowlist
+	dedup            *singleflight.Group
+	reconnect        *ReconnectPolicy
+	keepAlive        *keepAlive
+	subscriptions    *subscriptionPool
+	lifecycle        *lifecycle
+	background       *backgroundWorkers
+	transport        Transport
+	codec            Codec
+	tlsConfig        *tls.Config
+	redirects        *RedirectPolicy
+	requiredHeaders  []string
+	proxy            func(*http.Request) (*url.URL, error)
+	auth             authorizer
+
+	compressionThreshold int
+	maxResponseSize      int64
+	failOnStatus         bool
+	timings              bool
+}
+
+// NewClient initializes a new Client for the specified endpoint with an empty header map.
+// Requests created from the client are sent with http.DefaultClient unless another
+// *http.Client is configured through WithHTTPClient. Endpoints of the form
+// unix:///path/to.sock, or unix:///path/to.sock:/graphql to set the HTTP path, are reached
+// through a Unix domain socket; their requests fail when the transport of the *http.Client
+// is not an *http.Transport, whose dialing is redirected to the socket.
+func NewClient(endpoint string) *Client {
+	return &Client{
+		Endpoint:      endpoint,
+		Headers:       make(map[string]string),
+		subscriptions: newSubscriptionPool(),
+		lifecycle:     newLifecycle(),
+	}
+}
+
+// AddHeader adds a default header to the client. Default headers are sent with every
+// request created from the client, but headers set on the request itself take precedence.
+// The updated Client is returned.
+func (client *Client) AddHeader(key, value string) *Client {
+	client.Headers[key] = value
+	return client
+}
+
+// AddHeaders appends the key-value pairs in the provided headers map to the client's
+// default headers. The updated Client is returned.
+func (client *Client) AddHeaders(headers map[string]string) *Client {
+	for key, value := range headers {
+		client.Headers[key] = value
+	}
+	return client
+}
+
+// WithHTTPClient sets the *http.Client used to send the requests created from the client.
+// Passing nil restores the use of http.DefaultClient. The updated Client is returned.
+func (client *Client) WithHTTPClient(httpClient *http.Client) *Client {
+	client.HTTPClient = httpClient
+	return client
+}
+
+// WithTimeout sets an overall deadline applied to every request executed through the client.
+// A zero duration disables the timeout. The updated Client is returned.
+func (client *Client) WithTimeout(timeout time.Duration) *Client {
+	client.Timeout = timeout
+	return client
+}
+
+// NewRequest initializes a new Request bound to the client. The request targets the
+// client's endpoint and is executed with the client's *http.Client, default headers and timeout.
+func (client *Client) NewRequest() Request {
+	request := NewRequest(client.Endpoint)
+	request.client = client
+	return request
+}
+
+// httpClient returns the *http.Client configured on the client, falling back to
+// http.DefaultClient when none is set.
+func (client *Client) httpClient() *http.Client {
+	if client == nil || client.HTTPClient == nil {
+		return http.DefaultClient
+	}
+	return client.HTTPClient
+}
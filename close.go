@@ -0,0 +1,160 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrClientClosed is returned by the requests of a Client after Close is called.
+var ErrClientClosed = errors.New("client closed")
+
+// lifecycle tracks the requests in flight of a client, so that Close can wait for them.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	inflight int
+	drained  chan struct{}
+
+	// abort is cancelled when Close gives up waiting, cancelling the requests in flight.
+	abort  context.Context
+	cancel context.CancelFunc
+
+	// closing is cancelled as soon as Close is called, completing the subscriptions in flight.
+	closing  context.Context
+	complete context.CancelFunc
+}
+
+// newLifecycle returns the lifecycle of an open client.
+func newLifecycle() *lifecycle {
+	abort, cancel := context.WithCancel(context.Background())
+	closing, complete := context.WithCancel(context.Background())
+	return &lifecycle{
+		drained:  make(chan struct{}),
+		abort:    abort,
+		cancel:   cancel,
+		closing:  closing,
+		complete: complete,
+	}
+}
+
+// begin registers a request in flight, returning the context it must use and the function
+// to call when it ends, or ErrClientClosed once the client is closed.
+func (client *Client) begin(ctx context.Context) (context.Context, func(), error) {
+	if client == nil || client.lifecycle == nil {
+		return ctx, func() {}, nil
+	}
+	state := client.lifecycle
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.closed {
+		return ctx, nil, ErrClientClosed
+	}
+	state.inflight++
+
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(state.abort, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		state.mu.Lock()
+		defer state.mu.Unlock()
+		state.inflight--
+		if state.closed && state.inflight == 0 {
+			close(state.drained)
+		}
+	}, nil
+}
+
+// beginSubscription registers a subscription in flight like begin, but the context it
+// returns is also cancelled as soon as Close is called, so that Close completes the
+// subscription instead of waiting for the server to end it.
+func (client *Client) beginSubscription(ctx context.Context) (context.Context, func(), error) {
+	ctx, end, err := client.begin(ctx)
+	if err != nil {
+		return ctx, nil, err
+	}
+	if client == nil || client.lifecycle == nil {
+		return ctx, end, nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(client.lifecycle.closing, cancel)
+	return ctx, func() {
+		stop()
+		cancel()
+		end()
+	}, nil
+}
+
+// isClosed reports whether Close was called on the client.
+func (client *Client) isClosed() bool {
+	if client == nil || client.lifecycle == nil {
+		return false
+	}
+	client.lifecycle.mu.Lock()
+	defer client.lifecycle.mu.Unlock()
+	return client.lifecycle.closed
+}
+
+// Close shuts the client down gracefully. The background workers first send the requests
+// left in their queue, see Enqueue. New requests and subscriptions then fail with
+// ErrClientClosed, while the active subscriptions are completed: a complete message is sent
+// to the server over WebSocket connections, the streams of Server-Sent Events are closed,
+// and their channels are closed. Close then waits for the requests in flight to end,
+// cancelling them if ctx is done first, in which case the error of ctx is returned. Finally,
+// the idle connections of the client's *http.Client are closed. Calling Close more than
+// once only waits for the requests in flight again.
+func (client *Client) Close(ctx context.Context) error {
+	backgroundErr := client.background.close(ctx)
+	if client.lifecycle == nil {
+		client.subscriptions.close()
+		client.httpClient().CloseIdleConnections()
+		return backgroundErr
+	}
+
+	state := client.lifecycle
+	state.mu.Lock()
+	if !state.closed {
+		state.closed = true
+		state.complete()
+		if state.inflight == 0 {
+			close(state.drained)
+		}
+	}
+	state.mu.Unlock()
+	client.subscriptions.close()
+
+	var err error
+	select {
+	case <-state.drained:
+	case <-ctx.Done():
+		state.cancel()
+		<-state.drained
+		err = ctx.Err()
+	}
+	client.httpClient().CloseIdleConnections()
+	return errors.Join(backgroundErr, err)
+}
+
+// close completes the subscriptions of every connection of the pool and closes them. The
+// pool rejects new subscriptions afterwards.
+func (pool *subscriptionPool) close() {
+	if pool == nil {
+		return
+	}
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	pool.closed = true
+	for _, conn := range pool.conns {
+		conn.mu.Lock()
+		for id, sub := range conn.subs {
+			if conn.ready {
+				_ = conn.write(message{ID: id, Type: messageComplete})
+			}
+			delete(conn.subs, id)
+			sub.done <- nil
+		}
+		conn.close()
+		conn.mu.Unlock()
+	}
+}
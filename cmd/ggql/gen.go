@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	"go/format"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// builtinGoTypes maps the scalars defined by the specification to their Go types.
+var builtinGoTypes = map[string]string{
+	"ID":      "string",
+	"String":  "string",
+	"Int":     "int",
+	"Float":   "float64",
+	"Boolean": "bool",
+}
+
+// scalarFlags collects repeated "-scalar Name=import/path.Type" flags.
+type scalarFlags map[string]string
+
+// String implements flag.Value.
+func (scalars scalarFlags) String() string {
+	pairs := make([]string, 0, len(scalars))
+	for name, goType := range scalars {
+		pairs = append(pairs, name+"="+goType)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value.
+func (scalars scalarFlags) Set(value string) error {
+	name, goType, ok := strings.Cut(value, "=")
+	if !ok || name == "" || goType == "" {
+		return fmt.Errorf("invalid scalar mapping %q, expected Name=import/path.Type", value)
+	}
+	scalars[name] = goType
+	return nil
+}
+
+// runGen implements the gen command.
+func runGen(args []string) error {
+	flags := flag.NewFlagSet("gen", flag.ExitOnError)
+	schemaFlag := flags.String("schema", "", "schema SDL file, or GraphQL endpoint URL to introspect")
+	packageFlag := flags.String("package", "", "name of the generated package (required)")
+	outFlag := flags.String("out", "", "output file (default stdout)")
+	headers := make(headerFlags)
+	flags.Var(headers, "H", "header sent when introspecting the schema, as \"Key: Value\" (repeatable)")
+	scalars := make(scalarFlags)
+	flags.Var(scalars, "scalar", "Go type of a custom scalar, as Name=import/path.Type (repeatable)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ggql gen -schema <file|url> -package <name> [flags] <operations.graphql>...")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if *schemaFlag == "" || *packageFlag == "" || flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	schema, err := loadSchema(*schemaFlag, headers)
+	if err != nil {
+		return err
+	}
+	document, err := loadOperations(schema, flags.Args())
+	if err != nil {
+		return err
+	}
+
+	code, err := generate(schema, document, *packageFlag, scalars)
+	if err != nil {
+		return err
+	}
+	if *outFlag == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return os.WriteFile(*outFlag, code, 0o644)
+}
+
+// loadSchema loads the schema from an SDL file, or by introspecting an endpoint when source
+// is an HTTP URL.
+func loadSchema(source string, headers map[string]string) (*ast.Schema, error) {
+	var sdl string
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		introspection, err := ggql.NewClient(source).AddHeaders(headers).Introspect(context.Background()).Get()
+		if err != nil {
+			return nil, fmt.Errorf("introspecting schema: %w", err)
+		}
+		sdl = ggql.PrintSDL(introspection)
+	} else {
+		content, err := os.ReadFile(source)
+		if err != nil {
+			return nil, fmt.Errorf("reading schema: %w", err)
+		}
+		sdl = string(content)
+	}
+
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: source, Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("loading schema: %w", err)
+	}
+	return schema, nil
+}
+
+// loadOperations parses the operation files into a single document and validates it
+// against the schema, so that fragments can be shared across files.
+func loadOperations(schema *ast.Schema, files []string) (*ast.QueryDocument, error) {
+	document := &ast.QueryDocument{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading operations: %w", err)
+		}
+		parsed, err := parser.ParseQuery(&ast.Source{Name: file, Input: string(content)})
+		if err != nil {
+			return nil, fmt.Errorf("parsing operations: %w", err)
+		}
+		document.Operations = append(document.Operations, parsed.Operations...)
+		document.Fragments = append(document.Fragments, parsed.Fragments...)
+	}
+
+	if errs := validator.Validate(schema, document); len(errs) > 0 {
+		return nil, fmt.Errorf("validating operations: %w", errs)
+	}
+	for _, operation := range document.Operations {
+		if operation.Name == "" {
+			return nil, fmt.Errorf("anonymous %s operation: every operation must be named", operation.Operation)
+		}
+	}
+	return document, nil
+}
+
+// generator renders the Go code of a set of operations.
+type generator struct {
+	schema  *ast.Schema
+	scalars map[string]string
+
+	imports   map[string]bool
+	named     map[string]bool
+	namedCode []string
+}
+
+// generate renders the Go source file holding the types and functions of every operation
+// of the document.
+func generate(schema *ast.Schema, document *ast.QueryDocument, pkg string, scalars map[string]string) ([]byte, error) {
+	g := &generator{
+		schema:  schema,
+		scalars: scalars,
+		imports: map[string]bool{"context": true, "github.com/lance-free/ggql": true},
+		named:   make(map[string]bool),
+	}
+
+	var body bytes.Buffer
+	for _, operation := range document.Operations {
+		body.WriteString(g.operation(operation, document))
+	}
+	for _, code := range g.namedCode {
+		body.WriteString(code)
+	}
+
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, strconv.Quote(path))
+	}
+	sort.Strings(imports)
+
+	var file bytes.Buffer
+	file.WriteString("// Code generated by ggql gen. DO NOT EDIT.\n\n")
+	file.WriteString("package " + pkg + "\n\n")
+	file.WriteString("import (\n\t" + strings.Join(imports, "\n\t") + "\n)\n\n")
+	file.Write(body.Bytes())
+
+	code, err := format.Source(file.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+	return code, nil
+}
+
+// operation renders the document constant, the variables and response types and the request
+// functions of a single operation.
+func (g *generator) operation(operation *ast.OperationDefinition, document *ast.QueryDocument) string {
+	name := goName(operation.Name)
+	var code strings.Builder
+
+	fmt.Fprintf(&code, "// %sDocument is the document of the %s %s.\n", name, operation.Name, operation.Operation)
+	fmt.Fprintf(&code, "const %sDocument = %s\n\n", name, strconv.Quote(operationDocument(operation, document)))
+
+	hasVariables := len(operation.VariableDefinitions) > 0
+	if hasVariables {
+		fmt.Fprintf(&code, "// %sVariables holds the variables of the %s %s.\n", name, operation.Name, operation.Operation)
+		fmt.Fprintf(&code, "type %sVariables struct {\n", name)
+		for _, variable := range operation.VariableDefinitions {
+			fmt.Fprintf(&code, "\t%s %s `json:\"%s%s\"`\n",
+				goName(variable.Variable), g.inputType(variable.Type), variable.Variable, omitEmpty(variable.Type))
+		}
+		code.WriteString("}\n\n")
+	}
+
+	fmt.Fprintf(&code, "// %sResponse holds the data returned by the %s %s.\n", name, operation.Name, operation.Operation)
+	fmt.Fprintf(&code, "type %sResponse %s\n\n", name, g.selectionStruct(g.rootType(operation.Operation), operation.SelectionSet))
+
+	params := "client *ggql.Client"
+	args := "client"
+	if hasVariables {
+		params += ", variables " + name + "Variables"
+		args += ", variables"
+	}
+
+	fmt.Fprintf(&code, "// New%sRequest creates the request executing the %s %s through the client.\n", name, operation.Name, operation.Operation)
+	fmt.Fprintf(&code, "func New%sRequest(%s) ggql.Request {\n", name, params)
+	fmt.Fprintf(&code, "\trequest := client.NewRequest().Query(%sDocument).OperationName(%q)\n", name, operation.Name)
+	for _, variable := range operation.VariableDefinitions {
+		field := "variables." + goName(variable.Variable)
+		if variable.Type.NonNull {
+			fmt.Fprintf(&code, "\trequest = request.AddVariable(%q, %s)\n", variable.Variable, field)
+		} else {
+			fmt.Fprintf(&code, "\tif %s != nil {\n\t\trequest = request.AddVariable(%q, %s)\n\t}\n", field, variable.Variable, field)
+		}
+	}
+	code.WriteString("\treturn request\n}\n\n")
+
+	fmt.Fprintf(&code, "// %s executes the %s %s and decodes its data.\n", name, operation.Name, operation.Operation)
+	fmt.Fprintf(&code, "func %s(ctx context.Context, %s) (%sResponse, error) {\n", name, params, name)
+	fmt.Fprintf(&code, "\treturn ggql.ExecuteInto[%sResponse](ctx, New%sRequest(%s).FailOnGraphQLErrors())\n}\n\n", name, name, args)
+	return code.String()
+}
+
+// operationDocument renders the operation along with the fragments it uses, directly or
+// through other fragments.
+func operationDocument(operation *ast.OperationDefinition, document *ast.QueryDocument) string {
+	used := make(map[string]bool)
+	var visit func(selections ast.SelectionSet)
+	visit = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				visit(s.SelectionSet)
+			case *ast.InlineFragment:
+				visit(s.SelectionSet)
+			case *ast.FragmentSpread:
+				if !used[s.Name] {
+					used[s.Name] = true
+					if fragment := document.Fragments.ForName(s.Name); fragment != nil {
+						visit(fragment.SelectionSet)
+					}
+				}
+			}
+		}
+	}
+	visit(operation.SelectionSet)
+
+	single := &ast.QueryDocument{Operations: ast.OperationList{operation}}
+	for _, fragment := range document.Fragments {
+		if used[fragment.Name] {
+			single.Fragments = append(single.Fragments, fragment)
+		}
+	}
+
+	var buf bytes.Buffer
+	formatter.NewFormatter(&buf).FormatQueryDocument(single)
+	return buf.String()
+}
+
+// selectionStruct renders the anonymous struct type matching a selection set made on the
+// parent type. Fields selected through fragments are merged into the struct; those selected
+// through a fragment on a narrower type are made nullable since they may be absent.
+func (g *generator) selectionStruct(parent string, selections ast.SelectionSet) string {
+	var fields []string
+	seen := make(map[string]bool)
+	var collect func(selections ast.SelectionSet, conditional bool)
+	collect = func(selections ast.SelectionSet, conditional bool) {
+		for _, selection := range selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				key := s.Alias
+				if key == "" {
+					key = s.Name
+				}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				goType := g.outputType(s.Definition.Type, s.SelectionSet)
+				if conditional && !strings.HasPrefix(goType, "*") && !strings.HasPrefix(goType, "[]") {
+					goType = "*" + goType
+				}
+				fields = append(fields, fmt.Sprintf("%s %s `json:\"%s\"`", goName(key), goType, key))
+			case *ast.InlineFragment:
+				collect(s.SelectionSet, conditional || (s.TypeCondition != "" && s.TypeCondition != parent))
+			case *ast.FragmentSpread:
+				collect(s.Definition.SelectionSet, conditional || s.Definition.TypeCondition != parent)
+			}
+		}
+	}
+	collect(selections, false)
+	return "struct {\n" + strings.Join(fields, "\n") + "\n}"
+}
+
+// rootType returns the name of the root type of the given operation type.
+func (g *generator) rootType(operation ast.Operation) string {
+	var root *ast.Definition
+	switch operation {
+	case ast.Mutation:
+		root = g.schema.Mutation
+	case ast.Subscription:
+		root = g.schema.Subscription
+	default:
+		root = g.schema.Query
+	}
+	if root == nil {
+		return ""
+	}
+	return root.Name
+}
+
+// outputType renders the Go type of a field of the response. Nullable fields are pointers,
+// except lists which can already be nil.
+func (g *generator) outputType(t *ast.Type, selections ast.SelectionSet) string {
+	var goType string
+	switch {
+	case t.Elem != nil:
+		return "[]" + g.outputType(t.Elem, selections)
+	case len(selections) > 0:
+		goType = g.selectionStruct(t.NamedType, selections)
+	default:
+		goType = g.namedType(t.NamedType)
+	}
+	if !t.NonNull {
+		return "*" + goType
+	}
+	return goType
+}
+
+// inputType renders the Go type of a variable or input field.
+func (g *generator) inputType(t *ast.Type) string {
+	if t.Elem != nil {
+		return "[]" + g.inputType(t.Elem)
+	}
+	goType := g.namedType(t.NamedType)
+	if !t.NonNull {
+		return "*" + goType
+	}
+	return goType
+}
+
+// namedType returns the Go type of a scalar, enum or input object type, generating the
+// declaration of enums and input objects the first time they are referenced.
+func (g *generator) namedType(name string) string {
+	if goType, ok := g.scalars[name]; ok {
+		return g.importType(goType)
+	}
+	if goType, ok := builtinGoTypes[name]; ok {
+		return goType
+	}
+
+	definition := g.schema.Types[name]
+	if definition == nil {
+		return "any"
+	}
+	switch definition.Kind {
+	case ast.Enum:
+		g.enum(definition)
+		return goName(name)
+	case ast.InputObject:
+		g.inputObject(definition)
+		return goName(name)
+	default:
+		return "any"
+	}
+}
+
+// importType registers the import of a qualified type such as "github.com/google/uuid.UUID"
+// and returns its Go spelling, e.g. "uuid.UUID".
+func (g *generator) importType(goType string) string {
+	dot := strings.LastIndex(goType, ".")
+	if dot < 0 {
+		return goType
+	}
+	path := goType[:dot]
+	g.imports[path] = true
+	return path[strings.LastIndex(path, "/")+1:] + goType[dot:]
+}
+
+// enum generates the string type and constants of an enum.
+func (g *generator) enum(definition *ast.Definition) {
+	if g.named[definition.Name] {
+		return
+	}
+	g.named[definition.Name] = true
+
+	name := goName(definition.Name)
+	var code strings.Builder
+	fmt.Fprintf(&code, "// %s represents the %s enum.\n", name, definition.Name)
+	fmt.Fprintf(&code, "type %s string\n\n", name)
+	fmt.Fprintf(&code, "// Values of the %s enum.\n", definition.Name)
+	code.WriteString("const (\n")
+	for _, value := range definition.EnumValues {
+		fmt.Fprintf(&code, "\t%s%s %s = %q\n", name, goName(strings.ToLower(value.Name)), name, value.Name)
+	}
+	code.WriteString(")\n\n")
+	g.namedCode = append(g.namedCode, code.String())
+}
+
+// inputObject generates the struct of an input object.
+func (g *generator) inputObject(definition *ast.Definition) {
+	if g.named[definition.Name] {
+		return
+	}
+	g.named[definition.Name] = true
+
+	name := goName(definition.Name)
+	var code strings.Builder
+	fmt.Fprintf(&code, "// %s represents the %s input object.\n", name, definition.Name)
+	fmt.Fprintf(&code, "type %s struct {\n", name)
+	for _, field := range definition.Fields {
+		fmt.Fprintf(&code, "\t%s %s `json:\"%s%s\"`\n", goName(field.Name), g.inputType(field.Type), field.Name, omitEmpty(field.Type))
+	}
+	code.WriteString("}\n\n")
+	g.namedCode = append(g.namedCode, code.String())
+}
+
+// omitEmpty returns the omitempty tag option for nullable types, so that unset optional
+// values are omitted from the payload.
+func omitEmpty(t *ast.Type) string {
+	if t.NonNull {
+		return ""
+	}
+	return ",omitempty"
+}
+
+// goName converts a GraphQL name into an exported Go identifier, e.g. "user_id" to "UserID".
+func goName(name string) string {
+	parts := strings.FieldsFunc(name, func(r rune) bool { return r == '_' || r == '-' })
+	var builder strings.Builder
+	for _, part := range parts {
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		part = string(runes)
+		if strings.HasSuffix(part, "Id") {
+			part = strings.TrimSuffix(part, "Id") + "ID"
+		}
+		builder.WriteString(part)
+	}
+	if builder.Len() == 0 {
+		return "X"
+	}
+	return builder.String()
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"github.com/tidwall/gjson"
+	"os"
+)
+
+// runIntrospect implements the introspect command.
+func runIntrospect(args []string) error {
+	flags := flag.NewFlagSet("introspect", flag.ExitOnError)
+	client := registerClientFlags(flags)
+	jsonFlag := flags.Bool("json", false, "write the introspection result as JSON instead of SDL")
+	compactFlag := flags.Bool("compact", false, "write compact JSON instead of indented JSON, with -json")
+	outFlag := flags.String("out", "", "output file (default stdout)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ggql introspect [flags] <endpoint>")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Introspects the schema of the endpoint and writes it as SDL or, with -json, as the")
+		fmt.Fprintln(os.Stderr, "{\"__schema\": ...} object expected by GraphQL tooling.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := interruptible()
+	defer cancel()
+	introspection, err := client.newClient(flags.Arg(0)).Introspect(ctx).Get()
+	if err != nil {
+		return fmt.Errorf("introspecting schema: %w", err)
+	}
+
+	var output bytes.Buffer
+	if *jsonFlag {
+		err = printJSON(&output, gjson.Parse(`{"__schema":`+introspection.Raw+`}`), *compactFlag)
+		if err != nil {
+			return err
+		}
+	} else {
+		output.WriteString(ggql.PrintSDL(introspection))
+	}
+	if *outFlag == "" {
+		_, err = os.Stdout.Write(output.Bytes())
+		return err
+	}
+	return os.WriteFile(*outFlag, output.Bytes(), 0o644)
+}
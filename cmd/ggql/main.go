@@ -0,0 +1,95 @@
+// Command ggql is a command-line companion of the ggql library.
+//
+// Usage:
+//
+//	ggql <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	gen         generate typed Go request builders from a schema and operation files
+//	introspect  write the schema of an endpoint as SDL or introspection JSON
+//	manifest    write the persisted query manifest of operation files
+//	query       execute a query or mutation against an endpoint and print the response
+//	subscribe   stream the events of a subscription as JSON lines
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// command represents a subcommand of the ggql binary.
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+// commands maps subcommand names to their implementation.
+var commands = map[string]command{
+	"gen":        {summary: "generate typed Go request builders from a schema and operation files", run: runGen},
+	"introspect": {summary: "write the schema of an endpoint as SDL or introspection JSON", run: runIntrospect},
+	"manifest":   {summary: "write the persisted query manifest of operation files", run: runManifest},
+	"query":      {summary: "execute a query or mutation against an endpoint and print the response", run: runQuery},
+	"subscribe":  {summary: "stream the events of a subscription as JSON lines", run: runSubscribe},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "--help" || os.Args[1] == "help" {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "ggql: unknown command %q\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+
+	err := cmd.run(os.Args[2:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ggql %s: %v\n", os.Args[1], err)
+		os.Exit(1)
+	}
+}
+
+// usage prints the list of available commands to stderr.
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "Usage: ggql <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].summary)
+	}
+}
+
+// headerFlags collects repeated "-H 'Key: Value'" flags.
+type headerFlags map[string]string
+
+// String implements flag.Value.
+func (headers headerFlags) String() string {
+	pairs := make([]string, 0, len(headers))
+	for key, value := range headers {
+		pairs = append(pairs, key+": "+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ", ")
+}
+
+// Set implements flag.Value.
+func (headers headerFlags) Set(value string) error {
+	key, val, ok := strings.Cut(value, ":")
+	if !ok {
+		return fmt.Errorf("invalid header %q, expected \"Key: Value\"", value)
+	}
+	headers[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	return nil
+}
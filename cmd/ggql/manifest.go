@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// documentExtensions are the extensions of the files scanned for documents.
+var documentExtensions = map[string]bool{".graphql": true, ".gql": true}
+
+// runManifest implements the manifest command.
+func runManifest(args []string) error {
+	flags := flag.NewFlagSet("manifest", flag.ExitOnError)
+	formatFlag := flags.String("format", "apollo", "manifest format, apollo or relay")
+	outFlag := flags.String("out", "", "output file (default stdout)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ggql manifest [flags] <file|directory>...")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Writes the persisted query manifest of the documents of the .graphql and .gql files,")
+		fmt.Fprintln(os.Stderr, "directories being scanned recursively. Every file holds the document of one operation,")
+		fmt.Fprintln(os.Stderr, "hashed as is: it must be sent verbatim for its hash to match.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() == 0 || (*formatFlag != "apollo" && *formatFlag != "relay") {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	manifest := ggql.NewManifest()
+	for _, root := range flags.Args() {
+		err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || (path != root && !documentExtensions[strings.ToLower(filepath.Ext(path))]) {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			err = manifest.Add(string(content))
+			if err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var output bytes.Buffer
+	var err error
+	if *formatFlag == "relay" {
+		err = manifest.WriteRelay(&output)
+	} else {
+		err = manifest.WriteApollo(&output)
+	}
+	if err != nil {
+		return err
+	}
+	if *outFlag == "" {
+		_, err = os.Stdout.Write(output.Bytes())
+		return err
+	}
+	return os.WriteFile(*outFlag, output.Bytes(), 0o644)
+}
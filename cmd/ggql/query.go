@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"github.com/tidwall/gjson"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+)
+
+// variableFlags collects repeated "-v name=value" flags. Values are parsed as JSON when
+// possible and kept as strings otherwise; a JSON object merges all its members.
+type variableFlags map[string]any
+
+// String implements flag.Value.
+func (variables variableFlags) String() string {
+	pairs := make([]string, 0, len(variables))
+	for name, value := range variables {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", name, value))
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// Set implements flag.Value.
+func (variables variableFlags) Set(value string) error {
+	if strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var members map[string]any
+		err := json.Unmarshal([]byte(value), &members)
+		if err != nil {
+			return fmt.Errorf("invalid variables %q: %w", value, err)
+		}
+		for name, member := range members {
+			variables[name] = member
+		}
+		return nil
+	}
+	name, raw, ok := strings.Cut(value, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid variable %q, expected name=value or a JSON object", value)
+	}
+	var parsed any
+	err := json.Unmarshal([]byte(raw), &parsed)
+	if err != nil {
+		parsed = raw
+	}
+	variables[name] = parsed
+	return nil
+}
+
+// clientFlags are the flags shared by the commands talking to an endpoint.
+type clientFlags struct {
+	headers headerFlags
+	timeout *time.Duration
+}
+
+// registerClientFlags defines the flags shared by the commands talking to an endpoint.
+func registerClientFlags(flags *flag.FlagSet) clientFlags {
+	client := clientFlags{headers: make(headerFlags)}
+	flags.Var(client.headers, "H", "header sent to the endpoint, as \"Key: Value\" (repeatable)")
+	client.timeout = flags.Duration("timeout", 0, "timeout of the request, such as 30s (default none)")
+	return client
+}
+
+// newClient returns a client of the endpoint configured by the flags.
+func (flags clientFlags) newClient(endpoint string) *ggql.Client {
+	client := ggql.NewClient(endpoint).AddHeaders(flags.headers)
+	if *flags.timeout > 0 {
+		client = client.WithTimeout(*flags.timeout)
+	}
+	return client
+}
+
+// interruptible returns a context cancelled when the process is interrupted.
+func interruptible() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt)
+}
+
+// runQuery implements the query command.
+func runQuery(args []string) error {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	client := registerClientFlags(flags)
+	variables := make(variableFlags)
+	flags.Var(variables, "v", "variable, as name=value with a JSON or string value, or a JSON object of variables (repeatable)")
+	operationFlag := flags.String("o", "", "name of the operation to execute, for documents holding several")
+	pathFlag := flags.String("path", "", "gjson path of the value to print, such as data.user.name (default the whole response)")
+	rawFlag := flags.Bool("r", false, "print strings selected by -path without quotes")
+	compactFlag := flags.Bool("compact", false, "print compact JSON instead of indented JSON")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ggql query [flags] <endpoint> [file]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Executes the query or mutation read from file, or from stdin when file is absent or \"-\".")
+		fmt.Fprintln(os.Stderr, "The exit status is 1 when the response holds GraphQL errors.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	document, err := readDocument(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := interruptible()
+	defer cancel()
+	request := client.newClient(flags.Arg(0)).NewRequest().Query(document).AddVariables(variables)
+	if *operationFlag != "" {
+		request = request.OperationName(*operationFlag)
+	}
+	response, err := request.ExecuteResponse(ctx)
+	if err != nil {
+		var status *ggql.ErrHTTPStatus
+		if errors.As(err, &status) && len(response.Body) > 0 {
+			_ = printJSON(os.Stdout, response.Raw, *compactFlag)
+		}
+		return err
+	}
+
+	output := response.Raw
+	if *pathFlag != "" {
+		output = response.Raw.Get(*pathFlag)
+		if !output.Exists() {
+			return fmt.Errorf("path %q not found in response", *pathFlag)
+		}
+	}
+	if *rawFlag && output.Type == gjson.String {
+		_, err = fmt.Fprintln(os.Stdout, output.Str)
+	} else {
+		err = printJSON(os.Stdout, output, *compactFlag)
+	}
+	if err != nil {
+		return err
+	}
+	if response.HasErrors() {
+		return fmt.Errorf("response holds %d GraphQL error(s)", len(response.Errors))
+	}
+	return nil
+}
+
+// readDocument returns the content of the file, or of stdin when file is empty or "-".
+func readDocument(file string) (string, error) {
+	var content []byte
+	var err error
+	if file == "" || file == "-" {
+		content, err = io.ReadAll(os.Stdin)
+	} else {
+		content, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return "", fmt.Errorf("reading document: %w", err)
+	}
+	if strings.TrimSpace(string(content)) == "" {
+		return "", errors.New("empty document")
+	}
+	return string(content), nil
+}
+
+// printJSON writes value to w on its own line, indented unless compact is set.
+func printJSON(w io.Writer, value gjson.Result, compact bool) error {
+	var buf bytes.Buffer
+	var err error
+	if compact {
+		err = json.Compact(&buf, []byte(value.Raw))
+	} else {
+		err = json.Indent(&buf, []byte(value.Raw), "", "  ")
+	}
+	if err != nil {
+		return fmt.Errorf("formatting response: %w", err)
+	}
+	buf.WriteByte('\n')
+	_, err = w.Write(buf.Bytes())
+	return err
+}
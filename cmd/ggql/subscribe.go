@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"os"
+)
+
+// runSubscribe implements the subscribe command.
+func runSubscribe(args []string) error {
+	flags := flag.NewFlagSet("subscribe", flag.ExitOnError)
+	client := registerClientFlags(flags)
+	variables := make(variableFlags)
+	flags.Var(variables, "v", "variable, as name=value with a JSON or string value, or a JSON object of variables (repeatable)")
+	operationFlag := flags.String("o", "", "name of the operation to execute, for documents holding several")
+	sseFlag := flags.Bool("sse", false, "subscribe over Server-Sent Events instead of a WebSocket")
+	initFlag := flags.String("init", "", "JSON object sent as the payload of the WebSocket connection_init message")
+	pathFlag := flags.String("path", "", "gjson path of the value to print for each event, such as data.message (default the whole event)")
+	rawFlag := flags.Bool("r", false, "print strings selected by -path without quotes")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: ggql subscribe [flags] <endpoint> [file]")
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, "Subscribes to the operation read from file, or from stdin when file is absent or \"-\",")
+		fmt.Fprintln(os.Stderr, "and prints every event as a line of JSON until the server completes the subscription")
+		fmt.Fprintln(os.Stderr, "or the command is interrupted.")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(args)
+
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	document, err := readDocument(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	request := client.newClient(flags.Arg(0)).NewRequest().Query(document).AddVariables(variables)
+	if *operationFlag != "" {
+		request = request.OperationName(*operationFlag)
+	}
+	if *sseFlag {
+		request = request.UseSSE()
+	}
+	if *initFlag != "" {
+		var payload map[string]any
+		err = json.Unmarshal([]byte(*initFlag), &payload)
+		if err != nil {
+			return fmt.Errorf("invalid init payload: %w", err)
+		}
+		request = request.InitPayload(payload)
+	}
+
+	ctx, cancel := interruptible()
+	defer cancel()
+	results, errs := request.Subscribe(ctx)
+	for result := range results {
+		output := result
+		if *pathFlag != "" {
+			output = result.Get(*pathFlag)
+			if !output.Exists() {
+				continue
+			}
+		}
+		if *rawFlag && output.Type == gjson.String {
+			_, err = fmt.Fprintln(os.Stdout, output.Str)
+		} else {
+			err = printJSON(os.Stdout, output, true)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return <-errs
+}
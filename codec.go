@@ -0,0 +1,124 @@
+package ggql
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// Codec encodes the payloads of the requests and decodes the data of the responses. It lets
+// faster implementations such as sonic or go-json replace encoding/json, or customize the
+// encoding, e.g. to indent the payloads while debugging. The zero JSONCodec, based on
+// encoding/json, is used by default.
+//
+// Codecs only apply to the values of the callers: the payloads sent to the endpoint and the
+// targets of Decode, DecodeStrict and DoInto. The envelope of the responses, such as the
+// GraphQL errors, is always decoded with encoding/json.
+type Codec interface {
+	// Encode writes the JSON encoding of v to w. A trailing newline is allowed.
+	Encode(w io.Writer, v any) error
+
+	// NewDecoder returns a Decoder reading the JSON value from r.
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Decoder decodes a JSON value into a Go value. The *json.Decoder of encoding/json, and
+// those of most compatible implementations, satisfy it.
+type Decoder interface {
+	Decode(v any) error
+
+	// UseNumber and DisallowUnknownFields implement Request.UseNumber and DecodeStrict.
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// JSONCodec is a Codec based on encoding/json. When DisableHTMLEscape is set, the characters
+// <, > and & are written as is instead of being escaped as encoding/json does by default.
+// A non-empty Indent writes every JSON element on a new line, indented with Indent according
+// to its nesting.
+type JSONCodec struct {
+	DisableHTMLEscape bool
+	Indent            string
+}
+
+// Encode implements Codec.
+func (codec JSONCodec) Encode(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(!codec.DisableHTMLEscape)
+	if codec.Indent != "" {
+		encoder.SetIndent("", codec.Indent)
+	}
+	return encoder.Encode(v)
+}
+
+// NewDecoder implements Codec.
+func (JSONCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// WithCodec sets the Codec encoding the payloads of the client's requests and decoding the
+// data of their responses. Passing nil restores the default JSONCodec. The updated Client
+// is returned.
+func (client *Client) WithCodec(codec Codec) *Client {
+	client.codec = codec
+	return client
+}
+
+// WithCodec sets the Codec of the request, overriding the one of the parent Client. Passing
+// nil restores the default resolution. The modified Request is returned.
+func (request Request) WithCodec(codec Codec) Request {
+	request.codec = codec
+	return request
+}
+
+// resolveCodec returns the Codec of the request, falling back to the one of the parent
+// client, then to the zero JSONCodec.
+func (request Request) resolveCodec() Codec {
+	if request.codec != nil {
+		return request.codec
+	}
+	if request.client != nil && request.client.codec != nil {
+		return request.client.codec
+	}
+	return JSONCodec{}
+}
+
+// marshal returns the encoding of v by codec, without trailing newline.
+func marshal(codec Codec, v any) ([]byte, error) {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	err := codec.Encode(buf, v)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.Clone(bytes.TrimSuffix(buf.Bytes(), []byte("\n"))), nil
+}
+
+// encodedContent is a payload whose variables hold the serialized form of the registered
+// scalars, so that codecs don't depend on the json.Marshaler implemented by variables.
+type encodedContent struct {
+	Query         string         `json:"query,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	DocumentID    string         `json:"documentId,omitempty"`
+	Variables     any            `json:"variables"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// encoded returns the payload with its registered scalars serialized (see RegisterScalar).
+func (c content) encoded() (encodedContent, error) {
+	var variables any
+	if c.Variables != nil {
+		var err error
+		variables, err = encodeScalars(map[string]any(c.Variables))
+		if err != nil {
+			return encodedContent{}, err
+		}
+	}
+	return encodedContent{
+		Query:         c.Query,
+		OperationName: c.OperationName,
+		DocumentID:    c.DocumentID,
+		Variables:     variables,
+		Extensions:    c.Extensions,
+	}, nil
+}
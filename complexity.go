@@ -0,0 +1,196 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"strconv"
+)
+
+// DefaultListArguments are the arguments whose value gives the size of the lists returned by
+// a field, such as the first argument of Relay connections, when estimating the complexity
+// of an operation.
+var DefaultListArguments = []string{"first", "last", "limit"}
+
+// Complexity is the static estimate of the cost of an operation computed by
+// Request.Complexity. Depth is the maximum nesting of its fields, the root fields having a
+// depth of 1, and Fields is the number of fields it selects. Cost is the number of fields
+// the endpoint is expected to resolve: every field costs 1, and the cost of the fields
+// selected on a list is multiplied by the size of the list, as given by its list argument.
+type Complexity struct {
+	Depth  int
+	Fields int
+	Cost   int
+}
+
+// ComplexityLimit is the ceiling of the complexity of the operations sent by a client, see
+// Client.WithComplexityLimit. Zero maxima are not enforced. ListArguments are the arguments
+// giving the size of lists, DefaultListArguments when nil; lists without such argument are
+// assumed to hold DefaultListSize elements, 1 when zero.
+type ComplexityLimit struct {
+	MaxDepth        int
+	MaxFields       int
+	MaxCost         int
+	ListArguments   []string
+	DefaultListSize int
+}
+
+// WithComplexityLimit makes the client estimate the complexity of the operations of its
+// requests and fail the ones exceeding the limit with an ErrTooComplex, without reaching
+// the endpoint, rather than getting them rejected or rate limited by the server. Only the
+// list arguments of the fields are known to the estimator, which ignores the schema:
+// fields returning lists without list argument must be accounted for with DefaultListSize.
+// The updated Client is returned.
+func (client *Client) WithComplexityLimit(limit ComplexityLimit) *Client {
+	client.complexity = &limit
+	return client
+}
+
+// Complexity estimates the complexity of the operation executed by the request, with the
+// list arguments of DefaultListArguments and lists of unknown size assumed to hold a single
+// element. Variables used as list arguments are resolved from the request's variables or
+// their default value. An error is returned when the document can't be parsed.
+func (request Request) Complexity() (Complexity, error) {
+	return request.complexity(ComplexityLimit{})
+}
+
+// complexity estimates the complexity of the operation of the request with the list settings
+// of limit.
+func (request Request) complexity(limit ComplexityLimit) (Complexity, error) {
+	if request.Request == "" {
+		return Complexity{}, errors.New("no query/mutation provided")
+	}
+	document, err := parser.ParseQuery(&ast.Source{Input: request.Request})
+	if err != nil {
+		return Complexity{}, request.Validate(nil)
+	}
+	operation := selectOperation(document, request.operationName)
+	if operation == nil {
+		return Complexity{}, fmt.Errorf("no operation %q in document", request.operationName)
+	}
+
+	encoded, err := json.Marshal(variables(request.Variables))
+	if err != nil {
+		return Complexity{}, fmt.Errorf("encoding variables: %w", err)
+	}
+	estimator := complexityEstimator{
+		document:      document,
+		variables:     gjson.ParseBytes(encoded),
+		defaults:      make(map[string]*ast.Value),
+		listArguments: limit.ListArguments,
+		listSize:      max(limit.DefaultListSize, 1),
+		visiting:      make(map[string]bool),
+	}
+	if estimator.listArguments == nil {
+		estimator.listArguments = DefaultListArguments
+	}
+	for _, definition := range operation.VariableDefinitions {
+		estimator.defaults[definition.Variable] = definition.DefaultValue
+	}
+	return estimator.selectionSet(operation.SelectionSet, 1), nil
+}
+
+// complexityEstimator walks the selection sets of an operation to estimate its complexity.
+type complexityEstimator struct {
+	document      *ast.QueryDocument
+	variables     gjson.Result
+	defaults      map[string]*ast.Value
+	listArguments []string
+	listSize      int
+	visiting      map[string]bool
+}
+
+// selectionSet returns the complexity of the selections, found at the given depth. The
+// fragments spread recursively are only counted once.
+func (estimator complexityEstimator) selectionSet(selections ast.SelectionSet, depth int) Complexity {
+	var complexity Complexity
+	for _, selection := range selections {
+		var nested Complexity
+		switch selection := selection.(type) {
+		case *ast.Field:
+			if selection.Name == "__typename" {
+				continue
+			}
+			nested = Complexity{Depth: depth, Fields: 1, Cost: 1}
+			if len(selection.SelectionSet) > 0 {
+				children := estimator.selectionSet(selection.SelectionSet, depth+1)
+				nested.Depth = max(nested.Depth, children.Depth)
+				nested.Fields += children.Fields
+				nested.Cost += estimator.listSizeOf(selection) * children.Cost
+			}
+		case *ast.InlineFragment:
+			nested = estimator.selectionSet(selection.SelectionSet, depth)
+		case *ast.FragmentSpread:
+			fragment := estimator.document.Fragments.ForName(selection.Name)
+			if fragment == nil || estimator.visiting[selection.Name] {
+				continue
+			}
+			estimator.visiting[selection.Name] = true
+			nested = estimator.selectionSet(fragment.SelectionSet, depth)
+			delete(estimator.visiting, selection.Name)
+		}
+		complexity.Depth = max(complexity.Depth, nested.Depth)
+		complexity.Fields += nested.Fields
+		complexity.Cost += nested.Cost
+	}
+	return complexity
+}
+
+// listSizeOf returns the size of the list returned by the field, given by its first list
+// argument with a positive integer value, or the default list size.
+func (estimator complexityEstimator) listSizeOf(field *ast.Field) int {
+	for _, name := range estimator.listArguments {
+		argument := field.Arguments.ForName(name)
+		if argument == nil {
+			continue
+		}
+		size, ok := estimator.intValue(argument.Value)
+		if ok && size > 0 {
+			return size
+		}
+	}
+	return estimator.listSize
+}
+
+// intValue returns the integer held by value, resolving variables.
+func (estimator complexityEstimator) intValue(value *ast.Value) (int, bool) {
+	if value == nil {
+		return 0, false
+	}
+	switch value.Kind {
+	case ast.IntValue:
+		n, err := strconv.Atoi(value.Raw)
+		return n, err == nil
+	case ast.Variable:
+		variable := estimator.variables.Get(gjson.Escape(value.Raw))
+		if !variable.Exists() {
+			return estimator.intValue(estimator.defaults[value.Raw])
+		}
+		if variable.Type != gjson.Number || !isInteger(variable.Raw) {
+			return 0, false
+		}
+		return int(variable.Int()), true
+	}
+	return 0, false
+}
+
+// middleware returns a Handler failing the requests whose complexity exceeds the limit
+// before calling next.
+func (limit *ComplexityLimit) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		complexity, err := request.complexity(*limit)
+		if err != nil {
+			return Response{}, err
+		}
+		if limit.MaxDepth > 0 && complexity.Depth > limit.MaxDepth ||
+			limit.MaxFields > 0 && complexity.Fields > limit.MaxFields ||
+			limit.MaxCost > 0 && complexity.Cost > limit.MaxCost {
+			return Response{}, &ErrTooComplex{OperationName: request.operationName, Complexity: complexity, Limit: *limit}
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,338 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/parser"
+	"strconv"
+	"strings"
+)
+
+// ComposedRequest represents several independent queries merged into a single operation,
+// sent in one HTTP call to servers that don't support batching, e.g. to load the widgets of
+// a dashboard at once. The root fields of every query are aliased with a prefix, q0_, q1_
+// and so on, as are its variables and fragments, and the combined response is split back
+// into one result per query:
+//
+//	query ($q0_id: ID!) { q0_user: user(id: $q0_id) { name } q1_stats: stats { visits } }
+type ComposedRequest struct {
+	Requests []Request
+}
+
+// composedField is a root field of a composed query: the response key of the field in the
+// original query, and the index of that query.
+type composedField struct {
+	index int
+	key   string
+}
+
+// Compose groups the provided queries into a ComposedRequest. The endpoint, headers and
+// configuration of the first request are used to send the composed query.
+func Compose(requests ...Request) ComposedRequest {
+	return ComposedRequest{Requests: requests}
+}
+
+// Request returns the request sending the composed query: the first request, with the
+// composed document and the renamed variables of every query.
+// It fails if one of the requests is not a valid document defining the query to execute:
+// mutations, whose root fields are executed serially, and subscriptions can't be composed.
+func (composed ComposedRequest) Request() (Request, error) {
+	request, _, err := composed.compose()
+	return request, err
+}
+
+// Split splits the response of the composed query into one result per query, in the same
+// order as the requests. Each result has the shape of a response: a "data" member holding
+// the root fields of the query under their original names, and an "errors" member holding
+// the errors of these fields, whose paths are rewritten accordingly and whose locations are
+// dropped as they refer to the composed document. Errors without path are reported to every
+// query.
+func (composed ComposedRequest) Split(response Response) ([]gjson.Result, error) {
+	_, fields, err := composed.compose()
+	if err != nil {
+		return nil, err
+	}
+	return composed.split(response, fields), nil
+}
+
+// Do sends the composed query and returns the results of the queries in the same order as
+// the requests, see Split.
+func (composed ComposedRequest) Do() mo.Result[[]gjson.Result] {
+	return composed.DoCtx(context.Background())
+}
+
+// DoCtx behaves like Do but binds the outgoing HTTP request to the provided context.
+func (composed ComposedRequest) DoCtx(ctx context.Context) mo.Result[[]gjson.Result] {
+	return mo.TupleToResult(composed.do(ctx, false))
+}
+
+// Execute is the (value, error) counterpart of DoCtx. Like Request.Execute, it fails with an
+// ErrHTTPStatus when the endpoint answers with a non-2xx HTTP status.
+func (composed ComposedRequest) Execute(ctx context.Context) ([]gjson.Result, error) {
+	return composed.do(ctx, true)
+}
+
+// do sends the composed query through the middleware chain of the first request and splits
+// its response. execute selects the HTTP status policy of the Execute family.
+func (composed ComposedRequest) do(ctx context.Context, execute bool) ([]gjson.Result, error) {
+	request, fields, err := composed.compose()
+	if err != nil {
+		return nil, err
+	}
+	if execute {
+		request = request.failingOnHTTPStatus()
+	}
+	response, err := request.do(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return composed.split(response, fields), nil
+}
+
+// compose builds the request sending the composed query, and returns the root fields of the
+// queries by alias.
+func (composed ComposedRequest) compose() (Request, map[string]composedField, error) {
+	if len(composed.Requests) == 0 {
+		return Request{}, nil, errors.New("no request to compose")
+	}
+
+	combined := &ast.QueryDocument{}
+	operation := &ast.OperationDefinition{Operation: ast.Query}
+	combined.Operations = ast.OperationList{operation}
+	fields := make(map[string]composedField)
+	variables := make(map[string]any)
+	for i, request := range composed.Requests {
+		prefix := "q" + strconv.Itoa(i) + "_"
+		document, err := parser.ParseQuery(&ast.Source{Input: request.client.withFragments(request.Request)})
+		if err != nil {
+			return Request{}, nil, fmt.Errorf("request %d: parsing document: %w", i, err)
+		}
+		selected := selectOperation(document, request.operationName)
+		if selected == nil {
+			return Request{}, nil, fmt.Errorf("request %d: no operation to execute in document", i)
+		}
+		if selected.Operation != ast.Query {
+			return Request{}, nil, fmt.Errorf("request %d: only queries can be composed, found a %s", i, selected.Operation)
+		}
+
+		renameVariables(selected.SelectionSet, prefix)
+		for _, fragment := range document.Fragments {
+			renameVariables(fragment.SelectionSet, prefix)
+		}
+		for _, definition := range selected.VariableDefinitions {
+			definition.Variable = prefix + definition.Variable
+			operation.VariableDefinitions = append(operation.VariableDefinitions, definition)
+		}
+		for name, value := range request.Variables {
+			variables[prefix+name] = value
+		}
+
+		selections, err := aliasRootFields(selected.SelectionSet, document, prefix, i, fields)
+		if err != nil {
+			return Request{}, nil, fmt.Errorf("request %d: %w", i, err)
+		}
+		operation.SelectionSet = append(operation.SelectionSet, selections...)
+		renamed := make(map[*ast.FragmentSpread]bool)
+		for _, fragment := range usedFragments(selections, document) {
+			renameSpreads(fragment.SelectionSet, prefix, renamed)
+			fragment.Name = prefix + fragment.Name
+			combined.Fragments = append(combined.Fragments, fragment)
+		}
+		renameSpreads(selections, prefix, renamed)
+	}
+
+	var document strings.Builder
+	formatter.NewFormatter(&document).FormatQueryDocument(combined)
+	request := composed.Requests[0].Query(document.String()).ClearVariables().AddVariables(variables)
+	request.operationName = ""
+	return request, fields, nil
+}
+
+// aliasRootFields returns the root selections of a query with its fields aliased with
+// prefix, recording them in fields. The fragments spread at the root are inlined, so that
+// their fields are aliased as well.
+func aliasRootFields(selections ast.SelectionSet, document *ast.QueryDocument, prefix string, index int, fields map[string]composedField) (ast.SelectionSet, error) {
+	aliased := make(ast.SelectionSet, 0, len(selections))
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			field := *selection
+			key := field.Alias
+			if key == "" {
+				key = field.Name
+			}
+			field.Alias = prefix + key
+			fields[field.Alias] = composedField{index: index, key: key}
+			aliased = append(aliased, &field)
+		case *ast.InlineFragment:
+			inline := *selection
+			nested, err := aliasRootFields(selection.SelectionSet, document, prefix, index, fields)
+			if err != nil {
+				return nil, err
+			}
+			inline.SelectionSet = nested
+			aliased = append(aliased, &inline)
+		case *ast.FragmentSpread:
+			fragment := document.Fragments.ForName(selection.Name)
+			if fragment == nil {
+				return nil, fmt.Errorf("undefined fragment %s", selection.Name)
+			}
+			nested, err := aliasRootFields(fragment.SelectionSet, document, prefix, index, fields)
+			if err != nil {
+				return nil, err
+			}
+			aliased = append(aliased, &ast.InlineFragment{
+				TypeCondition: fragment.TypeCondition,
+				Directives:    selection.Directives,
+				SelectionSet:  nested,
+			})
+		}
+	}
+	return aliased, nil
+}
+
+// usedFragments returns the fragments of the document spread by the selections, directly or
+// through other fragments.
+func usedFragments(selections ast.SelectionSet, document *ast.QueryDocument) []*ast.FragmentDefinition {
+	var used []*ast.FragmentDefinition
+	seen := make(map[string]bool)
+	var visit func(selections ast.SelectionSet)
+	visit = func(selections ast.SelectionSet) {
+		for _, selection := range selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				visit(selection.SelectionSet)
+			case *ast.InlineFragment:
+				visit(selection.SelectionSet)
+			case *ast.FragmentSpread:
+				fragment := document.Fragments.ForName(selection.Name)
+				if fragment != nil && !seen[fragment.Name] {
+					seen[fragment.Name] = true
+					used = append(used, fragment)
+					visit(fragment.SelectionSet)
+				}
+			}
+		}
+	}
+	visit(selections)
+	return used
+}
+
+// renameSpreads prefixes the names of the fragments spread by the selections, without
+// following the spreads. Spreads already renamed, shared with inlined fragments, are
+// skipped.
+func renameSpreads(selections ast.SelectionSet, prefix string, renamed map[*ast.FragmentSpread]bool) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			renameSpreads(selection.SelectionSet, prefix, renamed)
+		case *ast.InlineFragment:
+			renameSpreads(selection.SelectionSet, prefix, renamed)
+		case *ast.FragmentSpread:
+			if !renamed[selection] {
+				renamed[selection] = true
+				selection.Name = prefix + selection.Name
+			}
+		}
+	}
+}
+
+// renameVariables prefixes the names of the variables referenced by the arguments and
+// directives of the selections, without following the spreads.
+func renameVariables(selections ast.SelectionSet, prefix string) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			for _, argument := range selection.Arguments {
+				renameVariable(argument.Value, prefix)
+			}
+			renameDirectiveVariables(selection.Directives, prefix)
+			renameVariables(selection.SelectionSet, prefix)
+		case *ast.InlineFragment:
+			renameDirectiveVariables(selection.Directives, prefix)
+			renameVariables(selection.SelectionSet, prefix)
+		case *ast.FragmentSpread:
+			renameDirectiveVariables(selection.Directives, prefix)
+		}
+	}
+}
+
+// renameDirectiveVariables prefixes the names of the variables referenced by the arguments
+// of the directives.
+func renameDirectiveVariables(directives ast.DirectiveList, prefix string) {
+	for _, directive := range directives {
+		for _, argument := range directive.Arguments {
+			renameVariable(argument.Value, prefix)
+		}
+	}
+}
+
+// renameVariable prefixes the names of the variables referenced by value.
+func renameVariable(value *ast.Value, prefix string) {
+	if value == nil {
+		return
+	}
+	if value.Kind == ast.Variable {
+		value.Raw = prefix + value.Raw
+	}
+	for _, child := range value.Children {
+		renameVariable(child.Value, prefix)
+	}
+}
+
+// split splits the response of the composed query according to its root fields.
+func (composed ComposedRequest) split(response Response, fields map[string]composedField) []gjson.Result {
+	data := make([][]string, len(composed.Requests))
+	if response.Data.IsObject() {
+		response.Data.ForEach(func(key, value gjson.Result) bool {
+			field, ok := fields[key.String()]
+			if ok {
+				name, _ := json.Marshal(field.key)
+				data[field.index] = append(data[field.index], string(name)+":"+value.Raw)
+			}
+			return true
+		})
+	}
+
+	errs := make([][]GraphQLError, len(composed.Requests))
+	for _, err := range response.Errors {
+		if len(err.Path) > 0 {
+			alias, _ := err.Path[0].(string)
+			field, ok := fields[alias]
+			if ok {
+				err.Path = append([]any{field.key}, err.Path[1:]...)
+				err.Locations = nil
+				errs[field.index] = append(errs[field.index], err)
+				continue
+			}
+		}
+		for i := range errs {
+			errs[i] = append(errs[i], err)
+		}
+	}
+
+	results := make([]gjson.Result, len(composed.Requests))
+	for i := range results {
+		var result strings.Builder
+		result.WriteString(`{"data":`)
+		if response.Data.IsObject() {
+			result.WriteString("{" + strings.Join(data[i], ",") + "}")
+		} else {
+			result.WriteString("null")
+		}
+		if len(errs[i]) > 0 {
+			encoded, _ := json.Marshal(errs[i])
+			result.WriteString(`,"errors":`)
+			result.Write(encoded)
+		}
+		result.WriteString("}")
+		results[i] = gjson.Parse(result.String())
+	}
+	return results
+}
@@ -0,0 +1,130 @@
+package ggql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"github.com/andybalholm/brotli"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptedEncodings lists the response content encodings decoded transparently.
+const acceptedEncodings = "gzip, deflate, br"
+
+// WithCompression enables gzip compression of request bodies whose size reaches threshold
+// bytes. Compressed requests carry a "Content-Encoding: gzip" header, so the endpoint must
+// support compressed request bodies. A zero or negative threshold disables compression.
+// The updated Client is returned.
+func (client *Client) WithCompression(threshold int) *Client {
+	client.compressionThreshold = threshold
+	return client
+}
+
+// compressBody gzips buf in place when the parent client's compression threshold is reached,
+// and reports whether the body was compressed.
+func (request Request) compressBody(buf *bytes.Buffer) (bool, error) {
+	if request.client == nil || request.client.compressionThreshold <= 0 || buf.Len() < request.client.compressionThreshold {
+		return false, nil
+	}
+
+	compressed := getBuffer()
+	defer putBuffer(compressed)
+	writer := gzip.NewWriter(compressed)
+	_, err := writer.Write(buf.Bytes())
+	if err != nil {
+		return false, err
+	}
+	err = writer.Close()
+	if err != nil {
+		return false, err
+	}
+
+	buf.Reset()
+	_, err = buf.Write(compressed.Bytes())
+	return true, err
+}
+
+// acceptEncoding advertises the content encodings decoded by readBody, unless the caller
+// already set the Accept-Encoding header.
+func acceptEncoding(header http.Header) {
+	if header.Get("Accept-Encoding") == "" {
+		header.Set("Accept-Encoding", acceptedEncodings)
+	}
+}
+
+// readBody reads the whole response body, decoding it according to its Content-Encoding.
+// A positive limit caps the size of the decoded body, beyond which ErrResponseTooLarge is
+// returned. The body is returned in a slice of its exact size: uncompressed bodies of known
+// length are read in place, the others through a pooled buffer.
+func readBody(res *http.Response, limit int64) ([]byte, error) {
+	reader, err := decodeBody(res)
+	if err != nil {
+		return nil, err
+	}
+	defer func(reader io.ReadCloser) {
+		_ = reader.Close()
+	}(reader)
+
+	if _, decoded := reader.(decodedBody); !decoded && res.ContentLength > 0 && (limit <= 0 || res.ContentLength <= limit) {
+		body := make([]byte, res.ContentLength)
+		_, err = io.ReadFull(reader, body)
+		if err != nil {
+			return nil, &ErrTransport{Op: "reading response", Err: err}
+		}
+		return body, nil
+	}
+
+	resBuf := getBuffer()
+	defer putBuffer(resBuf)
+	_, err = resBuf.ReadFrom(limitBody(reader, limit))
+	var tooLarge *ErrResponseTooLarge
+	if errors.As(err, &tooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &ErrTransport{Op: "reading response", Err: err}
+	}
+	return bytes.Clone(resBuf.Bytes()), nil
+}
+
+// decodeBody returns a reader of the response body decoding it according to its
+// Content-Encoding. Closing the reader closes the body.
+func decodeBody(res *http.Response) (io.ReadCloser, error) {
+	switch strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding"))) {
+	case "gzip", "x-gzip":
+		gzipReader, err := gzip.NewReader(res.Body)
+		if err != nil {
+			return nil, &ErrTransport{Op: "decompressing response", Err: err}
+		}
+		return decodedBody{Reader: gzipReader, closers: []io.Closer{gzipReader, res.Body}}, nil
+	case "deflate":
+		zlibReader, err := zlib.NewReader(res.Body)
+		if err != nil {
+			return nil, &ErrTransport{Op: "decompressing response", Err: err}
+		}
+		return decodedBody{Reader: zlibReader, closers: []io.Closer{zlibReader, res.Body}}, nil
+	case "br":
+		return decodedBody{Reader: brotli.NewReader(res.Body), closers: []io.Closer{res.Body}}, nil
+	default:
+		return res.Body, nil
+	}
+}
+
+// decodedBody is a decoding reader of a response body, closing both the decoder and the
+// body when closed.
+type decodedBody struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close implements io.Closer.
+func (body decodedBody) Close() error {
+	var errs []error
+	for _, closer := range body.closers {
+		errs = append(errs, closer.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,23 @@
+package ggql
+
+import (
+	"net/http"
+	"net/http/cookiejar"
+)
+
+// WithCookieJar makes the client store the cookies set by its endpoints in jar and send
+// them back with subsequent requests, including the handshakes of subscriptions, for APIs
+// authenticating through session cookies, e.g. set by a login mutation. A nil jar creates an
+// in-memory jar without public suffix list, which suits clients talking to a known set of
+// endpoints. The jar is set on a copy of the client's *http.Client. The updated Client is
+// returned.
+func (client *Client) WithCookieJar(jar http.CookieJar) *Client {
+	if jar == nil {
+		// cookiejar.New never fails.
+		jar, _ = cookiejar.New(nil)
+	}
+	httpClient := *client.httpClient()
+	httpClient.Jar = jar
+	client.HTTPClient = &httpClient
+	return client
+}
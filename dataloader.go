@@ -0,0 +1,228 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BatchFunc fetches the values of several keys at once for a Loader. It returns one result
+// per key, in the order of keys, or an error failing the whole batch. See AliasedBatch and
+// ListBatch.
+type BatchFunc[K comparable] func(ctx context.Context, keys []K) ([]mo.Result[gjson.Result], error)
+
+// LoaderOptions configures a Loader.
+type LoaderOptions struct {
+	// Wait is the time a batch collects keys after its first one before being sent,
+	// 2ms when zero.
+	Wait time.Duration
+
+	// MaxBatch is the number of keys sending a batch without waiting for the end of its
+	// window, unlimited when zero.
+	MaxBatch int
+}
+
+// Loader coalesces the single-key lookups issued within a short window, such as
+// user(id: $id) resolved for every item of a list, into a single request fetching all their
+// keys, then hands each caller the value of its key, in the manner of DataLoader:
+//
+//	users := ggql.NewLoader(ggql.AliasedBatch[string](client, ggql.Field("user").Select("id", "name"), "id", "ID!"), ggql.LoaderOptions{})
+//	user, err := users.Load(ctx, "42")
+//
+// Keys requested several times within a batch are fetched once. Values are not cached
+// across batches. A Loader is safe for concurrent use.
+type Loader[K comparable] struct {
+	batch   BatchFunc[K]
+	options LoaderOptions
+
+	mu      sync.Mutex
+	pending *loaderBatch[K]
+}
+
+// loaderBatch is a batch of keys collected by a Loader. Its done channel is closed once its
+// results are set.
+type loaderBatch[K comparable] struct {
+	ctx     context.Context
+	keys    []K
+	index   map[K]int
+	timer   *time.Timer
+	results []mo.Result[gjson.Result]
+	done    chan struct{}
+}
+
+// NewLoader returns a Loader fetching its batches of keys with batch.
+func NewLoader[K comparable](batch BatchFunc[K], options LoaderOptions) *Loader[K] {
+	if options.Wait <= 0 {
+		options.Wait = 2 * time.Millisecond
+	}
+	return &Loader[K]{batch: batch, options: options}
+}
+
+// Load returns the value of key, fetched along with the other keys loaded within the same
+// window. The batch is sent with the values of the context of its first key, but is not
+// cancelled by the contexts of the callers: cancelling ctx only stops waiting for the value.
+func (loader *Loader[K]) Load(ctx context.Context, key K) (gjson.Result, error) {
+	loader.mu.Lock()
+	batch := loader.pending
+	if batch == nil {
+		batch = &loaderBatch[K]{
+			ctx:   context.WithoutCancel(ctx),
+			index: make(map[K]int),
+			done:  make(chan struct{}),
+		}
+		batch.timer = time.AfterFunc(loader.options.Wait, func() {
+			loader.dispatch(batch)
+		})
+		loader.pending = batch
+	}
+	i, ok := batch.index[key]
+	if !ok {
+		i = len(batch.keys)
+		batch.index[key] = i
+		batch.keys = append(batch.keys, key)
+	}
+	full := loader.options.MaxBatch > 0 && len(batch.keys) >= loader.options.MaxBatch
+	if full {
+		loader.pending = nil
+	}
+	loader.mu.Unlock()
+	if full && batch.timer.Stop() {
+		go loader.dispatch(batch)
+	}
+
+	select {
+	case <-batch.done:
+		return batch.results[i].Get()
+	case <-ctx.Done():
+		return gjson.Result{}, ctx.Err()
+	}
+}
+
+// dispatch sends the batch and sets its results. Once the batch is no longer pending, its
+// keys are not modified anymore.
+func (loader *Loader[K]) dispatch(batch *loaderBatch[K]) {
+	loader.mu.Lock()
+	if loader.pending == batch {
+		loader.pending = nil
+	}
+	loader.mu.Unlock()
+	defer close(batch.done)
+
+	results, err := loader.batch(batch.ctx, batch.keys)
+	if err == nil && len(results) != len(batch.keys) {
+		err = fmt.Errorf("batch returned %d results for %d keys", len(results), len(batch.keys))
+	}
+	if err != nil {
+		results = make([]mo.Result[gjson.Result], len(batch.keys))
+		for i := range results {
+			results[i] = mo.Err[gjson.Result](err)
+		}
+	}
+	batch.results = results
+}
+
+// AliasedBatch returns a BatchFunc fetching the keys with a single query selecting field
+// once per key, under the aliases k0, k1 and so on, with the key passed as argument of type
+// argumentType, such as "ID!":
+//
+//	query ($k0: ID!, $k1: ID!) { k0: user(id: $k0) { id name } k1: user(id: $k1) { id name } }
+//
+// The value of each key is its aliased field. Keys whose field is reported by GraphQL
+// errors fail with an *ErrGraphQL holding these errors.
+func AliasedBatch[K comparable](client *Client, field FieldBuilder, argument, argumentType string) BatchFunc[K] {
+	return func(ctx context.Context, keys []K) ([]mo.Result[gjson.Result], error) {
+		// The document is assembled in place rather than with Var and And, which copy the
+		// definitions and fields at every key.
+		operation := OperationBuilder{
+			kind:      "query",
+			variables: make([]variableDefinition, len(keys)),
+			fields:    make([]FieldBuilder, len(keys)),
+		}
+		variables := make(map[string]any, len(keys))
+		for i, key := range keys {
+			alias := "k" + strconv.Itoa(i)
+			operation.variables[i] = variableDefinition{name: alias, kind: argumentType}
+			operation.fields[i] = field.Alias(alias).Arg(argument, "$"+alias)
+			variables[alias] = key
+		}
+		response, err := client.NewRequest().Query(operation.String()).AddVariables(variables).ExecuteResponse(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !response.Data.IsObject() {
+			return nil, batchError(response)
+		}
+
+		results := make([]mo.Result[gjson.Result], len(keys))
+		for i := range keys {
+			alias := "k" + strconv.Itoa(i)
+			graphQLErrors := response.ErrorsAt(alias)
+			if len(graphQLErrors) > 0 {
+				results[i] = mo.Err[gjson.Result](&ErrGraphQL{Errors: graphQLErrors})
+			} else {
+				results[i] = mo.Ok(response.Data.Get(alias))
+			}
+		}
+		return results, nil
+	}
+}
+
+// ListBatch returns a BatchFunc fetching the keys with a single query selecting field,
+// which takes the list of keys as argument of type argumentType, such as "[ID!]!", and
+// returns a list of values:
+//
+//	query ($keys: [ID!]!) { users(ids: $keys) { id name } }
+//
+// Values are matched with their key by the value found at keyPath, a gjson path such as
+// "id" that field must select; keys without value get a result that doesn't exist. An
+// empty keyPath matches the values with the keys by position, for servers returning the
+// values in the order of the keys.
+func ListBatch[K comparable](client *Client, field FieldBuilder, argument, argumentType, keyPath string) BatchFunc[K] {
+	return func(ctx context.Context, keys []K) ([]mo.Result[gjson.Result], error) {
+		operation := OperationBuilder{kind: "query"}.Var("keys", argumentType).And(field.Alias("values").Arg(argument, "$keys"))
+		response, err := client.NewRequest().Query(operation.String()).AddVariable("keys", keys).ExecuteResponse(ctx)
+		if err != nil {
+			return nil, err
+		}
+		values := response.Data.Get("values")
+		if !values.IsArray() {
+			return nil, batchError(response)
+		}
+
+		elements := values.Array()
+		results := make([]mo.Result[gjson.Result], len(keys))
+		if keyPath == "" {
+			if len(elements) != len(keys) {
+				return nil, fmt.Errorf("batch returned %d values for %d keys", len(elements), len(keys))
+			}
+			for i, element := range elements {
+				results[i] = mo.Ok(element)
+			}
+			return results, nil
+		}
+
+		byKey := make(map[string]gjson.Result, len(elements))
+		for _, element := range elements {
+			byKey[element.Get(keyPath).String()] = element
+		}
+		for i, key := range keys {
+			results[i] = mo.Ok(byKey[fmt.Sprint(key)])
+		}
+		return results, nil
+	}
+}
+
+// batchError returns the error of a batch response lacking the expected data: its GraphQL
+// errors, or an *ErrDecode when there are none.
+func batchError(response Response) error {
+	err := response.Err()
+	if err != nil {
+		return err
+	}
+	return &ErrDecode{Err: errors.New("batch response holds no data")}
+}
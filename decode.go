@@ -0,0 +1,257 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Decode unmarshals the "data" member of the response into the value pointed to by v with
+// the Codec of the request (see Client.WithCodec), following the rules of encoding/json by
+// default. It returns an *ErrDecode if the response has no data
+// or if the data cannot be unmarshalled. When the response has no data but contains GraphQL
+// errors, an *ErrGraphQL is returned instead.
+func (response Response) Decode(v any) error {
+	return response.decode(v, decodeOptions{})
+}
+
+// DecodeStrict behaves like Decode but also fails when the data doesn't match the shape of
+// v exactly, catching drift between client models and the schema of the server: members of
+// the data without a matching field in v are rejected, as well as missing or null members
+// matching a struct field tagged with `ggql:"required"`.
+func (response Response) DecodeStrict(v any) error {
+	return response.decode(v, decodeOptions{strict: true})
+}
+
+// decodeOptions holds the decoding options set on a request.
+type decodeOptions struct {
+	strict    bool
+	useNumber bool
+}
+
+// decode implements Decode and DecodeStrict.
+func (response Response) decode(v any, options decodeOptions) error {
+	if !response.Data.Exists() || response.Data.Type == gjson.Null {
+		if err := response.Err(); err != nil {
+			return err
+		}
+		return &ErrDecode{Err: errors.New("response contains no data")}
+	}
+
+	codec := response.codec
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	decoder := codec.NewDecoder(strings.NewReader(response.Data.Raw))
+	if options.useNumber {
+		decoder.UseNumber()
+	}
+	if options.strict {
+		decoder.DisallowUnknownFields()
+	}
+	err := decoder.Decode(v)
+	if err != nil {
+		return &ErrDecode{Err: fmt.Errorf("decoding data: %w", err)}
+	}
+	if !options.strict {
+		return nil
+	}
+	err = checkRequired(response.Data, reflect.TypeOf(v), "data")
+	if err != nil {
+		return &ErrDecode{Err: err}
+	}
+	return nil
+}
+
+// checkRequired returns an *ErrPath for the first member of value, found at path, that is
+// missing or null while its struct field in t is tagged with `ggql:"required"`.
+func checkRequired(value gjson.Result, t reflect.Type, path string) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if !value.IsObject() {
+			return nil
+		}
+		return checkRequiredFields(value, t, path)
+	case reflect.Slice, reflect.Array:
+		if !value.IsArray() {
+			return nil
+		}
+		for i, element := range value.Array() {
+			err := checkRequired(element, t.Elem(), path+"."+strconv.Itoa(i))
+			if err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if !value.IsObject() {
+			return nil
+		}
+		var err error
+		value.ForEach(func(key, element gjson.Result) bool {
+			err = checkRequired(element, t.Elem(), path+"."+key.Str)
+			return err == nil
+		})
+		return err
+	}
+	return nil
+}
+
+// checkRequiredFields checks the members of the object value against the fields of the
+// struct type t, including the fields promoted from embedded structs.
+func checkRequiredFields(value gjson.Result, t reflect.Type, path string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				err := checkRequiredFields(value, embedded, path)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		member := objectMember(value, name)
+		if !member.Exists() || member.Type == gjson.Null {
+			if field.Tag.Get("ggql") == "required" {
+				found := "missing"
+				if member.Exists() {
+					found = "null"
+				}
+				return &ErrPath{Path: path + "." + name, Expected: "value", Found: found}
+			}
+			continue
+		}
+		err := checkRequired(member, field.Type, path+"."+name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// objectMember returns the member of object named name, preferring an exact match but
+// accepting a case-insensitive one like encoding/json.
+func objectMember(object gjson.Result, name string) gjson.Result {
+	var member gjson.Result
+	object.ForEach(func(key, value gjson.Result) bool {
+		if key.Str == name {
+			member = value
+			return false
+		}
+		if !member.Exists() && strings.EqualFold(key.Str, name) {
+			member = value
+		}
+		return true
+	})
+	return member
+}
+
+// StrictDecode makes DoInto, DoIntoCtx and ExecuteInto decode the response of the request
+// with Response.DecodeStrict. The modified Request is returned.
+func (request Request) StrictDecode() Request {
+	request.decoding.strict = true
+	return request
+}
+
+// UseNumber makes DoInto, DoIntoCtx and ExecuteInto decode the numbers of the response that
+// are stored in interface values, such as the values of a map[string]any, as json.Number
+// rather than float64. This preserves the precision of the Int64 and BigInt scalars used by
+// some servers, which exceed the 53 bits of integer precision of float64. Fields of type
+// int64 or json.Number are decoded without loss in any case. The modified Request is
+// returned.
+func (request Request) UseNumber() Request {
+	request.decoding.useNumber = true
+	return request
+}
+
+// DoInto sends the request and unmarshals the "data" member of the response into a value
+// of type T. It is a type-safe alternative to navigating the gjson result returned by Do.
+func DoInto[T any](request Request) mo.Result[T] {
+	return DoIntoCtx[T](context.Background(), request)
+}
+
+// DoIntoCtx behaves like DoInto but binds the outgoing HTTP request to the provided context.
+func DoIntoCtx[T any](ctx context.Context, request Request) mo.Result[T] {
+	var value T
+	response, err := request.do(ctx)
+	if err != nil {
+		return mo.Err[T](err)
+	}
+
+	err = response.decode(&value, request.decoding)
+	if err != nil {
+		return mo.Err[T](err)
+	}
+	return mo.Ok(value)
+}
+
+// ExecuteInto is the (value, error) counterpart of DoIntoCtx. Like Request.Execute, it fails
+// with an ErrHTTPStatus when the endpoint answers with a non-2xx HTTP status.
+func ExecuteInto[T any](ctx context.Context, request Request) (T, error) {
+	return DoIntoCtx[T](ctx, request.failingOnHTTPStatus()).Get()
+}
+
+// Slice unmarshals the elements of the array found at path in result into a []T with the
+// default JSONCodec, following the rules of encoding/json; DecodeSlice uses the Codec of the
+// request instead. The path is a gjson path such as "data.users"; an empty path designates
+// result itself. A null value yields a nil slice. It returns an *ErrDecode wrapping an
+// *ErrPath if the value is missing or is not an array, or the unmarshalling error of the
+// first invalid element along with its index.
+func Slice[T any](result gjson.Result, path string) ([]T, error) {
+	return decodeSlice[T](JSONCodec{}, result, path)
+}
+
+// DecodeSlice is like Slice, but unmarshals the elements of the array found at path in the
+// "data" member of the response with the Codec of the request (see Client.WithCodec), like
+// Decode.
+func DecodeSlice[T any](response Response, path string) ([]T, error) {
+	codec := response.codec
+	if codec == nil {
+		codec = JSONCodec{}
+	}
+	return decodeSlice[T](codec, response.Data, path)
+}
+
+// decodeSlice implements Slice and DecodeSlice, unmarshalling the elements with codec.
+func decodeSlice[T any](codec Codec, result gjson.Result, path string) ([]T, error) {
+	value := result
+	if path != "" {
+		value = result.Get(path)
+	}
+	if value.Type == gjson.Null && value.Exists() {
+		return nil, nil
+	}
+	if !value.IsArray() {
+		return nil, pathError(path, "array", value)
+	}
+
+	elements := value.Array()
+	slice := make([]T, len(elements))
+	for i, element := range elements {
+		err := codec.NewDecoder(strings.NewReader(element.Raw)).Decode(&slice[i])
+		if err != nil {
+			return nil, &ErrDecode{Err: fmt.Errorf("path %q, index %d: %w", path, i, err)}
+		}
+	}
+	return slice, nil
+}
@@ -0,0 +1,47 @@
+package ggql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+// countingCodec is a JSONCodec counting the decoders it creates.
+type countingCodec struct {
+	JSONCodec
+	decoders *atomic.Int32
+}
+
+// NewDecoder implements Codec.
+func (codec countingCodec) NewDecoder(r io.Reader) Decoder {
+	codec.decoders.Add(1)
+	return codec.JSONCodec.NewDecoder(r)
+}
+
+func TestEntitiesDecodeWithCodec(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"_entities":[{"id":"1"},{"id":"2"}]}}`))
+	}))
+	t.Cleanup(server.Close)
+	var decoders atomic.Int32
+	client := NewClient(server.URL).WithCodec(countingCodec{decoders: &decoders})
+
+	type product struct {
+		ID string `json:"id"`
+	}
+	request := client.EntitiesRequest("Product", "id", map[string]any{"id": "1"}, map[string]any{"id": "2"})
+	products, err := Entities[product](context.Background(), request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(products) != 2 || products[0].ID != "1" || products[1].ID != "2" {
+		t.Errorf("got %+v", products)
+	}
+	if decoders.Load() != 2 {
+		t.Errorf("got %d decoders from the codec, want 2", decoders.Load())
+	}
+}
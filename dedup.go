@@ -0,0 +1,75 @@
+package ggql
+
+import (
+	"context"
+	"golang.org/x/sync/singleflight"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// WithDeduplication makes concurrent identical queries share a single network round trip:
+// while a query is in flight, queries executed through the client with the same endpoint,
+// document, operation name, variables and headers identifying the caller (see
+// WithCacheKeyHeaders) wait for its response instead of being sent. The response is shared
+// by every caller and must not be modified.
+// The shared exchange carries the values and deadline of the context of the first caller,
+// but not its cancellation: each caller stops waiting when its own context is done, while
+// the exchange goes on for the others. Mutations, subscriptions and requests authenticated
+// with their own credentials are never deduplicated. The updated Client is returned.
+func (client *Client) WithDeduplication() *Client {
+	client.dedup = &singleflight.Group{}
+	return client
+}
+
+// deduplicate returns a Handler sharing the in-flight calls to next of identical queries.
+func deduplicate(group *singleflight.Group, next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		if request.auth != nil || operationType(request.Request, request.operationName) != "query" {
+			return next(ctx, request)
+		}
+		key, err := cacheKey(request)
+		if err != nil {
+			return next(ctx, request)
+		}
+
+		results := group.DoChan(key, func() (any, error) {
+			shared, cancel := detach(ctx)
+			defer cancel()
+			return next(shared, request)
+		})
+		select {
+		case result := <-results:
+			response, _ := result.Val.(Response)
+			return response, result.Err
+		case <-ctx.Done():
+			return Response{}, ctx.Err()
+		}
+	}
+}
+
+// detach returns a context carrying the values and deadline of ctx but not its
+// cancellation, so that an exchange shared by several callers outlives the first one.
+func detach(ctx context.Context) (context.Context, context.CancelFunc) {
+	detached := context.WithoutCancel(ctx)
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(detached)
+	}
+	return context.WithDeadline(detached, deadline)
+}
+
+// headerKey returns a deterministic representation of header.
+func headerKey(header http.Header) string {
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var builder strings.Builder
+	for _, key := range keys {
+		builder.WriteString("\x00" + key + ":" + strings.Join(header[key], ","))
+	}
+	return builder.String()
+}
@@ -0,0 +1,56 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDeduplicationSurvivesFirstCallerCancellation(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"data":{"a":1}}`))
+	}))
+	defer server.Close()
+	client := NewClient(server.URL).WithDeduplication()
+	query := client.NewRequest().Query(`{ a }`)
+
+	firstCtx, cancelFirst := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := query.ExecuteResponse(firstCtx)
+		first <- err
+	}()
+	for hits.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := make(chan error, 1)
+	var response Response
+	go func() {
+		var err error
+		response, err = query.ExecuteResponse(context.Background())
+		second <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	cancelFirst()
+	err := <-first
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("first caller got error %v, want context.Canceled", err)
+	}
+	close(release)
+	err = <-second
+	if err != nil {
+		t.Fatalf("second caller got error %v", err)
+	}
+	if response.Data.Get("a").Int() != 1 || hits.Load() != 1 {
+		t.Errorf("got %s with %d server hits, want the shared response", response.Data.Raw, hits.Load())
+	}
+}
@@ -0,0 +1,142 @@
+package ggql
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Dump renders the HTTP request sent by Execute as a curl command, so that failing requests
+// can be reproduced outside the program and shared with API providers. Credentials supplied
+// through WithBearerToken or WithBasicAuth and AWS signatures are included like any other
+// header; the values of the headers named in redact are replaced by "[REDACTED]", matched
+// case-insensitively, e.g. with DefaultRedactedHeaders. Registered fragments and trusted
+// document IDs are applied as they would be when sending; compressed bodies are rendered
+// uncompressed and persisted queries are rendered with the full document. The files of
+// uploads are not read: their parts hold a placeholder, so that the request can still be
+// sent afterwards.
+func (request Request) Dump(ctx context.Context, redact ...string) (string, error) {
+	return request.dump(ctx, true, redact)
+}
+
+// dump implements Dump. Unless credentials is set, the Authorization header is rendered
+// redacted instead of being obtained from the authorizer of the request.
+func (request Request) dump(ctx context.Context, credentials bool, redact []string) (string, error) {
+	if request.Request == "" {
+		return "", errors.New("no query/mutation provided")
+	}
+	request.Request = request.client.withFragments(request.Request)
+
+	c := content{
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     placeholderUploads(request.Variables),
+	}
+	_, err := request.trustedDocument(&c)
+	if err != nil {
+		return "", err
+	}
+	req, err := request.newHTTPRequest(ctx, c)
+	if err != nil {
+		return "", err
+	}
+	if credentials {
+		err = request.authorize(ctx, req.Header)
+		if err != nil {
+			return "", err
+		}
+	} else if request.resolveAuth() != nil {
+		req.Header.Set("Authorization", "[REDACTED]")
+	}
+	err = request.sign(ctx, req)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := dumpBody(req)
+	if err != nil {
+		return "", fmt.Errorf("reading request body: %w", err)
+	}
+	return curlCommand(req, body, nameSet(redact, nil)), nil
+}
+
+// DumpCurl returns a Middleware writing every request as a curl command to w, redacting the
+// values of the headers named in redact. See Request.Dump. The credentials of requests
+// authenticated with WithBearerToken or WithBasicAuth are not obtained twice: their
+// Authorization header is always redacted.
+func DumpCurl(w io.Writer, redact ...string) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request Request) (Response, error) {
+			dump, err := request.dump(ctx, false, redact)
+			if err != nil {
+				dump = "# dumping request: " + err.Error()
+			}
+			_, _ = fmt.Fprintln(w, dump)
+			return next(ctx, request)
+		}
+	}
+}
+
+// dumpBody returns the uncompressed body of req and removes its Content-Encoding header.
+func dumpBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	if req.Header.Get("Content-Encoding") != "gzip" {
+		return body, nil
+	}
+	req.Header.Del("Content-Encoding")
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
+
+// curlCommand renders req as a curl command line, one option per line. Headers are sorted
+// so that dumps of identical requests are identical.
+func curlCommand(req *http.Request, body []byte, redact map[string]bool) string {
+	var command strings.Builder
+	command.WriteString("curl")
+	if req.Method != http.MethodGet {
+		command.WriteString(" -X " + req.Method)
+	}
+	command.WriteString(" " + shellQuote(req.URL.String()))
+
+	keys := make([]string, 0, len(req.Header))
+	for key := range req.Header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range req.Header[key] {
+			if redact[strings.ToLower(key)] {
+				value = "[REDACTED]"
+			}
+			command.WriteString(" \\\n  -H " + shellQuote(key+": "+value))
+		}
+	}
+	if req.Header.Get("Content-Type") == "application/json" {
+		// Drop the newline terminating the JSON payload to keep the command on one line.
+		body = bytes.TrimSuffix(body, []byte("\n"))
+	}
+	if len(body) > 0 {
+		command.WriteString(" \\\n  --data-binary " + shellQuote(string(body)))
+	}
+	return command.String()
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,53 @@
+package ggql
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDumpCurlKeepsUploadsAndCredentials(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		file, _, err := r.FormFile("0")
+		if err == nil {
+			contents, _ := io.ReadAll(file)
+			received = string(contents)
+		}
+		_, _ = w.Write([]byte(`{"data":{"upload":true}}`))
+	}))
+	defer server.Close()
+
+	var dump strings.Builder
+	var tokens atomic.Int32
+	source := TokenSource(func(context.Context) (string, error) {
+		tokens.Add(1)
+		return "secret", nil
+	})
+	client := NewClient(server.URL).Use(DumpCurl(&dump))
+	_, err := client.NewRequest().
+		Query(`mutation ($file: Upload!) { upload(file: $file) }`).
+		AddVariable("file", Upload{File: strings.NewReader("file contents"), FileName: "a.txt"}).
+		WithBearerToken(source).
+		ExecuteResponse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if received != "file contents" {
+		t.Errorf("server received file %q, want %q", received, "file contents")
+	}
+	if !strings.Contains(dump.String(), uploadPlaceholder) {
+		t.Errorf("dump lacks the upload placeholder:\n%s", dump.String())
+	}
+	if strings.Contains(dump.String(), "secret") {
+		t.Errorf("dump holds the token:\n%s", dump.String())
+	}
+	if tokens.Load() != 1 {
+		t.Errorf("token source called %d times, want 1", tokens.Load())
+	}
+}
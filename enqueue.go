@@ -0,0 +1,157 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned by Client.Enqueue when the queue of the background workers is
+// full.
+var ErrQueueFull = errors.New("background queue full")
+
+// BackgroundPolicy configures the background workers of a client, see
+// Client.WithBackgroundWorkers.
+type BackgroundPolicy struct {
+	// Workers is the number of requests sent concurrently in the background, 1 when zero.
+	Workers int
+
+	// QueueSize is the number of requests waiting for a worker at most, beyond which
+	// Enqueue fails with ErrQueueFull. It defaults to 1000.
+	QueueSize int
+
+	// Retry configures the retries of the failed requests. Unlike the retry policy of the
+	// client, mutations are retried: requests are enqueued for writes that are safe to
+	// repeat, such as telemetry. Requests uploading files are not. These retries wrap the
+	// whole execution of the request, retries of the client included. A MaxAttempts lower
+	// than 2 disables them.
+	Retry RetryPolicy
+}
+
+// backgroundWorkers sends the requests enqueued on a client.
+type backgroundWorkers struct {
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan queuedRequest
+	handler Handler
+	wg      sync.WaitGroup
+
+	// previous is the pool replaced by this one, closed along with it.
+	previous *backgroundWorkers
+
+	// abort is cancelled when Close gives up waiting, failing the requests left.
+	abort  context.Context
+	cancel context.CancelFunc
+}
+
+// queuedRequest is a request waiting for a background worker.
+type queuedRequest struct {
+	request Request
+	done    func(Response, error)
+}
+
+// WithBackgroundWorkers starts a pool of workers sending the requests handed over with
+// Enqueue in the background, according to policy. The workers stop when the client is
+// closed, once the queued requests are sent. Calling it again replaces the pool for the
+// requests enqueued afterwards; the previous pool keeps running until the client is closed.
+// The updated Client is returned.
+func (client *Client) WithBackgroundWorkers(policy BackgroundPolicy) *Client {
+	workers := max(policy.Workers, 1)
+	queueSize := policy.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+
+	handler := Handler(func(ctx context.Context, request Request) (Response, error) {
+		return request.ExecuteResponse(ctx)
+	})
+	policy.Retry.RetryMutations = true
+	retry := policy.Retry.normalized()
+	if retry != nil {
+		handler = retry.middleware(handler)
+	}
+
+	abort, cancel := context.WithCancel(context.Background())
+	pool := &backgroundWorkers{
+		queue:    make(chan queuedRequest, queueSize),
+		handler:  handler,
+		previous: client.background,
+		abort:    abort,
+		cancel:   cancel,
+	}
+	pool.wg.Add(workers)
+	for range workers {
+		go pool.work()
+	}
+	client.background = pool
+	return client
+}
+
+// Enqueue hands the request over to the background workers of the client and returns
+// immediately, for fire-and-forget writes where the caller shouldn't wait, such as
+// telemetry mutations. The request is sent with the client's configuration, like
+// ExecuteResponse, and retried according to the policy of the workers; done, if not nil, is
+// called from a worker with the result of the last attempt. Enqueue fails with ErrQueueFull
+// when the queue is full, ErrClientClosed once the client is closed, or when the client has
+// no background workers, see WithBackgroundWorkers.
+func (client *Client) Enqueue(request Request, done func(Response, error)) error {
+	pool := client.background
+	if pool == nil {
+		return errors.New("client has no background workers, see WithBackgroundWorkers")
+	}
+	request.client = client
+
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+	if pool.closed {
+		return ErrClientClosed
+	}
+	select {
+	case pool.queue <- queuedRequest{request: request, done: done}:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// work sends the queued requests until the queue is closed and drained.
+func (pool *backgroundWorkers) work() {
+	defer pool.wg.Done()
+	for queued := range pool.queue {
+		response, err := pool.handler(pool.abort, queued.request)
+		if queued.done != nil {
+			queued.done(response, err)
+		}
+	}
+}
+
+// close stops accepting requests and waits for the workers to send the queued ones. When ctx
+// is done first, the requests left are cancelled and the error of ctx is returned.
+func (pool *backgroundWorkers) close(ctx context.Context) error {
+	if pool == nil {
+		return nil
+	}
+	err := pool.previous.close(ctx)
+
+	pool.mu.Lock()
+	if !pool.closed {
+		pool.closed = true
+		close(pool.queue)
+	}
+	pool.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		pool.wg.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		pool.cancel()
+		<-drained
+		err = ctx.Err()
+	}
+	pool.cancel()
+	return err
+}
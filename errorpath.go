@@ -0,0 +1,180 @@
+package ggql
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"strings"
+)
+
+// ErrorPath returns the gjson path of the data node designated by the path of a GraphQL
+// error, relative to the "data" member of the response: ["users", 3, "email"] gives
+// "users.3.email". Field names holding gjson's special characters are escaped.
+func ErrorPath(path []any) string {
+	segments := make([]string, len(path))
+	for i, segment := range path {
+		index, ok := pathIndex(segment)
+		if ok {
+			segments[i] = strconv.Itoa(index)
+		} else {
+			segments[i] = gjson.Escape(fmt.Sprint(segment))
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+// DataPath returns the gjson path of the data node the error relates to, relative to the
+// "data" member of the response, or an empty string when the error has no path. See
+// ErrorPath.
+func (err GraphQLError) DataPath() string {
+	return ErrorPath(err.Path)
+}
+
+// ErrorsAt returns the GraphQL errors of the response that relate to the data node at the
+// gjson path, relative to the "data" member, or to a node below it. An empty path returns
+// every error having a path.
+func (response Response) ErrorsAt(path string) []GraphQLError {
+	return errorsAt(response.Errors, path)
+}
+
+// ErrorsAt returns the GraphQL errors of the result that relate to the data node at the
+// gjson path or to a node below it, see Response.ErrorsAt.
+func (result PartialResult) ErrorsAt(path string) []GraphQLError {
+	return errorsAt(result.Errors, path)
+}
+
+// Pruned returns the data of the result without the nodes broken by its errors, see
+// PruneErrors.
+func (result PartialResult) Pruned() gjson.Result {
+	return PruneErrors(result.Data, result.Errors)
+}
+
+// errorsAt returns the errors whose path designates the node at path or a node below it.
+func errorsAt(errors []GraphQLError, path string) []GraphQLError {
+	var matching []GraphQLError
+	for _, err := range errors {
+		if len(err.Path) == 0 {
+			continue
+		}
+		dataPath := err.DataPath()
+		if path == "" || dataPath == path || strings.HasPrefix(dataPath, path+".") {
+			matching = append(matching, err)
+		}
+	}
+	return matching
+}
+
+// PruneErrors returns a copy of data, the "data" member of a response, without the nodes
+// broken by the errors, so that callers iterating partial data skip only the broken parts.
+// For an error whose path goes through a list, the innermost list element on the path is
+// removed: the error ["users", 3, "email"] removes the fourth user rather than leaving it
+// without email. Otherwise the node at the path is removed from its object. Errors without
+// path are ignored, and the untouched parts of data are copied verbatim.
+func PruneErrors(data gjson.Result, errors []GraphQLError) gjson.Result {
+	root := &pruneNode{}
+	for _, err := range errors {
+		path := err.Path
+		for i := len(path) - 1; i >= 0; i-- {
+			_, ok := pathIndex(path[i])
+			if ok {
+				path = path[:i+1]
+				break
+			}
+		}
+		if len(path) > 0 {
+			root.add(path)
+		}
+	}
+	if len(root.children) == 0 {
+		return data
+	}
+
+	var builder strings.Builder
+	root.write(&builder, data)
+	return gjson.Parse(builder.String())
+}
+
+// pruneNode is a node of the tree of the data nodes to remove, keyed by path segment.
+type pruneNode struct {
+	remove   bool
+	children map[string]*pruneNode
+}
+
+// add marks the node at path for removal.
+func (node *pruneNode) add(path []any) {
+	for _, segment := range path {
+		if node.remove {
+			return
+		}
+		key := fmt.Sprint(segment)
+		child := node.children[key]
+		if child == nil {
+			child = &pruneNode{}
+			if node.children == nil {
+				node.children = make(map[string]*pruneNode)
+			}
+			node.children[key] = child
+		}
+		node = child
+	}
+	node.remove = true
+}
+
+// write writes value without the members and elements marked for removal.
+func (node *pruneNode) write(builder *strings.Builder, value gjson.Result) {
+	if len(node.children) == 0 || !(value.IsObject() || value.IsArray()) {
+		builder.WriteString(value.Raw)
+		return
+	}
+
+	opening, closing := byte('{'), byte('}')
+	if value.IsArray() {
+		opening, closing = '[', ']'
+	}
+	builder.WriteByte(opening)
+	index := 0
+	first := true
+	value.ForEach(func(key, member gjson.Result) bool {
+		name := key.String()
+		if value.IsArray() {
+			name = strconv.Itoa(index)
+			index++
+		}
+		child := node.children[name]
+		if child != nil && child.remove {
+			return true
+		}
+		if !first {
+			builder.WriteByte(',')
+		}
+		first = false
+		if value.IsObject() {
+			builder.WriteString(key.Raw)
+			builder.WriteByte(':')
+		}
+		if child == nil {
+			builder.WriteString(member.Raw)
+		} else {
+			child.write(builder, member)
+		}
+		return true
+	})
+	builder.WriteByte(closing)
+}
+
+// pathIndex returns the list index held by a segment of an error path, decoded from JSON as
+// a float64 or json.Number.
+func pathIndex(segment any) (int, bool) {
+	switch segment := segment.(type) {
+	case int:
+		return segment, true
+	case float64:
+		return int(segment), segment == float64(int(segment))
+	case json.Number:
+		index, err := strconv.Atoi(string(segment))
+		return index, err == nil
+	default:
+		return 0, false
+	}
+}
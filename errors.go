@@ -0,0 +1,363 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// bodySnippetLength is the maximum number of bytes of a response body included in the
+// message of an ErrHTTPStatus.
+const bodySnippetLength = 256
+
+// ErrTransport is returned when the HTTP exchange with the endpoint fails: the request could
+// not be created or sent, or the response could not be read. Op describes the failed step.
+type ErrTransport struct {
+	Op  string
+	Err error
+}
+
+// Error implements the error interface.
+func (err *ErrTransport) Error() string {
+	return err.Op + ": " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error, so that errors.Is can match causes such as
+// context.Canceled or context.DeadlineExceeded.
+func (err *ErrTransport) Unwrap() error {
+	return err.Err
+}
+
+// ErrTimeout is returned when a request exceeds the timeout set with Request.WithTimeout or
+// Client.WithTimeout. It is not returned when the deadline comes from the caller's context.
+type ErrTimeout struct {
+	Timeout time.Duration
+	Err     error
+}
+
+// Error implements the error interface.
+func (err *ErrTimeout) Error() string {
+	return fmt.Sprintf("request timed out after %s: %v", err.Timeout, err.Err)
+}
+
+// Unwrap returns the underlying error, which wraps context.DeadlineExceeded.
+func (err *ErrTimeout) Unwrap() error {
+	return err.Err
+}
+
+// ErrHTTPStatus is returned when the endpoint answers with a non-2xx HTTP status code.
+// Body holds the response body, which often describes the failure.
+type ErrHTTPStatus struct {
+	Code int
+	Body []byte
+}
+
+// Error implements the error interface. The message includes a snippet of the body.
+func (err *ErrHTTPStatus) Error() string {
+	snippet := strings.TrimSpace(string(err.Body))
+	if len(snippet) > bodySnippetLength {
+		snippet = snippet[:bodySnippetLength] + "..."
+	}
+	if snippet == "" {
+		return fmt.Sprintf("unexpected HTTP status %d", err.Code)
+	}
+	return fmt.Sprintf("unexpected HTTP status %d: %s", err.Code, snippet)
+}
+
+// ErrResponseTooLarge is returned when a response body exceeds the size limit set with
+// Client.WithMaxResponseSize or Request.WithMaxResponseSize.
+type ErrResponseTooLarge struct {
+	Limit int64
+}
+
+// Error implements the error interface.
+func (err *ErrResponseTooLarge) Error() string {
+	return fmt.Sprintf("response body exceeds %d bytes", err.Limit)
+}
+
+// ErrMissingHeader is returned without reaching the endpoint when a request lacks a header
+// required by its client, see Client.RequireHeaders.
+type ErrMissingHeader struct {
+	Name string
+}
+
+// Error implements the error interface.
+func (err *ErrMissingHeader) Error() string {
+	return fmt.Sprintf("missing required header %s", err.Name)
+}
+
+// ErrRedirect is returned, wrapped in an ErrTransport, when the endpoint redirects a request
+// in a way forbidden by the RedirectPolicy of the client.
+type ErrRedirect struct {
+	Location string
+	Reason   string
+}
+
+// Error implements the error interface.
+func (err *ErrRedirect) Error() string {
+	return fmt.Sprintf("redirect to %s refused: %s", err.Location, err.Reason)
+}
+
+// ErrGraphQL is returned when the response contains GraphQL errors and the caller asked
+// for them to be reported as Go errors.
+type ErrGraphQL struct {
+	Errors []GraphQLError
+}
+
+// Error implements the error interface. The messages of all errors are joined.
+func (err *ErrGraphQL) Error() string {
+	messages := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		messages[i] = e.Error()
+	}
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the individual GraphQL errors, so that errors.As can extract a GraphQLError.
+func (err *ErrGraphQL) Unwrap() []error {
+	errs := make([]error, len(err.Errors))
+	for i, e := range err.Errors {
+		errs[i] = e
+	}
+	return errs
+}
+
+// ErrValidation is returned before any network call when the document of a request is
+// invalid, see Client.WithValidation and Request.Validate. Each error locates the problem
+// in the document and, for validation errors, names the violated rule in its "rule"
+// extension.
+type ErrValidation struct {
+	Errors []GraphQLError
+}
+
+// Error implements the error interface. The messages of all errors are joined, each one
+// followed by its line and column.
+func (err *ErrValidation) Error() string {
+	messages := make([]string, len(err.Errors))
+	for i, e := range err.Errors {
+		messages[i] = e.Message
+		for _, location := range e.Locations {
+			messages[i] += fmt.Sprintf(" (%d:%d)", location.Line, location.Column)
+		}
+	}
+	return "invalid document: " + strings.Join(messages, "; ")
+}
+
+// ErrUntrustedDocument is returned without reaching the endpoint when the document of a
+// request is not part of the trusted documents manifest of a strict client, see
+// Client.WithTrustedDocuments.
+type ErrUntrustedDocument struct {
+	OperationName string
+}
+
+// Error implements the error interface.
+func (err *ErrUntrustedDocument) Error() string {
+	if err.OperationName == "" {
+		return "document is not trusted"
+	}
+	return fmt.Sprintf("document of operation %s is not trusted", err.OperationName)
+}
+
+// ErrOperationNotAllowed is returned without reaching the endpoint when the document of a
+// request is not part of the allowlist of the client, see Client.WithAllowlist. Hash is the
+// hash of the rejected document, to be looked up in or added to the manifest.
+type ErrOperationNotAllowed struct {
+	OperationName string
+	Hash          string
+}
+
+// Error implements the error interface.
+func (err *ErrOperationNotAllowed) Error() string {
+	if err.OperationName == "" {
+		return fmt.Sprintf("document %s is not allowed", err.Hash)
+	}
+	return fmt.Sprintf("operation %s (%s) is not allowed", err.OperationName, err.Hash)
+}
+
+// ErrTooComplex is returned without reaching the endpoint when the estimated complexity of
+// the operation of a request exceeds the limit of the client, see
+// Client.WithComplexityLimit.
+type ErrTooComplex struct {
+	OperationName string
+	Complexity    Complexity
+	Limit         ComplexityLimit
+}
+
+// Error implements the error interface. The first exceeded maximum is reported.
+func (err *ErrTooComplex) Error() string {
+	operation := "operation"
+	if err.OperationName != "" {
+		operation += " " + err.OperationName
+	}
+	switch {
+	case err.Limit.MaxDepth > 0 && err.Complexity.Depth > err.Limit.MaxDepth:
+		return fmt.Sprintf("%s too complex: depth %d exceeds %d", operation, err.Complexity.Depth, err.Limit.MaxDepth)
+	case err.Limit.MaxFields > 0 && err.Complexity.Fields > err.Limit.MaxFields:
+		return fmt.Sprintf("%s too complex: %d fields exceed %d", operation, err.Complexity.Fields, err.Limit.MaxFields)
+	default:
+		return fmt.Sprintf("%s too complex: cost %d exceeds %d", operation, err.Complexity.Cost, err.Limit.MaxCost)
+	}
+}
+
+// ErrDecode is returned when the response body cannot be parsed, or when its data cannot be
+// unmarshalled into the value requested by the caller.
+type ErrDecode struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (err *ErrDecode) Error() string {
+	return "decoding response: " + err.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (err *ErrDecode) Unwrap() error {
+	return err.Err
+}
+
+// ErrPath is wrapped in the *ErrDecode returned by the typed getters of Response, such as
+// Response.String, when the value at Path is missing or is not of the Expected kind.
+// Found is "missing" when the path doesn't exist, or the JSON kind of the value otherwise.
+type ErrPath struct {
+	Path     string
+	Expected string
+	Found    string
+}
+
+// Error implements the error interface.
+func (err *ErrPath) Error() string {
+	if err.Found == "missing" {
+		return fmt.Sprintf("path %q is missing", err.Path)
+	}
+	return fmt.Sprintf("path %q: expected %s, found %s", err.Path, err.Expected, err.Found)
+}
+
+// ErrCircuitOpen is returned without reaching the endpoint when its circuit breaker is open,
+// see Client.WithCircuitBreaker. RetryAt is the time at which a probe request will be let
+// through, or the zero time when another probe is already in flight.
+type ErrCircuitOpen struct {
+	Endpoint string
+	RetryAt  time.Time
+}
+
+// Error implements the error interface.
+func (err *ErrCircuitOpen) Error() string {
+	if err.RetryAt.IsZero() {
+		return fmt.Sprintf("circuit open for %s", err.Endpoint)
+	}
+	return fmt.Sprintf("circuit open for %s until %s", err.Endpoint, err.RetryAt.Format(time.RFC3339))
+}
+
+// ErrRateLimited is returned when a request is rejected by the client's rate limit, see
+// Client.WithRateLimit. Delay is the time to wait before a token is available when the
+// request failed fast; Err is the reason the wait for a token was abandoned otherwise,
+// such as the cancellation of the request's context.
+type ErrRateLimited struct {
+	Delay time.Duration
+	Err   error
+}
+
+// Error implements the error interface.
+func (err *ErrRateLimited) Error() string {
+	if err.Err != nil {
+		return "rate limited: " + err.Err.Error()
+	}
+	return fmt.Sprintf("rate limited: retry in %s", err.Delay)
+}
+
+// Unwrap returns the underlying error, if any.
+func (err *ErrRateLimited) Unwrap() error {
+	return err.Err
+}
+
+// ErrConcurrencyLimited is returned when a request is rejected by the client's concurrency
+// limit, see Client.WithMaxConcurrency. Limit is the number of requests allowed in flight.
+// Waited is the time the request was queued when it timed out; Err is the reason the wait
+// for a slot was abandoned otherwise, such as the cancellation of the request's context.
+// Both are zero when the queue was full.
+type ErrConcurrencyLimited struct {
+	Limit  int
+	Waited time.Duration
+	Err    error
+}
+
+// Error implements the error interface.
+func (err *ErrConcurrencyLimited) Error() string {
+	switch {
+	case err.Err != nil:
+		return "concurrency limited: " + err.Err.Error()
+	case err.Waited > 0:
+		return fmt.Sprintf("concurrency limited: no request slot freed in %s", err.Waited)
+	default:
+		return fmt.Sprintf("concurrency limited: %d requests in flight and queue full", err.Limit)
+	}
+}
+
+// Unwrap returns the underlying error, if any.
+func (err *ErrConcurrencyLimited) Unwrap() error {
+	return err.Err
+}
+
+// Error classes returned by ErrorClass.
+const (
+	ErrorClassTimeout     = "timeout"
+	ErrorClassCanceled    = "canceled"
+	ErrorClassTransport   = "transport"
+	ErrorClassHTTPStatus  = "http_status"
+	ErrorClassGraphQL     = "graphql"
+	ErrorClassDecode      = "decode"
+	ErrorClassTooLarge    = "too_large"
+	ErrorClassValidation  = "validation"
+	ErrorClassCircuit     = "circuit_open"
+	ErrorClassRateLimit   = "rate_limited"
+	ErrorClassConcurrency = "concurrency_limited"
+	ErrorClassOther       = "other"
+)
+
+// ErrorClass returns a short, stable label describing the kind of err, suitable for logs and
+// metrics: one of the ErrorClass constants, or an empty string when err is nil.
+// Deadlines and cancellations are reported as such even when wrapped by an ErrTransport.
+func ErrorClass(err error) string {
+	var (
+		timeout     *ErrTimeout
+		httpStatus  *ErrHTTPStatus
+		graphQL     *ErrGraphQL
+		decode      *ErrDecode
+		transport   *ErrTransport
+		circuit     *ErrCircuitOpen
+		rateLimit   *ErrRateLimited
+		concurrency *ErrConcurrencyLimited
+		validation  *ErrValidation
+		tooLarge    *ErrResponseTooLarge
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &timeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrorClassTimeout
+	case errors.Is(err, context.Canceled):
+		return ErrorClassCanceled
+	case errors.As(err, &httpStatus):
+		return ErrorClassHTTPStatus
+	case errors.As(err, &graphQL):
+		return ErrorClassGraphQL
+	case errors.As(err, &decode):
+		return ErrorClassDecode
+	case errors.As(err, &validation):
+		return ErrorClassValidation
+	case errors.As(err, &tooLarge):
+		return ErrorClassTooLarge
+	case errors.As(err, &transport):
+		return ErrorClassTransport
+	case errors.As(err, &circuit):
+		return ErrorClassCircuit
+	case errors.As(err, &rateLimit):
+		return ErrorClassRateLimit
+	case errors.As(err, &concurrency):
+		return ErrorClassConcurrency
+	default:
+		return ErrorClassOther
+	}
+}
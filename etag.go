@@ -0,0 +1,114 @@
+package ggql
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+)
+
+// etagCache stores the last response received for each GET URL along with its ETag, so that
+// requests can be revalidated with If-None-Match. The least recently used entries are
+// evicted once maxEntries is reached.
+type etagCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+// etagEntry is a response stored by the etagCache.
+type etagEntry struct {
+	url    string
+	etag   string
+	status int
+	header http.Header
+	body   []byte
+}
+
+// WithETagCache makes the client revalidate the queries sent with GET (see UseGET) using
+// HTTP conditional requests, to exploit the caching support of CDN-fronted GraphQL APIs.
+// The responses carrying an ETag header are stored by URL, and later requests to the same URL
+// send it in an If-None-Match header; when the endpoint answers 304 Not Modified, the stored
+// response is returned in place of the empty one. Unlike WithCache, every request reaches the
+// endpoint, which decides whether the stored response is still current. At most maxEntries
+// responses are stored, the least recently used being evicted first; a zero or negative
+// maxEntries disables the cache. The updated Client is returned.
+func (client *Client) WithETagCache(maxEntries int) *Client {
+	if maxEntries <= 0 {
+		client.etags = nil
+		return client
+	}
+	client.etags = &etagCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+	return client
+}
+
+// conditional adds an If-None-Match header to req when a response to its URL is stored,
+// unless the caller already set the header.
+func (request Request) conditional(req *http.Request) {
+	if request.client == nil || request.client.etags == nil || req.Method != http.MethodGet || req.Header.Get("If-None-Match") != "" {
+		return
+	}
+	entry, ok := request.client.etags.get(req.URL.String())
+	if ok {
+		req.Header.Set("If-None-Match", entry.etag)
+	}
+}
+
+// revalidated returns the stored response matching a 304 Not Modified answer to req, with
+// its status and headers, or res and body unchanged otherwise. Successful responses carrying
+// an ETag header are stored for later revalidations.
+func (request Request) revalidated(req *http.Request, res *http.Response, body []byte) (*http.Response, []byte) {
+	if request.client == nil || request.client.etags == nil || req.Method != http.MethodGet {
+		return res, body
+	}
+	cache := request.client.etags
+	url := req.URL.String()
+	if res.StatusCode == http.StatusNotModified {
+		entry, ok := cache.get(url)
+		if !ok || req.Header.Get("If-None-Match") != entry.etag {
+			return res, body
+		}
+		revalidated := *res
+		revalidated.StatusCode = entry.status
+		revalidated.Header = entry.header.Clone()
+		return &revalidated, entry.body
+	}
+	etag := res.Header.Get("ETag")
+	if etag != "" && res.StatusCode >= 200 && res.StatusCode < 300 {
+		cache.set(etagEntry{url: url, etag: etag, status: res.StatusCode, header: res.Header.Clone(), body: body})
+	}
+	return res, body
+}
+
+// get returns the entry stored for url, marking it as recently used.
+func (cache *etagCache) get(url string) (etagEntry, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	element, ok := cache.entries[url]
+	if !ok {
+		return etagEntry{}, false
+	}
+	cache.order.MoveToFront(element)
+	return element.Value.(etagEntry), true
+}
+
+// set stores entry, evicting the least recently used entry when the cache is full.
+func (cache *etagCache) set(entry etagEntry) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if element, ok := cache.entries[entry.url]; ok {
+		element.Value = entry
+		cache.order.MoveToFront(element)
+		return
+	}
+	cache.entries[entry.url] = cache.order.PushFront(entry)
+	if cache.order.Len() > cache.maxEntries {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(etagEntry).url)
+	}
+}
@@ -0,0 +1,59 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+)
+
+// entitiesOperation is the name of the operations built by EntitiesRequest.
+const entitiesOperation = "Entities"
+
+// EntitiesRequest initializes a Request resolving entities of an Apollo Federation subgraph
+// through its _entities field, for subgraph-to-subgraph calls. Every key holds the key
+// fields of one entity of type typename, such as {"id": "42"} or, for compound keys,
+// {"sku": "x", "vendor": {"id": "7"}}; the "__typename" member of the representations is
+// added. The selection is the selection set applied to the entities, without braces, such
+// as "id name price". The request targets the client's endpoint like NewRequest and can be
+// customized before being passed to Entities.
+func (client *Client) EntitiesRequest(typename, selection string, keys ...map[string]any) Request {
+	representations := make([]any, len(keys))
+	for i, key := range keys {
+		representation := make(map[string]any, len(key)+1)
+		for field, value := range key {
+			representation[field] = value
+		}
+		representation["__typename"] = typename
+		representations[i] = representation
+	}
+	query := fmt.Sprintf("query %s($representations: [_Any!]!) { _entities(representations: $representations) { ... on %s { %s } } }",
+		entitiesOperation, typename, selection)
+	return client.NewRequest().Query(query).OperationName(entitiesOperation).AddVariable("representations", representations)
+}
+
+// Entities executes a request built by EntitiesRequest and decodes the resolved entities into
+// a []T, in the order of the keys; entities that the subgraph couldn't resolve are left to
+// the zero value of T. Like Request.Execute, it fails with an ErrHTTPStatus when the endpoint
+// answers with a non-2xx HTTP status. When the response carries GraphQL errors along with
+// entities, typically for the entities that failed to resolve, the decoded entities are
+// returned with an *ErrGraphQL.
+func Entities[T any](ctx context.Context, request Request) ([]T, error) {
+	response, err := request.ExecuteResponse(ctx)
+	if err != nil {
+		return nil, err
+	}
+	entities := response.Data.Get("_entities")
+	if !entities.Exists() || entities.Type == gjson.Null {
+		err = response.Err()
+		if err != nil {
+			return nil, err
+		}
+		return nil, &ErrDecode{Err: errors.New("response contains no entities")}
+	}
+	values, err := DecodeSlice[T](response, "_entities")
+	if err != nil {
+		return nil, err
+	}
+	return values, response.Err()
+}
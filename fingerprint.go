@@ -0,0 +1,196 @@
+package ggql
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/formatter"
+	"github.com/vektah/gqlparser/v2/lexer"
+	"github.com/vektah/gqlparser/v2/parser"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultFingerprintHeader is the header carrying the operation fingerprints attached by
+// WithFingerprintHeader when no other header is given.
+const DefaultFingerprintHeader = "X-GraphQL-Fingerprint"
+
+// maxFingerprints bounds the number of fingerprints memoized by Fingerprint.
+const maxFingerprints = 1024
+
+// fingerprints memoizes the fingerprints computed by Fingerprint, keyed by operation name
+// and document, as they are computed several times per request by the logging, metrics and
+// tracing middleware.
+var fingerprints = struct {
+	sync.Mutex
+	values map[[2]string]string
+}{values: make(map[[2]string]string)}
+
+// NormalizeQuery returns the canonical form of a GraphQL document: comments, commas and
+// insignificant whitespace are removed, a single space being kept between adjacent names and
+// numbers, and block strings are rewritten as regular strings. When sortFields is true, the
+// selections of every selection set are also sorted (fields by response name, then fragment
+// spreads and inline fragments) and the fragment definitions are sorted by name, so that
+// documents selecting the same fields in a different order share the same canonical form.
+// Sorting requires parsing the document, whose syntax errors are returned.
+func NormalizeQuery(document string, sortFields bool) (string, error) {
+	if sortFields {
+		parsed, err := parser.ParseQuery(&ast.Source{Input: document})
+		if err != nil {
+			return "", fmt.Errorf("parsing document: %w", err)
+		}
+		for _, operation := range parsed.Operations {
+			sortSelections(operation.SelectionSet)
+		}
+		for _, fragment := range parsed.Fragments {
+			sortSelections(fragment.SelectionSet)
+		}
+		sort.SliceStable(parsed.Fragments, func(i, j int) bool {
+			return parsed.Fragments[i].Name < parsed.Fragments[j].Name
+		})
+		var formatted strings.Builder
+		formatter.NewFormatter(&formatted).FormatQueryDocument(parsed)
+		document = formatted.String()
+	}
+
+	var builder strings.Builder
+	lex := lexer.New(&ast.Source{Input: document})
+	previousWord := false
+	for {
+		token, err := lex.ReadToken()
+		if err != nil {
+			return "", fmt.Errorf("reading document: %w", err)
+		}
+		switch token.Kind {
+		case lexer.EOF:
+			return builder.String(), nil
+		case lexer.Comment:
+			continue
+		case lexer.Name, lexer.Int, lexer.Float:
+			if previousWord {
+				builder.WriteByte(' ')
+			}
+			builder.WriteString(token.Value)
+			previousWord = true
+		case lexer.String, lexer.BlockString:
+			var encoded bytes.Buffer
+			encoder := json.NewEncoder(&encoded)
+			encoder.SetEscapeHTML(false)
+			err = encoder.Encode(token.Value)
+			if err != nil {
+				return "", fmt.Errorf("encoding string: %w", err)
+			}
+			builder.Write(bytes.TrimSuffix(encoded.Bytes(), []byte("\n")))
+			previousWord = false
+		default:
+			builder.WriteString(token.Kind.String())
+			previousWord = false
+		}
+	}
+}
+
+// sortSelections sorts the selections of the set, and of its nested sets, in place.
+func sortSelections(selections ast.SelectionSet) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			sortSelections(selection.SelectionSet)
+		case *ast.InlineFragment:
+			sortSelections(selection.SelectionSet)
+		}
+	}
+	sort.SliceStable(selections, func(i, j int) bool {
+		rankI, keyI := selectionKey(selections[i])
+		rankJ, keyJ := selectionKey(selections[j])
+		if rankI != rankJ {
+			return rankI < rankJ
+		}
+		return keyI < keyJ
+	})
+}
+
+// selectionKey returns the rank and the key ordering a selection within its set: fields come
+// first by response name, then fragment spreads by name, then inline fragments by type
+// condition.
+func selectionKey(selection ast.Selection) (int, string) {
+	switch selection := selection.(type) {
+	case *ast.Field:
+		if selection.Alias != "" {
+			return 0, selection.Alias
+		}
+		return 0, selection.Name
+	case *ast.FragmentSpread:
+		return 1, selection.Name
+	case *ast.InlineFragment:
+		return 2, selection.TypeCondition
+	}
+	return 3, ""
+}
+
+// Fingerprint returns the fingerprint of an operation: the first 16 hexadecimal digits of the
+// SHA-256 hash of the operation name and of the document normalized with sorted fields (see
+// NormalizeQuery). Operations differing only in formatting, comments, field order or variable
+// values share their fingerprint, so that backends and APMs can group the traffic by
+// operation shape. Documents that cannot be parsed are fingerprinted after lexical
+// normalization only, or as is when they cannot be tokenized either.
+func Fingerprint(document, operationName string) string {
+	key := [2]string{operationName, document}
+	fingerprints.Lock()
+	fingerprint, ok := fingerprints.values[key]
+	fingerprints.Unlock()
+	if ok {
+		return fingerprint
+	}
+
+	normalized, err := NormalizeQuery(document, true)
+	if err != nil {
+		normalized, err = NormalizeQuery(document, false)
+		if err != nil {
+			normalized = document
+		}
+	}
+	hash := sha256.Sum256([]byte(operationName + "\n" + normalized))
+	fingerprint = hex.EncodeToString(hash[:8])
+
+	fingerprints.Lock()
+	if len(fingerprints.values) >= maxFingerprints {
+		fingerprints.values = make(map[[2]string]string)
+	}
+	fingerprints.values[key] = fingerprint
+	fingerprints.Unlock()
+	return fingerprint
+}
+
+// Fingerprint returns the fingerprint of the operation sent by the request (see Fingerprint).
+func (request Request) Fingerprint() string {
+	return Fingerprint(request.Request, request.operationName)
+}
+
+// fingerprintHeader holds the header installed by WithFingerprintHeader.
+type fingerprintHeader string
+
+// WithFingerprintHeader sends the fingerprint of every operation (see Fingerprint) in the
+// given header, or DefaultFingerprintHeader when empty, so that backends can group the
+// traffic by operation shape without parsing the documents. The fingerprint is computed
+// after the fragments registered with WithFragments are appended. The updated Client is
+// returned.
+func (client *Client) WithFingerprintHeader(header string) *Client {
+	if header == "" {
+		header = DefaultFingerprintHeader
+	}
+	client.fingerprint = fingerprintHeader(header)
+	return client
+}
+
+// middleware returns a Handler adding the fingerprint of the operation to the requests passed
+// to next.
+func (header fingerprintHeader) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		return next(ctx, request.AddHeader(string(header), request.Fingerprint()))
+	}
+}
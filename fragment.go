@@ -0,0 +1,123 @@
+package ggql
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// fragmentRegistry holds the fragment definitions registered on a client, by name.
+type fragmentRegistry struct {
+	mu          sync.RWMutex
+	definitions map[string]string
+}
+
+// RegisterFragment registers a named fragment on the client. Documents of the requests
+// executed through the client may then spread the fragment without defining it: the
+// definitions of the registered fragments they reference, directly or through other
+// registered fragments, are appended to the document before it is sent.
+// body is either the full definition ("fragment UserFields on User { id name }") or the
+// part following the name ("on User { id name }"). Registering a name again replaces the
+// previous definition. The updated Client is returned.
+func (client *Client) RegisterFragment(name, body string) *Client {
+	body = strings.TrimSpace(body)
+	if !strings.HasPrefix(body, "fragment") {
+		body = "fragment " + name + " " + body
+	}
+
+	if client.fragments == nil {
+		client.fragments = &fragmentRegistry{definitions: make(map[string]string)}
+	}
+	client.fragments.mu.Lock()
+	defer client.fragments.mu.Unlock()
+	client.fragments.definitions[name] = body
+	return client
+}
+
+// withFragments returns the document followed by the definitions of the registered fragments
+// it references but does not define. Fragments that are not registered are left for the
+// server to report.
+func (client *Client) withFragments(document string) string {
+	if client == nil || client.fragments == nil {
+		return document
+	}
+	client.fragments.mu.RLock()
+	defer client.fragments.mu.RUnlock()
+
+	spreads, definitions := fragmentNames(document)
+	defined := make(map[string]bool, len(definitions))
+	for _, name := range definitions {
+		defined[name] = true
+	}
+
+	var builder strings.Builder
+	builder.WriteString(document)
+	for len(spreads) > 0 {
+		name := spreads[0]
+		spreads = spreads[1:]
+		if defined[name] {
+			continue
+		}
+		definition, ok := client.fragments.definitions[name]
+		if !ok {
+			continue
+		}
+		defined[name] = true
+		builder.WriteString("\n\n")
+		builder.WriteString(definition)
+		nested, _ := fragmentNames(definition)
+		spreads = append(spreads, nested...)
+	}
+	return builder.String()
+}
+
+// fragmentNames returns the names of the fragments spread in the document, in order of
+// appearance, and the names of the fragments it defines. Inline fragments are ignored.
+// Like operationType, the document is scanned lexically and does not need to be valid.
+func fragmentNames(document string) (spreads, definitions []string) {
+	spread, definition := false, false
+	depth := 0
+	for i := 0; i < len(document); {
+		char := document[i]
+		switch {
+		case char == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case char == '"':
+			i = skipString(document, i)
+		case strings.HasPrefix(document[i:], "..."):
+			spread, definition = true, false
+			i += 3
+		case isNameStart(char):
+			start := i
+			for i < len(document) && isNameContinue(document[i]) {
+				i++
+			}
+			word := document[start:i]
+			switch {
+			case spread && word != "on":
+				spreads = append(spreads, word)
+			case definition:
+				definitions = append(definitions, word)
+			case depth == 0 && word == "fragment":
+				definition = true
+				spread = false
+				continue
+			}
+			spread, definition = false, false
+		default:
+			switch char {
+			case '{':
+				depth++
+			case '}':
+				depth--
+			}
+			if !unicode.IsSpace(rune(char)) && char != ',' {
+				spread, definition = false, false
+			}
+			i++
+		}
+	}
+	return spreads, definitions
+}
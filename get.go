@@ -0,0 +1,85 @@
+package ggql
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// UseGET makes the request send queries with HTTP GET, encoding the query, variables,
+// operation name and extensions in the URL instead of a JSON body. GET responses can be
+// cached by CDNs and proxies. Mutations and requests carrying uploads are still sent with
+// POST, as the GraphQL over HTTP specification forbids mutations over GET.
+// The modified Request is returned.
+func (request Request) UseGET() Request {
+	request.get = true
+	return request
+}
+
+// usesGET reports whether the payload should be sent with HTTP GET.
+func (request Request) usesGET(c content) bool {
+	if !request.get || operationType(request.Request, c.OperationName) == "mutation" {
+		return false
+	}
+	_, uploads := extractUploads(c.Variables)
+	return len(uploads) == 0
+}
+
+// newGETRequest builds a GET request whose URL query string carries the payload.
+// Parameters already present in the endpoint URL are preserved.
+func (request Request) newGETRequest(ctx context.Context, c content) (*http.Request, error) {
+	endpoint, err := request.payloadURL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, &ErrTransport{Op: "creating request", Err: err}
+	}
+	req.Header = request.header()
+	req.Header.Set("Accept", "application/graphql-response+json, application/json")
+	return req, nil
+}
+
+// payloadURL returns the endpoint URL whose query string carries the members of the payload,
+// the variables and extensions being encoded as JSON. Parameters already present in the
+// endpoint URL are preserved.
+func (request Request) payloadURL(c content) (string, error) {
+	endpoint, err := url.Parse(request.Endpoint)
+	if err != nil {
+		return "", &ErrTransport{Op: "parsing endpoint", Err: err}
+	}
+
+	params := endpoint.Query()
+	if c.Query != "" {
+		params.Set("query", c.Query)
+	}
+	if c.DocumentID != "" {
+		params.Set("documentId", c.DocumentID)
+	}
+	if c.OperationName != "" {
+		params.Set("operationName", c.OperationName)
+	}
+	if len(c.Variables) > 0 {
+		encoded, err := encodeScalars(map[string]any(c.Variables))
+		if err != nil {
+			return "", fmt.Errorf("encoding variables: %w", err)
+		}
+		variables, err := marshal(request.resolveCodec(), encoded)
+		if err != nil {
+			return "", fmt.Errorf("encoding variables: %w", err)
+		}
+		params.Set("variables", string(variables))
+	}
+	if len(c.Extensions) > 0 {
+		extensions, err := marshal(request.resolveCodec(), c.Extensions)
+		if err != nil {
+			return "", fmt.Errorf("encoding extensions: %w", err)
+		}
+		params.Set("extensions", string(extensions))
+	}
+	endpoint.RawQuery = params.Encode()
+	return endpoint.String(), nil
+}
@@ -0,0 +1,121 @@
+package ggql
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"time"
+)
+
+// Exists reports whether the response contains a value at path, which is a gjson path
+// relative to the whole response body, such as "data.user.name". A null value exists.
+func (response Response) Exists(path string) bool {
+	return response.Raw.Get(path).Exists()
+}
+
+// String returns the string at path, which is a gjson path relative to the whole response
+// body, such as "data.user.name". Unlike gjson, which returns the zero value of missing or
+// mismatched values, it returns an *ErrDecode wrapping an *ErrPath when the value is missing
+// or is not a string.
+func (response Response) String(path string) (string, error) {
+	value, err := response.lookup(path, gjson.String, "string")
+	if err != nil {
+		return "", err
+	}
+	return value.Str, nil
+}
+
+// Int returns the integer at path, see String. Numbers with a fractional part or exceeding
+// the range of int64 are rejected rather than truncated.
+func (response Response) Int(path string) (int64, error) {
+	value, err := response.lookup(path, gjson.Number, "integer")
+	if err != nil {
+		return 0, err
+	}
+	integer, err := strconv.ParseInt(value.Raw, 10, 64)
+	if err != nil {
+		return 0, &ErrDecode{Err: &ErrPath{Path: path, Expected: "integer", Found: "number " + value.Raw}}
+	}
+	return integer, nil
+}
+
+// Float returns the number at path, see String.
+func (response Response) Float(path string) (float64, error) {
+	value, err := response.lookup(path, gjson.Number, "number")
+	if err != nil {
+		return 0, err
+	}
+	return value.Num, nil
+}
+
+// Number returns the number at path as written by the server, see String. Unlike Float, it
+// doesn't go through float64, so that large integers such as Int64 or BigInt scalars keep
+// their precision.
+func (response Response) Number(path string) (json.Number, error) {
+	value, err := response.lookup(path, gjson.Number, "number")
+	if err != nil {
+		return "", err
+	}
+	return json.Number(value.Raw), nil
+}
+
+// Bool returns the boolean at path, see String.
+func (response Response) Bool(path string) (bool, error) {
+	value := response.Raw.Get(path)
+	if value.Type != gjson.True && value.Type != gjson.False {
+		return false, pathError(path, "boolean", value)
+	}
+	return value.Bool(), nil
+}
+
+// Time parses the string at path with layout, such as time.RFC3339, see String. An
+// *ErrDecode wrapping the parsing error is returned when the string doesn't match layout.
+func (response Response) Time(path, layout string) (time.Time, error) {
+	value, err := response.String(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	parsed, err := time.Parse(layout, value)
+	if err != nil {
+		return time.Time{}, &ErrDecode{Err: fmt.Errorf("path %q: %w", path, err)}
+	}
+	return parsed, nil
+}
+
+// lookup returns the value at path if it is of type kind.
+func (response Response) lookup(path string, kind gjson.Type, expected string) (gjson.Result, error) {
+	value := response.Raw.Get(path)
+	if value.Type != kind {
+		return gjson.Result{}, pathError(path, expected, value)
+	}
+	return value, nil
+}
+
+// pathError returns the *ErrDecode reporting that value, found at path, is not of the
+// expected kind.
+func pathError(path, expected string, value gjson.Result) error {
+	found := "missing"
+	if value.Exists() {
+		found = jsonKind(value)
+	}
+	return &ErrDecode{Err: &ErrPath{Path: path, Expected: expected, Found: found}}
+}
+
+// jsonKind returns the name of the JSON kind of value.
+func jsonKind(value gjson.Result) string {
+	switch {
+	case value.IsObject():
+		return "object"
+	case value.IsArray():
+		return "array"
+	case value.Type == gjson.True, value.Type == gjson.False:
+		return "boolean"
+	case value.Type == gjson.String:
+		return "string"
+	case value.Type == gjson.Number:
+		return "number"
+	default:
+		return "null"
+	}
+}
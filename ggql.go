@@ -2,18 +2,49 @@ package ggql
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"github.com/samber/mo"
 	"github.com/tidwall/gjson"
 	"io"
 	"net/http"
+	"sort"
+	"time"
 )
 
 // Request represents an HTTP request to a specific endpoint with optional headers.
+// The builder methods are copy-on-write: they never mutate the maps of the Request they are
+// called on, so a base request can safely be shared and extended from several goroutines.
 type Request struct {
 	Endpoint, Request string
 	Headers           map[string]string
 	Variables         map[string]any
+
+	operationName string
+	callHeaders   map[string]string
+	fixedHeader   http.Header
+	client        *Client
+	httpClient    *http.Client
+	transport     http.RoundTripper
+
+	failOnErrors bool
+	initPayload  map[string]any
+	resume       func(map[string]any, gjson.Result) map[string]any
+	persisted    bool
+	get          bool
+	graphQLBody  bool
+	sse          bool
+	noCache      bool
+	cacheWindow  *cacheWindow
+	codec        Codec
+	timings      bool
+	decoding     decodeOptions
+	timeout      time.Duration
+	priority     int
+	maxSize      int64
+	status       statusPolicy
+	auth         authorizer
 }
 
 // NewRequest initializes a new Request object with the specified endpoint and an empty header map.
@@ -28,6 +59,7 @@ func NewRequest(endpoint string) Request {
 // AddHeader adds a header to the request. It takes a key-value pair and updates the
 // Headers map in the Request struct. The updated Request is then returned.
 func (request Request) AddHeader(key, value string) Request {
+	request.Headers = copyHeaders(request.Headers, 1)
 	request.Headers[key] = value
 	return request
 }
@@ -37,6 +69,7 @@ func (request Request) AddHeader(key, value string) Request {
 // map and adds it to the Headers map of the Request struct. The modified
 // Request is then returned.
 func (request Request) AddHeaders(headers map[string]string) Request {
+	request.Headers = copyHeaders(request.Headers, len(headers))
 	for key, value := range headers {
 		request.Headers[key] = value
 	}
@@ -47,6 +80,7 @@ func (request Request) AddHeaders(headers map[string]string) Request {
 // The keys parameter specifies the keys of the headers to be removed.
 // The function returns the modified Request.
 func (request Request) RemoveHeaders(keys ...string) Request {
+	request.Headers = copyHeaders(request.Headers, 0)
 	for _, key := range keys {
 		delete(request.Headers, key)
 	}
@@ -63,6 +97,7 @@ func (request Request) ClearHeaders() Request {
 // AddVariable adds a variable to the request. It takes a key-value pair and updates the
 // Variables map in the Request struct. The updated Request is then returned.
 func (request Request) AddVariable(key string, value any) Request {
+	request.Variables = copyVariables(request.Variables, 1)
 	request.Variables[key] = value
 	return request
 }
@@ -71,6 +106,7 @@ func (request Request) AddVariable(key string, value any) Request {
 // The keys parameter specifies the keys of the variables to be removed.
 // The function returns the modified Request.
 func (request Request) RemoveVariables(keys ...string) Request {
+	request.Variables = copyVariables(request.Variables, 0)
 	for _, key := range keys {
 		delete(request.Variables, key)
 	}
@@ -88,12 +124,28 @@ func (request Request) ClearVariables() Request {
 // It iterates through the variables map and assigns each key-value pair to the corresponding key in the Request's Variables map.
 // The updated Request struct is then returned.
 func (request Request) AddVariables(variables map[string]any) Request {
+	request.Variables = copyVariables(request.Variables, len(variables))
 	for key, value := range variables {
 		request.Variables[key] = value
 	}
 	return request
 }
 
+// Clone returns a deep copy of the request. Headers, variables and the nested maps and
+// slices held by variables are copied, so the clone shares no mutable state with the
+// original, even when their maps are modified directly.
+func (request Request) Clone() Request {
+	request.Headers = copyHeaders(request.Headers, 0)
+	request.Variables, _ = deepCopy(request.Variables).(map[string]any)
+	if request.Variables == nil {
+		request.Variables = make(map[string]any)
+	}
+	if request.initPayload != nil {
+		request.initPayload, _ = deepCopy(request.initPayload).(map[string]any)
+	}
+	return request
+}
+
 // Query sets the query for the request. It updates the Request field of the
 // Request struct and returns the modified Request.
 func (request Request) Query(query string) Request {
@@ -101,11 +153,253 @@ func (request Request) Query(query string) Request {
 	return request
 }
 
+// OperationName sets the name of the operation to execute. It is sent as "operationName"
+// in the payload and selects which operation runs when the query document contains several.
+// The modified Request is returned.
+func (request Request) OperationName(name string) Request {
+	request.operationName = name
+	return request
+}
+
+// Name returns the operation name set with OperationName, or an empty string if none was set.
+func (request Request) Name() string {
+	return request.operationName
+}
+
+// WithHTTPClient sets the *http.Client used to send the request, overriding the one of the
+// parent Client and http.DefaultClient. Passing nil restores the default resolution.
+// The modified Request is returned.
+func (request Request) WithHTTPClient(httpClient *http.Client) Request {
+	request.httpClient = httpClient
+	return request
+}
+
+// WithRoundTripper sets the http.RoundTripper used to send the request. The remaining settings
+// of the resolved *http.Client (timeout, cookie jar, redirect policy) are kept, only its
+// Transport is replaced. This is useful to plug in proxies, custom TLS settings or mocked
+// transports in tests. Passing nil restores the client's own transport.
+// The modified Request is returned.
+func (request Request) WithRoundTripper(transport http.RoundTripper) Request {
+	request.transport = transport
+	return request
+}
+
+// FailOnGraphQLErrors makes the request fail with a Go error when the response contains
+// GraphQL errors, instead of returning the response as a successful result.
+// The modified Request is returned.
+func (request Request) FailOnGraphQLErrors() Request {
+	request.failOnErrors = true
+	return request
+}
+
+// WithTimeout sets an overall deadline on the execution of the request, overriding the timeout
+// of the parent Client. When the deadline is exceeded, the request fails with an *ErrTimeout.
+// A zero duration falls back to the client's timeout. The modified Request is returned.
+func (request Request) WithTimeout(timeout time.Duration) Request {
+	request.timeout = timeout
+	return request
+}
+
+// resolveTimeout returns the timeout applied to the request: its own, or the parent Client's.
+func (request Request) resolveTimeout() time.Duration {
+	if request.timeout > 0 {
+		return request.timeout
+	}
+	if request.client != nil {
+		return request.client.Timeout
+	}
+	return 0
+}
+
+// header builds the HTTP header sent with the request from three layers, each taking
+// precedence over the previous one: the default headers of the parent Client, the headers
+// set on the request and the overrides passed to Do or DoCtx. Within a layer, keys are
+// applied in sorted order so that keys differing only in case resolve deterministically.
+// An empty value removes the header set by the previous layers. Requests executed by
+// HTTPTransport use the header of their Operation instead.
+func (request Request) header() http.Header {
+	if request.fixedHeader != nil {
+		return request.fixedHeader.Clone()
+	}
+	header := make(http.Header)
+	if request.client != nil {
+		applyHeaders(header, request.client.Headers)
+	}
+	applyHeaders(header, request.Headers)
+	applyHeaders(header, request.callHeaders)
+	return header
+}
+
+// applyHeaders sets the headers of layer on header, deleting the ones with an empty value.
+func applyHeaders(header http.Header, layer map[string]string) {
+	keys := make([]string, 0, len(layer))
+	for key := range layer {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if layer[key] == "" {
+			header.Del(key)
+			continue
+		}
+		header.Set(key, layer[key])
+	}
+}
+
+// withCallHeaders returns the request with the per-call header overrides added, later maps
+// taking precedence over earlier ones.
+func (request Request) withCallHeaders(overrides []map[string]string) Request {
+	if len(overrides) == 0 {
+		return request
+	}
+	request.callHeaders = copyHeaders(request.callHeaders, 0)
+	for _, headers := range overrides {
+		keys := make([]string, 0, len(headers))
+		for key := range headers {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			request.callHeaders[http.CanonicalHeaderKey(key)] = headers[key]
+		}
+	}
+	return request
+}
+
+// resolveHTTPClient returns the *http.Client used to send the request. A client set on the
+// request takes precedence over the parent Client's, which in turn takes precedence over
+// http.DefaultClient. A custom round tripper is applied on a shallow copy of the result, as
+// well as the dialing of the socket of unix:// endpoints, the redirect policy and the hooks of
+// the client.
+func (request Request) resolveHTTPClient() *http.Client {
+	httpClient := request.httpClient
+	if httpClient == nil {
+		httpClient = request.client.httpClient()
+	}
+	if request.transport != nil {
+		clone := *httpClient
+		clone.Transport = request.transport
+		httpClient = &clone
+	}
+	return request.client.withHooks(request.client.withRedirects(withUnixTransport(httpClient, request.Endpoint)))
+}
+
+// copyHeaders returns a copy of headers with room for extra additional entries.
+func copyHeaders(headers map[string]string, extra int) map[string]string {
+	copied := make(map[string]string, len(headers)+extra)
+	for key, value := range headers {
+		copied[key] = value
+	}
+	return copied
+}
+
+// copyVariables returns a shallow copy of variables with room for extra additional entries.
+func copyVariables(variables map[string]any, extra int) map[string]any {
+	copied := make(map[string]any, len(variables)+extra)
+	for key, value := range variables {
+		copied[key] = value
+	}
+	return copied
+}
+
+// deepCopy recursively copies the maps and slices commonly found in variables.
+// Other values are returned as is.
+func deepCopy(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		copied := make(map[string]any, len(v))
+		for key, item := range v {
+			copied[key] = deepCopy(item)
+		}
+		return copied
+	case []any:
+		copied := make([]any, len(v))
+		for i, item := range v {
+			copied[i] = deepCopy(item)
+		}
+		return copied
+	case map[string]string:
+		return copyHeaders(v, 0)
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return value
+	}
+}
+
 // content represents the request payload for an HTTP request sent to a GraphQL endpoint.
-// It contains a query string and a map of variables.
+// It contains a query string, the optional name of the operation to execute, a map of
+// variables encoded through the registered scalar marshalers and the optional protocol
+// extensions.
 type content struct {
-	Query     string         `json:"query"`
-	Variables map[string]any `json:"variables"`
+	Query         string         `json:"query,omitempty"`
+	OperationName string         `json:"operationName,omitempty"`
+	DocumentID    string         `json:"documentId,omitempty"`
+	Variables     variables      `json:"variables"`
+	Extensions    map[string]any `json:"extensions,omitempty"`
+}
+
+// newHTTPRequest builds the HTTP request carrying the payload. Queries are sent with GET when
+// enabled through UseGET; every other operation is sent as the body of a POST request, JSON
+// unless UseGraphQLContentType is set.
+func (request Request) newHTTPRequest(ctx context.Context, c content) (*http.Request, error) {
+	if request.usesGET(c) {
+		return request.newGETRequest(ctx, c)
+	}
+	if request.usesGraphQLBody(c) {
+		return request.newGraphQLRequest(ctx, c)
+	}
+
+	reqBuf := getBuffer()
+	defer putBuffer(reqBuf)
+	contentType, err := encodeContent(reqBuf, c, request.resolveCodec())
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+	compressed, err := request.compressBody(reqBuf)
+	if err != nil {
+		return nil, fmt.Errorf("compressing request: %w", err)
+	}
+
+	// The pooled buffer is released on return while the transport may still read the body,
+	// so the request is sent with an exact-size copy of the payload.
+	payload := bytes.NewReader(bytes.Clone(reqBuf.Bytes()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, request.Endpoint, payload)
+	if err != nil {
+		return nil, &ErrTransport{Op: "creating request", Err: err}
+	}
+	req.Header = request.header()
+	req.Header.Set("Content-Type", contentType)
+	if compressed {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if contentType != "application/json" && req.Header.Get("Apollo-Require-Preflight") == "" {
+		// Multipart bodies are "simple" requests that CSRF-protected servers reject
+		// unless a non-simple header is present.
+		req.Header.Set("Apollo-Require-Preflight", "true")
+	}
+	return req, nil
+}
+
+// encodeContent writes the payload to buf and returns the matching content type. The payload
+// is encoded as JSON unless its variables contain uploads, in which case it is encoded as a
+// multipart/form-data body following the GraphQL multipart request specification.
+func encodeContent(buf *bytes.Buffer, c content, codec Codec) (string, error) {
+	variables, uploads := extractUploads(c.Variables)
+	if len(uploads) > 0 {
+		c.Variables = variables
+		return encodeMultipart(buf, c, uploads, codec)
+	}
+
+	encoded, err := c.encoded()
+	if err != nil {
+		return "", err
+	}
+	err = codec.Encode(buf, encoded)
+	if err != nil {
+		return "", err
+	}
+	return "application/json", nil
 }
 
 // Do sends an HTTP POST request to the specified endpoint with the query/mutation from the Request.
@@ -115,44 +409,124 @@ type content struct {
 // If there is an error encoding the request payload, creating the request, sending the request,
 // or reading the response, it returns an error with the corresponding error message.
 // The response is always closed before returning.
-func (request Request) Do() mo.Result[gjson.Result] {
+// The optional headers override, for this call only, the headers of the request and of its
+// Client; an empty value removes a header. See AddHeader for request-level headers.
+func (request Request) Do(headers ...map[string]string) mo.Result[gjson.Result] {
+	return request.DoCtx(context.Background(), headers...)
+}
+
+// DoCtx behaves like Do but binds the outgoing HTTP request to the provided context.
+// Cancelling the context or exceeding its deadline aborts the in-flight request, in which
+// case the returned error wraps the context's error.
+func (request Request) DoCtx(ctx context.Context, headers ...map[string]string) mo.Result[gjson.Result] {
+	response, err := request.withCallHeaders(headers).do(ctx)
+	if err != nil {
+		return mo.Err[gjson.Result](err)
+	}
+	return mo.Ok[gjson.Result](response.Raw)
+}
+
+// DoResponse sends the request like DoCtx and returns the parsed Response, giving typed access
+// to the "data" member, to the GraphQL errors reported by the server and to the HTTP status
+// code, headers and raw body of the exchange.
+func (request Request) DoResponse(ctx context.Context) mo.Result[Response] {
+	return mo.TupleToResult(request.do(ctx))
+}
+
+// Execute sends the request like DoCtx but returns the parsed body and a plain Go error,
+// for callers that prefer the standard (value, error) convention over mo.Result.
+// Returned errors wrap their cause and can be inspected with errors.Is and errors.As.
+// Unlike DoCtx, a non-2xx HTTP status fails with an ErrHTTPStatus, see IgnoreHTTPStatus.
+func (request Request) Execute(ctx context.Context) (gjson.Result, error) {
+	response, err := request.failingOnHTTPStatus().do(ctx)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	return response.Raw, nil
+}
+
+// ExecuteResponse is the (value, error) counterpart of DoResponse. Like Execute, it fails
+// with an ErrHTTPStatus when the endpoint answers with a non-2xx HTTP status; the parsed
+// response is returned along with the error.
+func (request Request) ExecuteResponse(ctx context.Context) (Response, error) {
+	return request.failingOnHTTPStatus().do(ctx)
+}
+
+// do runs the request through the parent client's middleware chain and returns the parsed
+// response. It is shared by every execution method. The registered fragments referenced by
+// the document are appended to it first. When FailOnGraphQLErrors is set, GraphQL errors in
+// the response are returned as a Go error.
+func (request Request) do(ctx context.Context) (Response, error) {
 	if request.Request == "" {
-		return mo.Errf[gjson.Result]("no query/mutation provided")
+		return Response{}, errors.New("no query/mutation provided")
+	}
+	request.Request = request.client.withFragments(request.Request)
+	ctx, end, err := request.client.begin(ctx)
+	if err != nil {
+		return Response{}, err
 	}
+	defer end()
 
-	c := content{
-		Query:     request.Request,
-		Variables: request.Variables,
+	parent := ctx
+	timeout := request.resolveTimeout()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	var reqBuf bytes.Buffer
-	err := json.NewEncoder(&reqBuf).Encode(c)
+	response, err := request.client.handler()(ctx, request)
+	response.codec = request.resolveCodec()
 	if err != nil {
-		return mo.Errf[gjson.Result]("encoding request: %w", err)
+		if timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) && parent.Err() == nil {
+			return response, &ErrTimeout{Timeout: timeout, Err: err}
+		}
+		return response, err
 	}
+	if request.failOnErrors {
+		return response, response.Err()
+	}
+	return response, nil
+}
 
-	req, err := http.NewRequest(http.MethodPost, request.Endpoint, &reqBuf)
+// send encodes the payload, posts it to the request's endpoint and parses the response.
+func (request Request) send(ctx context.Context, c content) (Response, error) {
+	req, err := request.newHTTPRequest(ctx, c)
+	if err != nil {
+		return Response{}, err
+	}
+	err = request.authorize(ctx, req.Header)
 	if err != nil {
-		return mo.Errf[gjson.Result]("creating request: %w", err)
+		return Response{}, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	for key, value := range request.Headers {
-		req.Header.Set(key, value)
+	acceptEncoding(req.Header)
+	request.conditional(req)
+	err = request.sign(ctx, req)
+	if err != nil {
+		return Response{}, err
 	}
+	req, trace := request.traceTimings(req)
 
-	res, err := http.DefaultClient.Do(req)
+	res, err := request.resolveHTTPClient().Do(req)
 	if err != nil {
-		return mo.Errf[gjson.Result]("sending request: %w", err)
+		return Response{}, &ErrTransport{Op: "sending request", Err: err}
 	}
 	defer func(Body io.ReadCloser) {
 		_ = Body.Close()
 	}(res.Body)
 
-	var resBuf bytes.Buffer
-	_, err = resBuf.ReadFrom(res.Body)
+	body, err := readBody(res, request.resolveMaxResponseSize())
 	if err != nil {
-		return mo.Errf[gjson.Result]("reading response: %w", err)
+		return Response{}, err
 	}
+	res, body = request.revalidated(req, res, body)
 
-	return mo.Ok[gjson.Result](gjson.ParseBytes(resBuf.Bytes()))
+	response, err := parseResponse(body)
+	response.StatusCode = res.StatusCode
+	response.Header = res.Header
+	response.Timings = trace.result()
+	if statusErr := request.checkHTTPStatus(res, body); statusErr != nil {
+		return response, statusErr
+	}
+	return response, err
 }
@@ -0,0 +1,301 @@
+// Package ggqlapollo reports the usage of GraphQL operations sent by ggql clients to Apollo
+// GraphOS (Apollo Studio).
+//
+// ClientAwareness identifies the client to Apollo Router and Apollo Server with the
+// apollographql-client-name and apollographql-client-version headers, so that the usage
+// reported by the graph is broken down by client. A Reporter additionally aggregates
+// operation statistics (request counts, requests with errors and latency histograms) on the
+// client side and sends them to the usage reporting endpoint of GraphOS, for graphs whose
+// servers don't report usage themselves:
+//
+//	reporter, err := ggqlapollo.NewReporter(ggqlapollo.Options{
+//		APIKey:        os.Getenv("APOLLO_KEY"),
+//		GraphRef:      "my-graph@production",
+//		ClientName:    "inventory-sync",
+//		ClientVersion: "1.4.0",
+//	})
+//	client := ggql.NewClient(endpoint).Use(reporter.Middleware())
+//	defer reporter.Close(context.Background())
+package ggqlapollo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of Options.
+const (
+	DefaultEndpoint = "https://usage-reporting.api.apollographql.com/api/ingress/traces"
+	DefaultInterval = 20 * time.Second
+)
+
+// Headers identifying the client to Apollo Router and Apollo Server.
+const (
+	HeaderClientName    = "apollographql-client-name"
+	HeaderClientVersion = "apollographql-client-version"
+)
+
+// parseFailureKey is the statistics key reported for documents that can't be parsed.
+const parseFailureKey = "## GraphQLParseFailure\n"
+
+// ClientAwareness returns a ggql.Middleware setting the apollographql-client-name and
+// apollographql-client-version headers on every request that doesn't set them, so that
+// Apollo Router and Apollo Server attribute the operations to the client in the usage they
+// report. An empty version omits its header.
+func ClientAwareness(name, version string) ggql.Middleware {
+	return func(next ggql.Handler) ggql.Handler {
+		return func(ctx context.Context, request ggql.Request) (ggql.Response, error) {
+			if name != "" && request.Headers[HeaderClientName] == "" {
+				request = request.AddHeader(HeaderClientName, name)
+			}
+			if version != "" && request.Headers[HeaderClientVersion] == "" {
+				request = request.AddHeader(HeaderClientVersion, version)
+			}
+			return next(ctx, request)
+		}
+	}
+}
+
+// Options configures a Reporter. APIKey, a graph API key, and GraphRef, the graph and
+// variant receiving the reports such as "my-graph@production", are required.
+type Options struct {
+	APIKey   string
+	GraphRef string
+
+	// ClientName and ClientVersion identify the client in the reports and in the client
+	// awareness headers of the requests, see ClientAwareness.
+	ClientName    string
+	ClientVersion string
+
+	// Endpoint is the usage reporting endpoint, DefaultEndpoint when empty. Interval is the
+	// period between two reports, DefaultInterval when zero.
+	Endpoint string
+	Interval time.Duration
+
+	// HTTPClient sends the reports, http.DefaultClient when nil.
+	HTTPClient *http.Client
+
+	// OnError is called with the errors of the reports sent periodically. The statistics of
+	// a failed report are dropped.
+	OnError func(error)
+}
+
+// Reporter aggregates the statistics of the operations executed through its middleware and
+// reports them to Apollo GraphOS periodically. Operations are keyed by name and normalized
+// document, their "signature" (see ggql.NormalizeQuery). A Reporter is safe for concurrent
+// use.
+type Reporter struct {
+	options  Options
+	hostname string
+
+	mu    sync.Mutex
+	stats map[string]*operationStats
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+}
+
+// operationStats are the statistics of an operation since the last report.
+type operationStats struct {
+	requests   uint64
+	withErrors uint64
+	latencies  [histogramBuckets]int64
+}
+
+// NewReporter returns a Reporter sending a report every Interval, until closed.
+func NewReporter(options Options) (*Reporter, error) {
+	if options.APIKey == "" || options.GraphRef == "" {
+		return nil, errors.New("ggqlapollo: APIKey and GraphRef are required")
+	}
+	if options.Endpoint == "" {
+		options.Endpoint = DefaultEndpoint
+	}
+	if options.Interval <= 0 {
+		options.Interval = DefaultInterval
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	hostname, _ := os.Hostname()
+	reporter := &Reporter{
+		options:  options,
+		hostname: hostname,
+		stats:    make(map[string]*operationStats),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go reporter.run()
+	return reporter, nil
+}
+
+// Middleware returns a ggql.Middleware recording the statistics of every request and setting
+// its client awareness headers. Requests failing with an error or answered with GraphQL
+// errors count as requests with errors.
+func (reporter *Reporter) Middleware() ggql.Middleware {
+	awareness := ClientAwareness(reporter.options.ClientName, reporter.options.ClientVersion)
+	return func(next ggql.Handler) ggql.Handler {
+		next = awareness(next)
+		return func(ctx context.Context, request ggql.Request) (ggql.Response, error) {
+			start := time.Now()
+			response, err := next(ctx, request)
+			reporter.record(statsKey(request), time.Since(start), err != nil || response.HasErrors())
+			return response, err
+		}
+	}
+}
+
+// record adds a request to the statistics of the operation.
+func (reporter *Reporter) record(key string, duration time.Duration, failed bool) {
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	stats := reporter.stats[key]
+	if stats == nil {
+		stats = &operationStats{}
+		reporter.stats[key] = stats
+	}
+	stats.requests++
+	if failed {
+		stats.withErrors++
+	}
+	stats.latencies[histogramBucket(duration)]++
+}
+
+// Flush sends the statistics aggregated since the last report, if any, and resets them.
+func (reporter *Reporter) Flush(ctx context.Context) error {
+	reporter.mu.Lock()
+	stats := reporter.stats
+	reporter.stats = make(map[string]*operationStats)
+	reporter.mu.Unlock()
+	if len(stats) == 0 {
+		return nil
+	}
+	return reporter.send(ctx, reporter.encode(stats, time.Now()))
+}
+
+// Close stops the periodic reports and sends the pending statistics.
+func (reporter *Reporter) Close(ctx context.Context) error {
+	reporter.once.Do(func() {
+		close(reporter.stop)
+	})
+	<-reporter.done
+	return reporter.Flush(ctx)
+}
+
+// run sends a report every interval until the reporter is closed.
+func (reporter *Reporter) run() {
+	defer close(reporter.done)
+	ticker := time.NewTicker(reporter.options.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), reporter.options.Interval)
+			err := reporter.Flush(ctx)
+			cancel()
+			if err != nil && reporter.options.OnError != nil {
+				reporter.options.OnError(err)
+			}
+		case <-reporter.stop:
+			return
+		}
+	}
+}
+
+// encode returns the Report message holding the statistics.
+func (reporter *Reporter) encode(stats map[string]*operationStats, end time.Time) []byte {
+	header := message(nil).
+		string(fieldHeaderHostname, reporter.hostname).
+		string(fieldHeaderAgentVersion, "ggql").
+		string(fieldHeaderRuntimeVersion, runtime.Version()).
+		string(fieldHeaderUname, runtime.GOOS+", "+runtime.GOARCH).
+		string(fieldHeaderGraphRef, reporter.options.GraphRef)
+	statsContext := message(nil).
+		string(fieldStatsContextClientName, reporter.options.ClientName).
+		string(fieldStatsContextClientVersion, reporter.options.ClientVersion)
+
+	report := message(nil).message(fieldReportHeader, header)
+	var operations uint64
+	for key, operation := range stats {
+		latency := message(nil).
+			uint(fieldLatencyRequestCount, operation.requests).
+			uint(fieldLatencyRequestsWithError, operation.withErrors).
+			packedSint(fieldLatencyCount, encodeHistogram(operation.latencies[:]))
+		contextualized := message(nil).
+			message(fieldContextualizedStatsContext, statsContext).
+			message(fieldContextualizedStatsLatency, latency)
+		tracesAndStats := message(nil).message(fieldTracesAndStatsStats, contextualized)
+		entry := message(nil).string(fieldMapKey, key).message(fieldMapValue, tracesAndStats)
+		report = report.message(fieldReportTracesPerQuery, entry)
+		operations += operation.requests
+	}
+	return report.
+		message(fieldReportEndTime, timestamp(end)).
+		uint(fieldReportOperationCount, operations)
+}
+
+// send posts the gzipped report to the usage reporting endpoint.
+func (reporter *Reporter) send(ctx context.Context, report []byte) error {
+	var body bytes.Buffer
+	writer := gzip.NewWriter(&body)
+	_, err := writer.Write(report)
+	err = errors.Join(err, writer.Close())
+	if err != nil {
+		return fmt.Errorf("ggqlapollo: compressing report: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, reporter.options.Endpoint, &body)
+	if err != nil {
+		return fmt.Errorf("ggqlapollo: creating report request: %w", err)
+	}
+	req.Header.Set("X-Api-Key", reporter.options.APIKey)
+	req.Header.Set("Content-Type", "application/protobuf")
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "ggql")
+	res, err := reporter.options.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("ggqlapollo: sending report: %w", err)
+	}
+	defer res.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("ggqlapollo: report rejected with HTTP status %d: %s", res.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// operationPattern matches the keyword and optional name starting an operation definition.
+var operationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)\b\s*([_A-Za-z][_0-9A-Za-z]*)?`)
+
+// statsKey returns the key of the statistics of the request's operation: its name, "-" for
+// anonymous operations, followed by its signature.
+func statsKey(request ggql.Request) string {
+	signature, err := ggql.NormalizeQuery(request.Request, true)
+	if err != nil {
+		return parseFailureKey
+	}
+	name := request.Name()
+	if name == "" {
+		match := operationPattern.FindStringSubmatch(request.Request)
+		if match != nil {
+			name = match[2]
+		}
+	}
+	if name == "" {
+		name = "-"
+	}
+	return "# " + name + "\n" + signature
+}
@@ -0,0 +1,137 @@
+package ggqlapollo
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// Field numbers of the messages of Apollo's usage reporting protocol (reports.proto) that
+// are sent by the Reporter.
+const (
+	fieldReportHeader         = 1
+	fieldReportEndTime        = 2
+	fieldReportTracesPerQuery = 5
+	fieldReportOperationCount = 6
+
+	fieldHeaderHostname       = 5
+	fieldHeaderAgentVersion   = 6
+	fieldHeaderRuntimeVersion = 8
+	fieldHeaderUname          = 9
+	fieldHeaderGraphRef       = 12
+
+	fieldTimestampSeconds = 1
+	fieldTimestampNanos   = 2
+
+	fieldMapKey   = 1
+	fieldMapValue = 2
+
+	fieldTracesAndStatsStats = 2
+
+	fieldContextualizedStatsContext = 1
+	fieldContextualizedStatsLatency = 2
+
+	fieldStatsContextClientName    = 2
+	fieldStatsContextClientVersion = 3
+
+	fieldLatencyRequestCount      = 2
+	fieldLatencyRequestsWithError = 8
+	fieldLatencyCount             = 13
+)
+
+// Protocol buffers wire types.
+const (
+	wireVarint = 0
+	wireBytes  = 2
+)
+
+// histogramBuckets is the number of buckets of the duration histograms of Apollo's usage
+// reporting protocol, whose bucket i counts the durations up to 1.1^i microseconds.
+const histogramBuckets = 384
+
+// histogramBucket returns the bucket of the duration histograms counting duration.
+func histogramBucket(duration time.Duration) int {
+	micros := float64(duration) / float64(time.Microsecond)
+	if micros <= 1 {
+		return 0
+	}
+	bucket := int(math.Ceil(math.Log(micros) / math.Log(1.1)))
+	return min(bucket, histogramBuckets-1)
+}
+
+// encodeHistogram returns the encoding of the histogram counts expected by the protocol:
+// runs of several empty buckets are replaced by their negated length, and trailing empty
+// buckets are dropped.
+func encodeHistogram(counts []int64) []int64 {
+	var encoded []int64
+	zeros := int64(0)
+	for _, count := range counts {
+		if count == 0 {
+			zeros++
+			continue
+		}
+		switch {
+		case zeros == 1:
+			encoded = append(encoded, 0)
+		case zeros > 1:
+			encoded = append(encoded, -zeros)
+		}
+		zeros = 0
+		encoded = append(encoded, count)
+	}
+	return encoded
+}
+
+// message is a protocol buffers message being encoded.
+type message []byte
+
+// tag appends the tag of a field.
+func (m message) tag(field, wireType int) message {
+	return binary.AppendUvarint(m, uint64(field)<<3|uint64(wireType))
+}
+
+// uint appends a varint field, omitted when zero.
+func (m message) uint(field int, value uint64) message {
+	if value == 0 {
+		return m
+	}
+	return binary.AppendUvarint(m.tag(field, wireVarint), value)
+}
+
+// bytes appends a length-delimited field.
+func (m message) bytes(field int, value []byte) message {
+	m = binary.AppendUvarint(m.tag(field, wireBytes), uint64(len(value)))
+	return append(m, value...)
+}
+
+// string appends a string field, omitted when empty.
+func (m message) string(field int, value string) message {
+	if value == "" {
+		return m
+	}
+	return m.bytes(field, []byte(value))
+}
+
+// message appends an embedded message field.
+func (m message) message(field int, value message) message {
+	return m.bytes(field, value)
+}
+
+// packedSint appends a packed repeated sint64 field, omitted when empty.
+func (m message) packedSint(field int, values []int64) message {
+	if len(values) == 0 {
+		return m
+	}
+	var packed []byte
+	for _, value := range values {
+		packed = binary.AppendUvarint(packed, uint64(value<<1)^uint64(value>>63))
+	}
+	return m.bytes(field, packed)
+}
+
+// timestamp returns the google.protobuf.Timestamp message of t.
+func timestamp(t time.Time) message {
+	return message(nil).
+		uint(fieldTimestampSeconds, uint64(t.Unix())).
+		uint(fieldTimestampNanos, uint64(t.Nanosecond()))
+}
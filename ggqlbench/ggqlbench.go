@@ -0,0 +1,213 @@
+// Package ggqlbench provides a load-testing harness for GraphQL endpoints built on ggql.
+//
+// Run fires a request template at a configurable rate and concurrency and collects latency
+// percentiles, a breakdown of the failures and the rate of responses carrying GraphQL errors,
+// for capacity testing of GraphQL servers and gateways:
+//
+//	request := ggql.NewClient(endpoint).NewRequest().Query(`{ products(first: 20) { id } }`)
+//	result := ggqlbench.Run(ctx, request, ggqlbench.Config{Rate: 200, Concurrency: 32, Duration: time.Minute})
+//	fmt.Println(result)
+package ggqlbench
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"golang.org/x/time/rate"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Config configures a run. The run stops once Requests requests were sent or Duration
+// elapsed, whichever comes first, or when its context is done; without them, the run lasts
+// until the context is done. Rate caps the number of requests started per second,
+// unlimited when zero, and Concurrency is the number of requests in flight at most, 1 when
+// zero. Vary, when set, derives the request sent at each iteration from the template, e.g.
+// to vary its variables; iterations are numbered from 0.
+type Config struct {
+	Rate        float64
+	Concurrency int
+	Duration    time.Duration
+	Requests    int
+	Vary        func(iteration int, template ggql.Request) ggql.Request
+}
+
+// Result holds the statistics of a run. Requests counts the requests sent, among which
+// Failed ones returned an error, and GraphQLErrors ones succeeded with a response carrying
+// GraphQL errors. Errors breaks the failures down by ggql.ErrorClass, and StatusCodes counts
+// the HTTP statuses of the responses received. Elapsed is the duration of the run.
+type Result struct {
+	Requests      int
+	Failed        int
+	GraphQLErrors int
+	Errors        map[string]int
+	StatusCodes   map[int]int
+	Elapsed       time.Duration
+
+	// latencies holds the latencies of every request, sorted.
+	latencies []time.Duration
+}
+
+// Run sends the request template according to config and returns the statistics of the
+// run. Requests are executed with ggql.Request.ExecuteResponse, so that non-2xx statuses
+// count as failures. Requests interrupted by the end of the run are not counted.
+func Run(ctx context.Context, template ggql.Request, config Config) Result {
+	concurrency := max(config.Concurrency, 1)
+	if config.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Duration)
+		defer cancel()
+	}
+	var limiter *rate.Limiter
+	if config.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(config.Rate), 1)
+	}
+
+	iterations := make(chan int)
+	go func() {
+		defer close(iterations)
+		for i := 0; config.Requests <= 0 || i < config.Requests; i++ {
+			if limiter != nil && limiter.Wait(ctx) != nil {
+				return
+			}
+			select {
+			case iterations <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	result := Result{Errors: make(map[string]int), StatusCodes: make(map[int]int)}
+	var wg sync.WaitGroup
+	start := time.Now()
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range iterations {
+				request := template
+				if config.Vary != nil {
+					request = config.Vary(i, template)
+				}
+				sent := time.Now()
+				response, err := request.ExecuteResponse(ctx)
+				latency := time.Since(sent)
+				if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+					return
+				}
+
+				mu.Lock()
+				result.record(response, err, latency)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	result.Elapsed = time.Since(start)
+	sort.Slice(result.latencies, func(i, j int) bool {
+		return result.latencies[i] < result.latencies[j]
+	})
+	return result
+}
+
+// record adds the outcome of a request to the result.
+func (result *Result) record(response ggql.Response, err error, latency time.Duration) {
+	result.Requests++
+	result.latencies = append(result.latencies, latency)
+	if response.StatusCode != 0 {
+		result.StatusCodes[response.StatusCode]++
+	}
+	switch {
+	case err != nil:
+		result.Failed++
+		result.Errors[ggql.ErrorClass(err)]++
+	case response.HasErrors():
+		result.GraphQLErrors++
+	}
+}
+
+// Throughput returns the number of requests completed per second.
+func (result Result) Throughput() float64 {
+	if result.Elapsed <= 0 {
+		return 0
+	}
+	return float64(result.Requests) / result.Elapsed.Seconds()
+}
+
+// ErrorRate returns the fraction of the requests that failed, between 0 and 1.
+func (result Result) ErrorRate() float64 {
+	return ratio(result.Failed, result.Requests)
+}
+
+// GraphQLErrorRate returns the fraction of the requests whose response carried GraphQL
+// errors, between 0 and 1.
+func (result Result) GraphQLErrorRate() float64 {
+	return ratio(result.GraphQLErrors, result.Requests)
+}
+
+// Percentile returns the latency under which the fraction p of the requests completed, such
+// as 0.99 for the 99th percentile, using the nearest-rank method. It returns 0 when no
+// request completed.
+func (result Result) Percentile(p float64) time.Duration {
+	if len(result.latencies) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(result.latencies)))) - 1
+	rank = min(max(rank, 0), len(result.latencies)-1)
+	return result.latencies[rank]
+}
+
+// Mean returns the mean latency of the requests, or 0 when no request completed.
+func (result Result) Mean() time.Duration {
+	if len(result.latencies) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, latency := range result.latencies {
+		total += latency
+	}
+	return total / time.Duration(len(result.latencies))
+}
+
+// String returns a human-readable report of the result.
+func (result Result) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "requests:       %d in %s (%.1f/s)\n", result.Requests, result.Elapsed.Round(time.Millisecond), result.Throughput())
+	fmt.Fprintf(&builder, "failed:         %d (%.2f%%)\n", result.Failed, 100*result.ErrorRate())
+	fmt.Fprintf(&builder, "graphql errors: %d (%.2f%%)\n", result.GraphQLErrors, 100*result.GraphQLErrorRate())
+	fmt.Fprintf(&builder, "latency:        mean %s, p50 %s, p90 %s, p95 %s, p99 %s, max %s\n",
+		result.Mean(), result.Percentile(0.5), result.Percentile(0.9), result.Percentile(0.95), result.Percentile(0.99), result.Percentile(1))
+	for _, class := range sortedKeys(result.Errors) {
+		fmt.Fprintf(&builder, "%-16s%d\n", "error "+class+":", result.Errors[class])
+	}
+	for _, status := range sortedKeys(result.StatusCodes) {
+		fmt.Fprintf(&builder, "%-16s%d\n", fmt.Sprintf("status %d:", status), result.StatusCodes[status])
+	}
+	return builder.String()
+}
+
+// ratio returns n / total, or 0 when total is 0.
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// sortedKeys returns the keys of m in ascending order.
+func sortedKeys[K int | string, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i] < keys[j]
+	})
+	return keys
+}
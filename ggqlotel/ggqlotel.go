@@ -0,0 +1,118 @@
+// Package ggqlotel provides OpenTelemetry tracing for ggql clients.
+//
+// The instrumentation is opt-in: install the middleware returned by Middleware on a
+// ggql.Client to create a span per executed request and propagate the trace context
+// to the GraphQL endpoint.
+package ggqlotel
+
+import (
+	"context"
+	"github.com/lance-free/ggql"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"regexp"
+)
+
+// instrumentationName identifies the tracer created by this package.
+const instrumentationName = "github.com/lance-free/ggql/ggqlotel"
+
+// Attribute keys recorded on the spans created by the middleware.
+const (
+	AttributeOperationName = attribute.Key("graphql.operation.name")
+	AttributeOperationType = attribute.Key("graphql.operation.type")
+	AttributeFingerprint   = attribute.Key("graphql.operation.fingerprint")
+	AttributeDocument      = attribute.Key("graphql.document")
+	AttributeEndpoint      = attribute.Key("url.full")
+	AttributeResponseSize  = attribute.Key("graphql.response.size")
+	AttributeErrorCount    = attribute.Key("graphql.errors.count")
+)
+
+// Options configures the tracing middleware. Zero values fall back to the global
+// TracerProvider and TextMapPropagator registered with the otel package.
+type Options struct {
+	TracerProvider trace.TracerProvider
+	Propagator     propagation.TextMapPropagator
+
+	// IncludeDocument records the full query document on the span. It is disabled by
+	// default since documents can be large.
+	IncludeDocument bool
+}
+
+// Middleware returns a ggql.Middleware that creates a client span for every request,
+// records the operation name, endpoint, response size and GraphQL error count as attributes,
+// and injects the trace context (W3C traceparent by default) into the outgoing headers.
+func Middleware(options Options) ggql.Middleware {
+	provider := options.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	propagator := options.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	tracer := provider.Tracer(instrumentationName)
+
+	return func(next ggql.Handler) ggql.Handler {
+		return func(ctx context.Context, request ggql.Request) (ggql.Response, error) {
+			kind, name := operation(request.Request)
+			if request.Name() != "" {
+				name = request.Name()
+			}
+			spanName := "graphql." + kind
+			if name != "" {
+				spanName = kind + " " + name
+			}
+
+			attributes := []attribute.KeyValue{
+				AttributeOperationType.String(kind),
+				AttributeFingerprint.String(request.Fingerprint()),
+				AttributeEndpoint.String(request.Endpoint),
+			}
+			if name != "" {
+				attributes = append(attributes, AttributeOperationName.String(name))
+			}
+			if options.IncludeDocument {
+				attributes = append(attributes, AttributeDocument.String(request.Request))
+			}
+
+			ctx, span := tracer.Start(ctx, spanName,
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(attributes...))
+			defer span.End()
+
+			carrier := make(propagation.MapCarrier)
+			propagator.Inject(ctx, carrier)
+			request = request.AddHeaders(carrier)
+
+			response, err := next(ctx, request)
+			span.SetAttributes(
+				AttributeResponseSize.Int(len(response.Raw.Raw)),
+				AttributeErrorCount.Int(len(response.Errors)),
+			)
+			switch {
+			case err != nil:
+				span.RecordError(err)
+				span.SetStatus(codes.Error, err.Error())
+			case response.HasErrors():
+				span.SetStatus(codes.Error, response.Errors[0].Message)
+			}
+			return response, err
+		}
+	}
+}
+
+// operationPattern matches the keyword and optional name starting an operation definition.
+var operationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)\b\s*([_A-Za-z][_0-9A-Za-z]*)?`)
+
+// operation returns the type and name of the first operation of the document. Documents
+// using the query shorthand are reported as anonymous queries.
+func operation(document string) (kind, name string) {
+	match := operationPattern.FindStringSubmatch(document)
+	if match == nil {
+		return "query", ""
+	}
+	return match[1], match[2]
+}
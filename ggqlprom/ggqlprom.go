@@ -0,0 +1,170 @@
+// Package ggqlprom provides Prometheus metrics for ggql clients.
+//
+// The instrumentation is opt-in: create the collectors with NewMetrics and install the
+// middleware returned by Metrics.Middleware on a ggql.Client to record every executed request.
+package ggqlprom
+
+import (
+	"context"
+	"github.com/lance-free/ggql"
+	"github.com/prometheus/client_golang/prometheus"
+	"regexp"
+	"time"
+)
+
+// Label names of the collected metrics.
+const (
+	LabelEndpoint    = "endpoint"
+	LabelOperation   = "operation"
+	LabelFingerprint = "fingerprint"
+	LabelClass       = "class"
+)
+
+// Options configures the collectors created by NewMetrics.
+type Options struct {
+	// Registerer receives the collectors. It defaults to prometheus.DefaultRegisterer.
+	Registerer prometheus.Registerer
+
+	// Namespace and Subsystem prefix the metric names. Namespace defaults to "ggql".
+	Namespace string
+	Subsystem string
+
+	// DurationBuckets and SizeBuckets set the buckets of the request duration (seconds) and
+	// response size (bytes) histograms. They default to prometheus.DefBuckets and to
+	// exponential buckets from 256 bytes to 4 MiB.
+	DurationBuckets []float64
+	SizeBuckets     []float64
+
+	// Fingerprint adds the fingerprint of the operations (see ggql.Fingerprint) as a label of
+	// every metric, to tell apart the operations sharing a name or sent anonymously. Every
+	// distinct operation shape creates new series, so it is disabled by default.
+	Fingerprint bool
+}
+
+// Metrics holds the collectors updated by the middleware:
+//   - requests_total, the number of executed requests;
+//   - errors_total, the number of failed requests by error class (see ggql.ErrorClass),
+//     responses carrying GraphQL errors being counted with the "graphql" class;
+//   - request_duration_seconds, the duration of the requests;
+//   - response_size_bytes, the size of the response bodies.
+//
+// Every metric is labeled by endpoint and operation name, and by operation fingerprint when
+// Options.Fingerprint is set.
+type Metrics struct {
+	fingerprint bool
+
+	requests *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+}
+
+// NewMetrics creates the collectors and registers them with the configured Registerer.
+// It returns an error if one of the collectors cannot be registered, e.g. when NewMetrics is
+// called twice with the same Registerer and naming options.
+func NewMetrics(options Options) (*Metrics, error) {
+	registerer := options.Registerer
+	if registerer == nil {
+		registerer = prometheus.DefaultRegisterer
+	}
+	namespace := options.Namespace
+	if namespace == "" {
+		namespace = "ggql"
+	}
+	durationBuckets := options.DurationBuckets
+	if durationBuckets == nil {
+		durationBuckets = prometheus.DefBuckets
+	}
+	sizeBuckets := options.SizeBuckets
+	if sizeBuckets == nil {
+		sizeBuckets = prometheus.ExponentialBuckets(256, 4, 8)
+	}
+
+	labels := []string{LabelEndpoint, LabelOperation}
+	if options.Fingerprint {
+		labels = append(labels, LabelFingerprint)
+	}
+	metrics := &Metrics{
+		fingerprint: options.Fingerprint,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: options.Subsystem,
+			Name:      "requests_total",
+			Help:      "Number of GraphQL requests executed.",
+		}, labels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: options.Subsystem,
+			Name:      "errors_total",
+			Help:      "Number of GraphQL requests that failed, by error class.",
+		}, append(labels, LabelClass)),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: options.Subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Duration of the GraphQL requests.",
+			Buckets:   durationBuckets,
+		}, labels),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: options.Subsystem,
+			Name:      "response_size_bytes",
+			Help:      "Size of the GraphQL response bodies.",
+			Buckets:   sizeBuckets,
+		}, labels),
+	}
+
+	for _, collector := range []prometheus.Collector{metrics.requests, metrics.errors, metrics.duration, metrics.size} {
+		err := registerer.Register(collector)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return metrics, nil
+}
+
+// Middleware returns a ggql.Middleware that records the metrics of every request.
+func (metrics *Metrics) Middleware() ggql.Middleware {
+	return func(next ggql.Handler) ggql.Handler {
+		return func(ctx context.Context, request ggql.Request) (ggql.Response, error) {
+			name := request.Name()
+			if name == "" {
+				name = operationName(request.Request)
+			}
+
+			values := []string{request.Endpoint, name}
+			if metrics.fingerprint {
+				values = append(values, request.Fingerprint())
+			}
+
+			start := time.Now()
+			response, err := next(ctx, request)
+
+			metrics.requests.WithLabelValues(values...).Inc()
+			metrics.duration.WithLabelValues(values...).Observe(time.Since(start).Seconds())
+			switch {
+			case err != nil:
+				metrics.errors.WithLabelValues(append(values, ggql.ErrorClass(err))...).Inc()
+			case response.HasErrors():
+				metrics.errors.WithLabelValues(append(values, ggql.ErrorClassGraphQL)...).Inc()
+			}
+			if err == nil {
+				metrics.size.WithLabelValues(values...).Observe(float64(len(response.Raw.Raw)))
+			}
+			return response, err
+		}
+	}
+}
+
+// operationPattern matches the keyword and optional name starting an operation definition.
+var operationPattern = regexp.MustCompile(`^\s*(query|mutation|subscription)\b\s*([_A-Za-z][_0-9A-Za-z]*)?`)
+
+// operationName returns the name of the first operation of the document, or an empty string
+// for anonymous operations.
+func operationName(document string) string {
+	match := operationPattern.FindStringSubmatch(document)
+	if match == nil {
+		return ""
+	}
+	return match[2]
+}
@@ -0,0 +1,240 @@
+package ggqltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"github.com/tidwall/gjson"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable that makes AssertGolden write the golden files
+// instead of comparing them, when set to a non-empty value:
+//
+//	GGQLTEST_UPDATE=1 go test ./...
+const UpdateGoldenEnv = "GGQLTEST_UPDATE"
+
+// maxDifferences is the number of differences reported by AssertGolden.
+const maxDifferences = 10
+
+// AssertOperation reports an error when the operation received by a Transport is not named
+// want, either by its operation name or by the first operation its document defines.
+func AssertOperation(t testing.TB, operation Operation, want string) {
+	t.Helper()
+	name := operation.OperationName
+	if name == "" {
+		name = operationName(operation.Query)
+	}
+	if name != want {
+		t.Errorf("ggqltest: operation is %q, want %q", name, want)
+	}
+}
+
+// AssertVariables reports an error when the variables of the operation received by a
+// Transport differ from want. Both are compared in their JSON form, so that numbers and
+// structs compare as sent: AssertVariables(t, op, map[string]any{"id": 42, "filter": f}).
+func AssertVariables(t testing.TB, operation Operation, want map[string]any) {
+	t.Helper()
+	got, err := normalize(operation.Variables)
+	if err != nil {
+		t.Fatalf("ggqltest: encoding variables: %v", err)
+	}
+	expected, err := normalize(want)
+	if err != nil {
+		t.Fatalf("ggqltest: encoding expected variables: %v", err)
+	}
+	differences := diff("variables", got, expected, nil)
+	if len(differences) > 0 {
+		t.Errorf("ggqltest: variables differ:\n%s", strings.Join(differences, "\n"))
+	}
+}
+
+// AssertGolden compares got, such as a gjson.Result, a ggql.Response, a JSON string or
+// []byte, or any value encodable as JSON, to the JSON golden file at path, and reports the
+// differing fields as an error. The fields at the ignore paths are skipped, to leave out
+// timestamps or generated identifiers; paths are dot-separated, with * matching any object
+// member or array element, such as "data.users.*.createdAt". The golden file is written
+// instead, indented, when the UpdateGoldenEnv environment variable is set.
+func AssertGolden(t testing.TB, got any, path string, ignore ...string) {
+	t.Helper()
+	actual, err := normalize(got)
+	if err != nil {
+		t.Fatalf("ggqltest: encoding value: %v", err)
+	}
+
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		content, err := json.MarshalIndent(actual, "", "  ")
+		if err != nil {
+			t.Fatalf("ggqltest: encoding golden file: %v", err)
+		}
+		err = os.MkdirAll(filepath.Dir(path), 0o755)
+		if err == nil {
+			err = os.WriteFile(path, append(content, '\n'), 0o644)
+		}
+		if err != nil {
+			t.Fatalf("ggqltest: writing golden file: %v", err)
+		}
+		return
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("ggqltest: golden file %s does not exist, run the test with %s=1 to create it", path, UpdateGoldenEnv)
+	}
+	if err != nil {
+		t.Fatalf("ggqltest: reading golden file: %v", err)
+	}
+	expected, err := normalize(content)
+	if err != nil {
+		t.Fatalf("ggqltest: decoding golden file %s: %v", path, err)
+	}
+	differences := diff("", actual, expected, ignore)
+	if len(differences) > 0 {
+		t.Errorf("ggqltest: value differs from golden file %s:\n%s", path, strings.Join(differences, "\n"))
+	}
+}
+
+// normalize returns the JSON value of v, decoded into maps, slices and json.Number values.
+// Strings and byte slices are decoded as JSON documents.
+func normalize(v any) (any, error) {
+	var data []byte
+	switch v := v.(type) {
+	case gjson.Result:
+		data = []byte(v.Raw)
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case json.RawMessage:
+		data = v
+	case ggql.Response:
+		data = []byte(v.Raw.Raw)
+	default:
+		var err error
+		data, err = json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var normalized any
+	err := decoder.Decode(&normalized)
+	return normalized, err
+}
+
+// diff returns the differences between got and want, found at path, at most maxDifferences.
+// The values at the ignore paths are skipped.
+func diff(path string, got, want any, ignore []string) []string {
+	var differences []string
+	var walk func(path string, got, want any)
+	walk = func(path string, got, want any) {
+		if len(differences) >= maxDifferences || ignored(path, ignore) {
+			return
+		}
+		switch want := want.(type) {
+		case map[string]any:
+			got, ok := got.(map[string]any)
+			if !ok {
+				break
+			}
+			keys := make([]string, 0, len(want)+len(got))
+			for key := range want {
+				keys = append(keys, key)
+			}
+			for key := range got {
+				if _, ok := want[key]; !ok {
+					keys = append(keys, key)
+				}
+			}
+			sort.Strings(keys)
+			for _, key := range keys {
+				member := join(path, key)
+				gotValue, inGot := got[key]
+				wantValue, inWant := want[key]
+				switch {
+				case ignored(member, ignore) || len(differences) >= maxDifferences:
+				case !inGot:
+					differences = append(differences, fmt.Sprintf("  %s: missing, want %s", member, encode(wantValue)))
+				case !inWant:
+					differences = append(differences, fmt.Sprintf("  %s: got %s, want nothing", member, encode(gotValue)))
+				default:
+					walk(member, gotValue, wantValue)
+				}
+			}
+			return
+		case []any:
+			got, ok := got.([]any)
+			if !ok || len(got) != len(want) {
+				break
+			}
+			for i := range want {
+				walk(join(path, strconv.Itoa(i)), got[i], want[i])
+			}
+			return
+		}
+		if !reflect.DeepEqual(got, want) {
+			location := path
+			if location == "" {
+				location = "(root)"
+			}
+			differences = append(differences, fmt.Sprintf("  %s: got %s, want %s", location, encode(got), encode(want)))
+		}
+	}
+	walk(path, got, want)
+	return differences
+}
+
+// ignored reports whether path matches one of the ignore paths.
+func ignored(path string, ignore []string) bool {
+	if path == "" {
+		return false
+	}
+	segments := strings.Split(path, ".")
+	for _, pattern := range ignore {
+		patterns := strings.Split(pattern, ".")
+		if len(patterns) != len(segments) {
+			continue
+		}
+		match := true
+		for i := range patterns {
+			if patterns[i] != "*" && patterns[i] != segments[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// join appends a segment to a dot-separated path.
+func join(path, segment string) string {
+	if path == "" {
+		return segment
+	}
+	return path + "." + segment
+}
+
+// encode returns the compact JSON encoding of a normalized value.
+func encode(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
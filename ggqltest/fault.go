@@ -0,0 +1,208 @@
+package ggqltest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// malformedBody is the response body of the faults injecting malformed JSON.
+const malformedBody = `{"data":{]}`
+
+// Fault describes a failure injected by a FaultTransport. A fault applies to the requests
+// carrying the operation named Operation, or to every request when it is empty, with the
+// given Probability, between 0 and 1; a zero Probability always applies.
+//
+// An applied fault first waits for Latency. It then fails the round trip with Err when set,
+// or answers with the HTTP status Status and the body Body without reaching the endpoint
+// when Status is set. Otherwise the request is sent, and the response body is replaced by
+// malformed JSON when Malformed is set, or cut in half when Truncate is set.
+type Fault struct {
+	Operation   string
+	Probability float64
+
+	Latency   time.Duration
+	Err       error
+	Status    int
+	Body      string
+	Malformed bool
+	Truncate  bool
+}
+
+// FaultTransport is an http.RoundTripper injecting faults into the round trips of another
+// one, such as latency, network errors, HTTP error statuses and corrupted bodies, to test
+// the retry and fallback paths of code built on ggql:
+//
+//	faults := ggqltest.NewFaultTransport(transport,
+//		ggqltest.Fault{Probability: 0.2, Status: http.StatusServiceUnavailable},
+//		ggqltest.Fault{Operation: "GetUser", Latency: 2 * time.Second},
+//	)
+//	client := faults.Client("https://api.example.com/graphql")
+//
+// Every applicable fault is drawn independently: latencies add up, and the first drawn
+// fault among those failing the request or altering its response wins. A FaultTransport is
+// safe for concurrent use.
+type FaultTransport struct {
+	next   http.RoundTripper
+	faults []Fault
+
+	mu     sync.Mutex
+	random *rand.Rand
+	counts map[int]int
+}
+
+// NewFaultTransport returns a FaultTransport injecting the faults into the round trips of
+// next, http.DefaultTransport when nil.
+func NewFaultTransport(next http.RoundTripper, faults ...Fault) *FaultTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &FaultTransport{
+		next:   next,
+		faults: faults,
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+		counts: make(map[int]int),
+	}
+}
+
+// Seed seeds the random draws of the probabilities, to make test runs reproducible. The
+// updated FaultTransport is returned.
+func (transport *FaultTransport) Seed(seed int64) *FaultTransport {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.random = rand.New(rand.NewSource(seed))
+	return transport
+}
+
+// Client returns a ggql.Client for the endpoint whose requests go through the transport.
+func (transport *FaultTransport) Client(endpoint string) *ggql.Client {
+	return ggql.NewClient(endpoint).WithHTTPClient(&http.Client{Transport: transport})
+}
+
+// Injected returns the number of times the fault at the given index of the faults passed
+// to NewFaultTransport was applied.
+func (transport *FaultTransport) Injected(index int) int {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return transport.counts[index]
+}
+
+// RoundTrip implements http.RoundTripper.
+func (transport *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, faults, err := transport.draw(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var outcome *Fault
+	for i := range faults {
+		if faults[i].Latency > 0 {
+			timer := time.NewTimer(faults[i].Latency)
+			select {
+			case <-timer.C:
+			case <-req.Context().Done():
+				timer.Stop()
+				return nil, req.Context().Err()
+			}
+		}
+		if outcome == nil && (faults[i].Err != nil || faults[i].Status != 0 || faults[i].Malformed || faults[i].Truncate) {
+			outcome = &faults[i]
+		}
+	}
+
+	switch {
+	case outcome == nil:
+		return transport.next.RoundTrip(req)
+	case outcome.Err != nil:
+		return nil, outcome.Err
+	case outcome.Status != 0:
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", outcome.Status, http.StatusText(outcome.Status)),
+			StatusCode:    outcome.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": []string{"application/json"}},
+			Body:          io.NopCloser(strings.NewReader(outcome.Body)),
+			ContentLength: int64(len(outcome.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	res, err := transport.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Header = res.Header.Clone()
+	if outcome.Malformed {
+		body = []byte(malformedBody)
+		res.Header.Del("Content-Encoding")
+	} else {
+		body = body[:len(body)/2]
+	}
+	res.Body = io.NopCloser(bytes.NewReader(body))
+	res.ContentLength = int64(len(body))
+	res.Header.Del("Content-Length")
+	return res, nil
+}
+
+// draw returns the faults applying to the request, in order, and counts them. When faults
+// depend on the operation, the request is decoded and returned as a copy whose body can
+// still be sent.
+func (transport *FaultTransport) draw(req *http.Request) (*http.Request, []Fault, error) {
+	name := ""
+	for _, fault := range transport.faults {
+		if fault.Operation == "" {
+			continue
+		}
+		var body []byte
+		if req.Body != nil {
+			var err error
+			body, err = io.ReadAll(req.Body)
+			_ = req.Body.Close()
+			if err != nil {
+				return nil, nil, fmt.Errorf("ggqltest: reading request: %w", err)
+			}
+			req = req.Clone(req.Context())
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		operation, err := decodeOperation(req)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ggqltest: decoding operation: %w", err)
+		}
+		if req.Body != nil {
+			req.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		name = operation.OperationName
+		if name == "" {
+			name = operationName(operation.Query)
+		}
+		break
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	var faults []Fault
+	for i, fault := range transport.faults {
+		if fault.Operation != "" && fault.Operation != name {
+			continue
+		}
+		if fault.Probability > 0 && transport.random.Float64() >= fault.Probability {
+			continue
+		}
+		transport.counts[i]++
+		faults = append(faults, fault)
+	}
+	return req, faults, nil
+}
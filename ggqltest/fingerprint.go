@@ -0,0 +1,75 @@
+package ggqltest
+
+import "strings"
+
+// fingerprint normalizes a GraphQL document by removing comments and collapsing whitespace
+// and commas, so that documents differing only in formatting share the same fingerprint.
+func fingerprint(document string) string {
+	var builder strings.Builder
+	pendingSpace := false
+	for i := 0; i < len(document); i++ {
+		char := document[i]
+		switch {
+		case char == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+			pendingSpace = true
+		case char == ' ' || char == '\t' || char == '\n' || char == '\r' || char == ',':
+			pendingSpace = true
+		case char == '"':
+			end := i + 1
+			for end < len(document) && document[end] != '"' {
+				if document[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if pendingSpace && builder.Len() > 0 && isName(lastByte(&builder)) {
+				builder.WriteByte(' ')
+			}
+			pendingSpace = false
+			if end >= len(document) {
+				builder.WriteString(document[i:])
+				return builder.String()
+			}
+			builder.WriteString(document[i : end+1])
+			i = end
+		default:
+			if pendingSpace && builder.Len() > 0 && isName(lastByte(&builder)) && isName(char) {
+				builder.WriteByte(' ')
+			}
+			pendingSpace = false
+			builder.WriteByte(char)
+		}
+	}
+	return builder.String()
+}
+
+// operationName returns the name of the first named operation defined by the document.
+func operationName(document string) string {
+	queryFingerprint := fingerprint(document)
+	for _, keyword := range []string{"query ", "mutation ", "subscription "} {
+		if !strings.HasPrefix(queryFingerprint, keyword) {
+			continue
+		}
+		rest := queryFingerprint[len(keyword):]
+		end := 0
+		for end < len(rest) && isName(rest[end]) {
+			end++
+		}
+		return rest[:end]
+	}
+	return ""
+}
+
+// isName reports whether char can be part of a GraphQL name.
+func isName(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9')
+}
+
+// lastByte returns the last byte written to the builder.
+func lastByte(builder *strings.Builder) byte {
+	s := builder.String()
+	return s[len(s)-1]
+}
@@ -0,0 +1,264 @@
+// Package ggqltest provides an in-memory transport for testing code built on ggql.
+//
+// A Transport answers GraphQL requests with canned JSON fixtures matched by operation name or
+// by query fingerprint, so unit tests never hit the network:
+//
+//	transport := ggqltest.NewTransport()
+//	transport.OnOperation("GetUser").Respond(`{"data":{"user":{"name":"Ada"}}}`)
+//	client := transport.Client("https://api.example.com/graphql")
+//
+// The assertion helpers check the operations received by the transport and compare
+// responses to golden files:
+//
+//	ggqltest.AssertOperation(t, transport.Operations()[0], "GetUser")
+//	ggqltest.AssertGolden(t, response, "testdata/user.json", "data.user.createdAt")
+//
+// A FaultTransport injects latency, errors and corrupted responses into the round trips of
+// another transport, to exercise retries and fallbacks.
+package ggqltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/lance-free/ggql"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Operation represents a GraphQL operation received by a Transport.
+type Operation struct {
+	Query         string
+	OperationName string
+	Variables     map[string]any
+	Extensions    map[string]any
+	Header        http.Header
+}
+
+// Transport is an http.RoundTripper answering GraphQL requests with canned fixtures.
+// Every received operation is recorded and can be inspected with Operations.
+// A Transport is safe for concurrent use.
+type Transport struct {
+	mu         sync.Mutex
+	mocks      []*Mock
+	operations []Operation
+}
+
+// Mock describes the response returned for the operations it matches.
+type Mock struct {
+	operationName string
+	fingerprint   string
+
+	status int
+	body   string
+	header http.Header
+	err    error
+	times  int
+	calls  int
+}
+
+// NewTransport initializes an empty Transport.
+func NewTransport() *Transport {
+	return &Transport{}
+}
+
+// Client returns a ggql.Client for the endpoint whose requests are answered by the transport.
+func (transport *Transport) Client(endpoint string) *ggql.Client {
+	return ggql.NewClient(endpoint).WithHTTPClient(&http.Client{Transport: transport})
+}
+
+// OnOperation registers a mock matching the operations sent with the given operation name,
+// or whose document defines an operation with that name.
+func (transport *Transport) OnOperation(name string) *Mock {
+	return transport.register(&Mock{operationName: name})
+}
+
+// OnQuery registers a mock matching the operations whose document has the same fingerprint
+// as query. Fingerprints ignore comments and insignificant whitespace and commas.
+func (transport *Transport) OnQuery(query string) *Mock {
+	return transport.register(&Mock{fingerprint: fingerprint(query)})
+}
+
+// register adds a mock answering with an empty data object by default.
+func (transport *Transport) register(mock *Mock) *Mock {
+	mock.status = http.StatusOK
+	mock.body = `{"data":{}}`
+	mock.header = make(http.Header)
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	transport.mocks = append(transport.mocks, mock)
+	return mock
+}
+
+// Respond sets the JSON body returned by the mock. The updated Mock is returned.
+func (mock *Mock) Respond(body string) *Mock {
+	mock.body = body
+	return mock
+}
+
+// RespondStatus sets the HTTP status code and body returned by the mock.
+// The updated Mock is returned.
+func (mock *Mock) RespondStatus(status int, body string) *Mock {
+	mock.status = status
+	mock.body = body
+	return mock
+}
+
+// RespondHeader adds a header to the response returned by the mock. The updated Mock is returned.
+func (mock *Mock) RespondHeader(key, value string) *Mock {
+	mock.header.Add(key, value)
+	return mock
+}
+
+// RespondError makes the mock fail the round trip with err, simulating a network failure.
+// The updated Mock is returned.
+func (mock *Mock) RespondError(err error) *Mock {
+	mock.err = err
+	return mock
+}
+
+// Times limits the number of operations the mock answers. Once exhausted, the next matching
+// mock is used. Zero, the default, means unlimited. The updated Mock is returned.
+func (mock *Mock) Times(n int) *Mock {
+	mock.times = n
+	return mock
+}
+
+// Calls returns the number of operations the mock has answered.
+func (mock *Mock) Calls() int {
+	return mock.calls
+}
+
+// Operations returns the operations received by the transport, in order.
+func (transport *Transport) Operations() []Operation {
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	return append([]Operation(nil), transport.operations...)
+}
+
+// RoundTrip implements http.RoundTripper. It decodes the GraphQL operation of the request,
+// records it, and answers with the first matching mock. An error is returned when no mock
+// matches the operation.
+func (transport *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	operation, err := decodeOperation(req)
+	if err != nil {
+		return nil, fmt.Errorf("ggqltest: decoding operation: %w", err)
+	}
+
+	transport.mu.Lock()
+	transport.operations = append(transport.operations, operation)
+	mock := transport.match(operation)
+	if mock != nil {
+		mock.calls++
+	}
+	transport.mu.Unlock()
+
+	if mock == nil {
+		name := operation.OperationName
+		if name == "" {
+			name = fingerprint(operation.Query)
+		}
+		return nil, fmt.Errorf("ggqltest: no mock for operation %q", name)
+	}
+	if mock.err != nil {
+		return nil, mock.err
+	}
+
+	header := mock.header.Clone()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", mock.status, http.StatusText(mock.status)),
+		StatusCode:    mock.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(mock.body)),
+		ContentLength: int64(len(mock.body)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the first mock matching the operation that is not exhausted.
+func (transport *Transport) match(operation Operation) *Mock {
+	name := operation.OperationName
+	if name == "" {
+		name = operationName(operation.Query)
+	}
+	queryFingerprint := fingerprint(operation.Query)
+
+	for _, mock := range transport.mocks {
+		if mock.times > 0 && mock.calls >= mock.times {
+			continue
+		}
+		if mock.operationName != "" && mock.operationName == name {
+			return mock
+		}
+		if mock.fingerprint != "" && mock.fingerprint == queryFingerprint {
+			return mock
+		}
+	}
+	return nil
+}
+
+// payload is the JSON shape of a GraphQL operation sent over HTTP.
+type payload struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+	Extensions    map[string]any `json:"extensions"`
+}
+
+// decodeOperation extracts the GraphQL operation from a GET, JSON or multipart request.
+func decodeOperation(req *http.Request) (Operation, error) {
+	var p payload
+	switch {
+	case req.Method == http.MethodGet:
+		params := req.URL.Query()
+		p.Query = params.Get("query")
+		p.OperationName = params.Get("operationName")
+		for name, target := range map[string]*map[string]any{"variables": &p.Variables, "extensions": &p.Extensions} {
+			if raw := params.Get(name); raw != "" {
+				if err := json.Unmarshal([]byte(raw), target); err != nil {
+					return Operation{}, err
+				}
+			}
+		}
+	case strings.HasPrefix(req.Header.Get("Content-Type"), "multipart/"):
+		_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		if err != nil {
+			return Operation{}, err
+		}
+		form, err := multipart.NewReader(req.Body, params["boundary"]).ReadForm(32 << 20)
+		if err != nil {
+			return Operation{}, err
+		}
+		if operations := form.Value["operations"]; len(operations) > 0 {
+			if err := json.Unmarshal([]byte(operations[0]), &p); err != nil {
+				return Operation{}, err
+			}
+		}
+	case req.Body != nil:
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return Operation{}, err
+		}
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&p); err != nil {
+			return Operation{}, err
+		}
+	}
+
+	return Operation{
+		Query:         p.Query,
+		OperationName: p.OperationName,
+		Variables:     p.Variables,
+		Extensions:    p.Extensions,
+		Header:        req.Header.Clone(),
+	}, nil
+}
@@ -3,11 +3,17 @@ module github.com/lance-free/ggql
 go 1.22
 
 require (
+	github.com/andybalholm/brotli v1.1.0
+	github.com/gorilla/websocket v1.5.3
 	github.com/samber/mo v1.12.0
 	github.com/tidwall/gjson v1.17.1
+	github.com/vektah/gqlparser/v2 v2.5.16
+	golang.org/x/sync v0.7.0
+	golang.org/x/time v0.5.0
 )
 
 require (
+	github.com/agnivade/levenshtein v1.1.1 // indirect
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.0 // indirect
 )
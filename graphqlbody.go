@@ -0,0 +1,46 @@
+package ggql
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// UseGraphQLContentType makes the request send its document as the raw body of a POST
+// request with the "application/graphql" content type, the variables, operation name and
+// extensions being encoded in the URL query string as with UseGET. Some minimal servers only
+// accept this format. Payloads without document, such as persisted queries sent by hash or
+// trusted documents sent by identifier, and requests carrying uploads are still sent as
+// JSON. UseGET takes precedence for queries. The modified Request is returned.
+func (request Request) UseGraphQLContentType() Request {
+	request.graphQLBody = true
+	return request
+}
+
+// usesGraphQLBody reports whether the payload should be sent as an application/graphql body.
+func (request Request) usesGraphQLBody(c content) bool {
+	if !request.graphQLBody || c.Query == "" {
+		return false
+	}
+	_, uploads := extractUploads(c.Variables)
+	return len(uploads) == 0
+}
+
+// newGraphQLRequest builds a POST request whose body is the document of the payload and
+// whose URL query string carries the other members.
+func (request Request) newGraphQLRequest(ctx context.Context, c content) (*http.Request, error) {
+	document := c.Query
+	c.Query = ""
+	endpoint, err := request.payloadURL(c)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(document))
+	if err != nil {
+		return nil, &ErrTransport{Op: "creating request", Err: err}
+	}
+	req.Header = request.header()
+	req.Header.Set("Content-Type", "application/graphql")
+	return req, nil
+}
@@ -0,0 +1,88 @@
+package ggql
+
+import (
+	"net/http"
+)
+
+// RequestHook is called with every HTTP request sent by a client, after its headers are
+// set, authentication included. It may modify the request; an error aborts it.
+type RequestHook func(req *http.Request) error
+
+// ResponseHook is called with every HTTP response received by a client, before its body is
+// read. A hook reading the body must replace it with an equivalent reader. An error fails
+// the request.
+type ResponseHook func(res *http.Response) error
+
+// OnRequest adds a hook called with the raw HTTP requests sent by the client, e.g. to attach
+// a CSRF token or implement a custom authentication scheme. Hooks are called in the order
+// they were added, for every HTTP request: queries, mutations, batches, persisted query
+// attempts, incremental delivery and DoRaw, but not the handshakes of subscriptions. Errors
+// surface as an ErrTransport. The updated Client is returned.
+func (client *Client) OnRequest(hook RequestHook) *Client {
+	client.requestHooks = append(client.requestHooks, hook)
+	return client
+}
+
+// OnResponse adds a hook called with the raw HTTP responses received by the client, e.g. to
+// capture cookies, CSRF tokens or request IDs from their headers. Hooks are called in the
+// order they were added, like OnRequest hooks. The updated Client is returned.
+func (client *Client) OnResponse(hook ResponseHook) *Client {
+	client.responseHooks = append(client.responseHooks, hook)
+	return client
+}
+
+// hookRoundTripper calls the hooks of a client around the round trips of transport.
+type hookRoundTripper struct {
+	transport     http.RoundTripper
+	requestHooks  []RequestHook
+	responseHooks []ResponseHook
+}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper hookRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if len(roundTripper.requestHooks) > 0 {
+		// RoundTrippers must not modify the request they are given.
+		req = req.Clone(req.Context())
+		for _, hook := range roundTripper.requestHooks {
+			err := hook(req)
+			if err != nil {
+				if req.Body != nil {
+					_ = req.Body.Close()
+				}
+				return nil, err
+			}
+		}
+	}
+
+	res, err := roundTripper.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, hook := range roundTripper.responseHooks {
+		err = hook(res)
+		if err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// withHooks returns httpClient unchanged when the client has no hooks, or a copy calling
+// them around each round trip.
+func (client *Client) withHooks(httpClient *http.Client) *http.Client {
+	if client == nil || (len(client.requestHooks) == 0 && len(client.responseHooks) == 0) {
+		return httpClient
+	}
+	transport := httpClient.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	clone := *httpClient
+	clone.Transport = hookRoundTripper{
+		transport:     transport,
+		requestHooks:  client.requestHooks,
+		responseHooks: client.responseHooks,
+	}
+	return &clone
+}
@@ -0,0 +1,83 @@
+package ggql
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultIdempotencyHeader is the header carrying the idempotency keys attached by
+// WithIdempotencyKeys when no other header is given.
+const DefaultIdempotencyHeader = "Idempotency-Key"
+
+// IdempotencyKeyFunc generates the idempotency key of a mutation.
+type IdempotencyKeyFunc func(request Request) (string, error)
+
+// RandomIdempotencyKey is an IdempotencyKeyFunc returning a random UUID (version 4), so
+// that every call of a mutation is applied once.
+func RandomIdempotencyKey(Request) (string, error) {
+	uuid := make([]byte, 16)
+	_, err := rand.Read(uuid)
+	if err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+	encoded := hex.EncodeToString(uuid)
+	return encoded[:8] + "-" + encoded[8:12] + "-" + encoded[12:16] + "-" + encoded[16:20] + "-" + encoded[20:], nil
+}
+
+// HashIdempotencyKey is an IdempotencyKeyFunc returning the hex-encoded SHA-256 hash of the
+// document, operation name and variables of the mutation, so that identical mutations share
+// their key and are applied once even when sent by separate calls.
+func HashIdempotencyKey(request Request) (string, error) {
+	encoded, err := json.Marshal(content{
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     request.Variables,
+	})
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// idempotency holds the settings installed by WithIdempotencyKeys.
+type idempotency struct {
+	header   string
+	generate IdempotencyKeyFunc
+}
+
+// WithIdempotencyKeys attaches an idempotency key to every mutation sent by the client, in
+// the given header, or DefaultIdempotencyHeader when empty. Keys are generated by generate,
+// or RandomIdempotencyKey when nil, once per call: the retries of WithRetry reuse the key of
+// the call, so that idempotency-aware servers don't apply a retried mutation twice. Mutations
+// already carrying the header keep their key. The updated Client is returned.
+func (client *Client) WithIdempotencyKeys(header string, generate IdempotencyKeyFunc) *Client {
+	if header == "" {
+		header = DefaultIdempotencyHeader
+	}
+	if generate == nil {
+		generate = RandomIdempotencyKey
+	}
+	client.idempotency = &idempotency{header: header, generate: generate}
+	return client
+}
+
+// middleware returns a Handler adding an idempotency key to the mutations passed to next.
+func (idempotency *idempotency) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		if operationType(request.Request, request.operationName) != "mutation" || request.header().Get(idempotency.header) != "" {
+			return next(ctx, request)
+		}
+		key, err := idempotency.generate(request)
+		if err != nil {
+			return Response{}, fmt.Errorf("generating idempotency key: %w", err)
+		}
+		return next(ctx, request.AddHeader(idempotency.header, key))
+	}
+}
@@ -0,0 +1,265 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"io"
+	"mime"
+	"mime/multipart"
+)
+
+// incrementalAccept is the Accept header of requests asking for incremental delivery.
+const incrementalAccept = "multipart/mixed; deferSpec=20220824, application/graphql-response+json, application/json"
+
+// Patch is a subsequent payload of an incrementally delivered response: the data of a
+// deferred fragment, or the items of a streamed list, to be merged at Path into the
+// initial result.
+type Patch struct {
+	Path       []any
+	Label      string
+	Data       gjson.Result
+	Items      gjson.Result
+	Errors     []GraphQLError
+	Extensions gjson.Result
+}
+
+// IncrementalResponse is the result of ExecuteIncremental. Initial holds the first payload
+// sent by the server; the deferred fragments and streamed items that follow are delivered
+// on Patches, which is closed once the server reports that no payload remains.
+type IncrementalResponse struct {
+	Initial Response
+	Patches <-chan Patch
+
+	err error
+}
+
+// Err returns the error that interrupted the delivery of the patches, if any. It must only
+// be called once Patches is closed.
+func (incremental *IncrementalResponse) Err() error {
+	return incremental.err
+}
+
+// DoIncremental behaves like ExecuteIncremental and wraps its result in a mo.Result.
+func (request Request) DoIncremental(ctx context.Context) mo.Result[*IncrementalResponse] {
+	return mo.TupleToResult(request.ExecuteIncremental(ctx))
+}
+
+// ExecuteIncremental sends a request whose document uses the @defer or @stream directives,
+// and accepts a multipart/mixed response following the incremental delivery over HTTP
+// specification. It returns once the initial payload is received; the subsequent payloads
+// are read in the background and delivered as patches. When the server answers with a
+// single JSON payload, Patches is closed immediately.
+// The patches must be received until the channel is closed, or ctx must be cancelled to
+// abandon the response. Like Subscribe, the request does not go through the client's
+// middleware chain. Until Patches is closed, the request counts as in flight for
+// Client.Close, which cancels it when it gives up waiting.
+func (request Request) ExecuteIncremental(ctx context.Context) (*IncrementalResponse, error) {
+	if request.Request == "" {
+		return nil, errors.New("no query/mutation provided")
+	}
+	err := request.checkRequiredHeaders()
+	if err != nil {
+		return nil, err
+	}
+	request.Request = request.client.withFragments(request.Request)
+	err = request.checkAllowlist(request.Request)
+	if err != nil {
+		return nil, err
+	}
+	ctx, end, err := request.client.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancel context.CancelFunc
+	if timeout := request.resolveTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	release := func() {
+		cancel()
+		end()
+	}
+
+	req, err := request.newHTTPRequest(ctx, content{
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     request.Variables,
+	})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	req.Header.Set("Accept", incrementalAccept)
+	err = request.authorize(ctx, req.Header)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	err = request.sign(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	res, err := request.resolveHTTPClient().Do(req)
+	if err != nil {
+		release()
+		return nil, &ErrTransport{Op: "sending request", Err: err}
+	}
+
+	request = request.failingOnHTTPStatus()
+	patches := make(chan Patch)
+	incremental := &IncrementalResponse{Patches: patches}
+	mediaType, params, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "multipart/mixed" {
+		defer release()
+		defer func(Body io.ReadCloser) {
+			_ = Body.Close()
+		}(res.Body)
+		close(patches)
+
+		body, err := readBody(res, request.resolveMaxResponseSize())
+		if err != nil {
+			return nil, err
+		}
+		incremental.Initial, err = parseResponse(body)
+		incremental.Initial.StatusCode = res.StatusCode
+		incremental.Initial.Header = res.Header
+		if statusErr := request.checkHTTPStatus(res, body); statusErr != nil {
+			return nil, statusErr
+		}
+		return incremental, err
+	}
+
+	reader := multipart.NewReader(res.Body, params["boundary"])
+	body, err := nextPayload(reader)
+	if err == nil {
+		incremental.Initial, err = parseResponse(body)
+	}
+	if err != nil {
+		release()
+		_ = res.Body.Close()
+		return nil, err
+	}
+	incremental.Initial.StatusCode = res.StatusCode
+	incremental.Initial.Header = res.Header
+
+	go func() {
+		defer release()
+		defer func(Body io.ReadCloser) {
+			_ = Body.Close()
+		}(res.Body)
+		defer close(patches)
+		incremental.err = readPatches(ctx, reader, incremental.Initial.Raw, patches)
+	}()
+	return incremental, nil
+}
+
+// nextPayload reads the next part of a multipart/mixed response.
+func nextPayload(reader *multipart.Reader) ([]byte, error) {
+	part, err := reader.NextPart()
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(part)
+	if err != nil {
+		return nil, &ErrTransport{Op: "reading response", Err: err}
+	}
+	return body, nil
+}
+
+// pendingResult describes a deferred fragment or streamed list announced by the server in
+// the "pending" entries of the current incremental delivery format.
+type pendingResult struct {
+	Path  []any  `json:"path"`
+	Label string `json:"label"`
+}
+
+// readPatches reads the subsequent payloads of an incremental response and sends their
+// patches until the server reports that no payload remains. Both the current format, where
+// results reference the "pending" entries by id, and the earlier format, where each result
+// carries its path, are supported.
+func readPatches(ctx context.Context, reader *multipart.Reader, initial gjson.Result, patches chan<- Patch) error {
+	pending := make(map[string]pendingResult)
+	hasNext := initial.Get("hasNext").Bool()
+	payload := initial
+	for hasNext {
+		for _, entry := range payload.Get("pending").Array() {
+			var result pendingResult
+			err := json.Unmarshal([]byte(entry.Raw), &result)
+			if err != nil {
+				return &ErrDecode{Err: fmt.Errorf("decoding pending result: %w", err)}
+			}
+			pending[entry.Get("id").String()] = result
+		}
+
+		body, err := nextPayload(reader)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		payload = gjson.ParseBytes(body)
+		hasNext = payload.Get("hasNext").Bool()
+
+		results := payload.Get("incremental").Array()
+		if !payload.Get("incremental").Exists() && (payload.Get("data").Exists() || payload.Get("items").Exists() || payload.Get("errors").Exists()) {
+			results = []gjson.Result{payload}
+		}
+		for _, result := range results {
+			patch, err := decodePatch(result, pending)
+			if err != nil {
+				return err
+			}
+			select {
+			case patches <- patch:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	return nil
+}
+
+// decodePatch decodes a single incremental result.
+func decodePatch(result gjson.Result, pending map[string]pendingResult) (Patch, error) {
+	patch := Patch{
+		Label:      result.Get("label").String(),
+		Data:       result.Get("data"),
+		Items:      result.Get("items"),
+		Extensions: result.Get("extensions"),
+	}
+	if path := result.Get("path"); path.IsArray() {
+		err := json.Unmarshal([]byte(path.Raw), &patch.Path)
+		if err != nil {
+			return patch, &ErrDecode{Err: fmt.Errorf("decoding patch path: %w", err)}
+		}
+	}
+	if id := result.Get("id"); id.Exists() {
+		origin := pending[id.String()]
+		patch.Label = origin.Label
+		patch.Path = append(append([]any(nil), origin.Path...), patch.Path...)
+		if subPath := result.Get("subPath"); subPath.IsArray() {
+			var rest []any
+			err := json.Unmarshal([]byte(subPath.Raw), &rest)
+			if err != nil {
+				return patch, &ErrDecode{Err: fmt.Errorf("decoding patch path: %w", err)}
+			}
+			patch.Path = append(patch.Path, rest...)
+		}
+	}
+	if errs := result.Get("errors"); errs.IsArray() {
+		err := json.Unmarshal([]byte(errs.Raw), &patch.Errors)
+		if err != nil {
+			return patch, &ErrDecode{Err: fmt.Errorf("decoding errors: %w", err)}
+		}
+	}
+	return patch, nil
+}
@@ -0,0 +1,129 @@
+package ggql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// HandlerTransport returns a Transport executing operations against handler, a GraphQL
+// server such as a gqlgen or graphql-go handler, within the process and without any network
+// socket. Operations are encoded and decoded exactly like with HTTPTransport, so that the
+// settings of the client still apply, which makes it suitable for fast integration tests
+// and embedded servers. See HandlerRoundTripper to also run batches, subscriptions excepted,
+// against handler.
+func HandlerTransport(handler http.Handler) Transport {
+	roundTripper := HandlerRoundTripper(handler)
+	return TransportFunc(func(ctx context.Context, operation Operation) (Response, error) {
+		operation.request = operation.request.WithRoundTripper(roundTripper)
+		return HTTPTransport{}.Execute(ctx, operation)
+	})
+}
+
+// HandlerRoundTripper returns an http.RoundTripper serving requests with handler within the
+// process. The response is buffered until handler returns. It can be installed with
+// Client.WithHTTPClient or Request.WithRoundTripper.
+func HandlerRoundTripper(handler http.Handler) http.RoundTripper {
+	return handlerRoundTripper{handler: handler}
+}
+
+// handlerRoundTripper is the http.RoundTripper returned by HandlerRoundTripper.
+type handlerRoundTripper struct {
+	handler http.Handler
+}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper handlerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	served := req.Clone(req.Context())
+	served.RequestURI = req.URL.RequestURI()
+	served.RemoteAddr = "127.0.0.1:0"
+	if served.Host == "" {
+		served.Host = req.URL.Host
+	}
+	if served.Body == nil {
+		served.Body = http.NoBody
+	}
+
+	recorder := &responseRecorder{header: make(http.Header)}
+	roundTripper.handler.ServeHTTP(recorder, served)
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recorder.status, http.StatusText(recorder.status)),
+		StatusCode:    recorder.status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        recorder.header,
+		Body:          io.NopCloser(&recorder.body),
+		ContentLength: int64(recorder.body.Len()),
+		Request:       req,
+	}, nil
+}
+
+// responseRecorder is the http.ResponseWriter buffering the response of a handler.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header implements http.ResponseWriter.
+func (recorder *responseRecorder) Header() http.Header {
+	return recorder.header
+}
+
+// Write implements http.ResponseWriter.
+func (recorder *responseRecorder) Write(data []byte) (int, error) {
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+	}
+	return recorder.body.Write(data)
+}
+
+// WriteHeader implements http.ResponseWriter.
+func (recorder *responseRecorder) WriteHeader(status int) {
+	if recorder.status == 0 {
+		recorder.status = status
+	}
+}
+
+// Flush implements http.Flusher. The response is buffered, so it has no effect.
+func (recorder *responseRecorder) Flush() {}
+
+// ResolverTransport returns a Transport executing operations with resolve, without any
+// encoding to HTTP, e.g. to stub a server in tests or to call an in-process executor. The
+// value returned by resolve is encoded as JSON into the "data" member of the response. An
+// error is reported as the "errors" member: the GraphQL errors of an *ErrGraphQL as is, and
+// any other error as a single GraphQL error with its message. Responses have the 200 status.
+func ResolverTransport(resolve func(ctx context.Context, operation Operation) (any, error)) Transport {
+	return TransportFunc(func(ctx context.Context, operation Operation) (Response, error) {
+		data, err := resolve(ctx, operation)
+		payload := struct {
+			Data   any            `json:"data"`
+			Errors []GraphQLError `json:"errors,omitempty"`
+		}{Data: data}
+		if err != nil {
+			var graphQL *ErrGraphQL
+			if errors.As(err, &graphQL) {
+				payload.Errors = graphQL.Errors
+			} else {
+				payload.Errors = []GraphQLError{{Message: err.Error()}}
+			}
+		}
+
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return Response{}, fmt.Errorf("encoding resolved data: %w", err)
+		}
+		response, err := parseResponse(body)
+		response.StatusCode = http.StatusOK
+		response.Header = make(http.Header)
+		return response, err
+	})
+}
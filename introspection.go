@@ -0,0 +1,356 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"strconv"
+	"strings"
+)
+
+// IntrospectionQuery is the standard introspection query, requesting every type, field,
+// argument, enum value and directive of the schema along with their descriptions.
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      description
+      locations
+      args { ...InputValue }
+      isRepeatable
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    description
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType {
+                kind
+                name
+              }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// defaultDeprecationReason is the reason implied by a @deprecated directive without argument.
+const defaultDeprecationReason = "No longer supported"
+
+// builtinScalars lists the scalars defined by the specification, omitted from printed SDL.
+var builtinScalars = map[string]bool{
+	"String":  true,
+	"Int":     true,
+	"Float":   true,
+	"Boolean": true,
+	"ID":      true,
+}
+
+// builtinDirectives lists the directives defined by the specification, omitted from printed SDL.
+var builtinDirectives = map[string]bool{
+	"skip":        true,
+	"include":     true,
+	"deprecated":  true,
+	"specifiedBy": true,
+	"oneOf":       true,
+}
+
+// Introspect runs the standard introspection query against the client's endpoint and
+// returns the "__schema" object of the response. It fails if the server reports GraphQL
+// errors, e.g. because introspection is disabled.
+func (client *Client) Introspect(ctx context.Context) mo.Result[gjson.Result] {
+	response, err := client.NewRequest().
+		Query(IntrospectionQuery).
+		OperationName("IntrospectionQuery").
+		FailOnGraphQLErrors().
+		do(ctx)
+	if err != nil {
+		return mo.Err[gjson.Result](err)
+	}
+
+	schema := response.Data.Get("__schema")
+	if !schema.Exists() {
+		return mo.Err[gjson.Result](&ErrDecode{Err: errors.New("response contains no schema")})
+	}
+	return mo.Ok(schema)
+}
+
+// PrintSDL renders an introspection result as GraphQL Schema Definition Language text.
+// The result can be the "__schema" object returned by Introspect, or a whole introspection
+// response. Introspection types, built-in scalars and built-in directives are omitted.
+func PrintSDL(introspection gjson.Result) string {
+	schema := introspection
+	switch {
+	case introspection.Get("data.__schema").Exists():
+		schema = introspection.Get("data.__schema")
+	case introspection.Get("__schema").Exists():
+		schema = introspection.Get("__schema")
+	}
+
+	var blocks []string
+	if definition := printSchemaDefinition(schema); definition != "" {
+		blocks = append(blocks, definition)
+	}
+	for _, directive := range schema.Get("directives").Array() {
+		if !builtinDirectives[directive.Get("name").String()] {
+			blocks = append(blocks, printDirective(directive))
+		}
+	}
+	for _, t := range schema.Get("types").Array() {
+		name := t.Get("name").String()
+		if strings.HasPrefix(name, "__") || builtinScalars[name] {
+			continue
+		}
+		blocks = append(blocks, printType(t))
+	}
+	return strings.Join(blocks, "\n\n") + "\n"
+}
+
+// printSchemaDefinition renders the schema definition, or returns an empty string when the
+// root operation types use their conventional names and the definition can be omitted.
+func printSchemaDefinition(schema gjson.Result) string {
+	roots := []struct{ operation, defaultName string }{
+		{"query", "Query"},
+		{"mutation", "Mutation"},
+		{"subscription", "Subscription"},
+	}
+
+	conventional := true
+	var fields []string
+	for _, root := range roots {
+		name := schema.Get(root.operation + "Type.name").String()
+		if name == "" {
+			continue
+		}
+		if name != root.defaultName {
+			conventional = false
+		}
+		fields = append(fields, "  "+root.operation+": "+name)
+	}
+	if conventional {
+		return ""
+	}
+	return "schema {\n" + strings.Join(fields, "\n") + "\n}"
+}
+
+// printDirective renders a directive definition.
+func printDirective(directive gjson.Result) string {
+	var locations []string
+	for _, location := range directive.Get("locations").Array() {
+		locations = append(locations, location.String())
+	}
+	return printDescription(directive.Get("description").String(), "") +
+		"directive @" + directive.Get("name").String() +
+		printArguments(directive.Get("args").Array(), "") +
+		printRepeatable(directive) +
+		" on " + strings.Join(locations, " | ")
+}
+
+// printRepeatable renders the repeatable keyword of a directive definition, or returns an
+// empty string when the directive is not repeatable.
+func printRepeatable(directive gjson.Result) string {
+	if !directive.Get("isRepeatable").Bool() {
+		return ""
+	}
+	return " repeatable"
+}
+
+// printType renders the definition of a named type according to its kind.
+func printType(t gjson.Result) string {
+	name := t.Get("name").String()
+	description := printDescription(t.Get("description").String(), "")
+
+	switch t.Get("kind").String() {
+	case "SCALAR":
+		return description + "scalar " + name
+	case "OBJECT":
+		return description + "type " + name + printInterfaces(t) + printFields(t.Get("fields").Array())
+	case "INTERFACE":
+		return description + "interface " + name + printInterfaces(t) + printFields(t.Get("fields").Array())
+	case "UNION":
+		var members []string
+		for _, member := range t.Get("possibleTypes").Array() {
+			members = append(members, member.Get("name").String())
+		}
+		return description + "union " + name + " = " + strings.Join(members, " | ")
+	case "ENUM":
+		var values []string
+		for _, value := range t.Get("enumValues").Array() {
+			values = append(values, printDescription(value.Get("description").String(), "  ")+
+				"  "+value.Get("name").String()+printDeprecation(value))
+		}
+		return description + "enum " + name + " {\n" + strings.Join(values, "\n") + "\n}"
+	case "INPUT_OBJECT":
+		var fields []string
+		for _, field := range t.Get("inputFields").Array() {
+			fields = append(fields, printDescription(field.Get("description").String(), "  ")+
+				"  "+printInputValue(field))
+		}
+		return description + "input " + name + " {\n" + strings.Join(fields, "\n") + "\n}"
+	default:
+		return description + "scalar " + name
+	}
+}
+
+// printInterfaces renders the implements clause of an object or interface type.
+func printInterfaces(t gjson.Result) string {
+	var interfaces []string
+	for _, i := range t.Get("interfaces").Array() {
+		interfaces = append(interfaces, i.Get("name").String())
+	}
+	if len(interfaces) == 0 {
+		return ""
+	}
+	return " implements " + strings.Join(interfaces, " & ")
+}
+
+// printFields renders the fields block of an object or interface type.
+func printFields(fields []gjson.Result) string {
+	lines := make([]string, len(fields))
+	for i, field := range fields {
+		lines[i] = printDescription(field.Get("description").String(), "  ") +
+			"  " + field.Get("name").String() +
+			printArguments(field.Get("args").Array(), "  ") +
+			": " + printTypeRef(field.Get("type")) +
+			printDeprecation(field)
+	}
+	return " {\n" + strings.Join(lines, "\n") + "\n}"
+}
+
+// printArguments renders an argument list. Arguments are printed on one line unless one of
+// them has a description, in which case each argument is printed on its own line.
+func printArguments(args []gjson.Result, indent string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	multiline := false
+	for _, arg := range args {
+		if arg.Get("description").String() != "" {
+			multiline = true
+		}
+	}
+
+	values := make([]string, len(args))
+	for i, arg := range args {
+		values[i] = printInputValue(arg)
+	}
+	if !multiline {
+		return "(" + strings.Join(values, ", ") + ")"
+	}
+
+	lines := make([]string, len(args))
+	for i, arg := range args {
+		lines[i] = printDescription(arg.Get("description").String(), indent+"  ") + indent + "  " + values[i]
+	}
+	return "(\n" + strings.Join(lines, "\n") + "\n" + indent + ")"
+}
+
+// printInputValue renders an argument or input field with its type and default value.
+func printInputValue(value gjson.Result) string {
+	printed := value.Get("name").String() + ": " + printTypeRef(value.Get("type"))
+	if defaultValue := value.Get("defaultValue"); defaultValue.Exists() && defaultValue.Type != gjson.Null {
+		printed += " = " + defaultValue.String()
+	}
+	return printed + printDeprecation(value)
+}
+
+// printTypeRef renders a type reference, wrapping list and non-null types.
+func printTypeRef(ref gjson.Result) string {
+	switch ref.Get("kind").String() {
+	case "NON_NULL":
+		return printTypeRef(ref.Get("ofType")) + "!"
+	case "LIST":
+		return "[" + printTypeRef(ref.Get("ofType")) + "]"
+	default:
+		return ref.Get("name").String()
+	}
+}
+
+// printDeprecation renders the @deprecated directive of a deprecated field or enum value.
+func printDeprecation(element gjson.Result) string {
+	if !element.Get("isDeprecated").Bool() {
+		return ""
+	}
+	reason := element.Get("deprecationReason").String()
+	if reason == "" || reason == defaultDeprecationReason {
+		return " @deprecated"
+	}
+	return " @deprecated(reason: " + strconv.Quote(reason) + ")"
+}
+
+// printDescription renders a description as a block string followed by a line break,
+// or returns an empty string when there is no description. Descriptions ending with a quote
+// are written on their own line, since the quote would merge with the closing delimiter.
+func printDescription(description, indent string) string {
+	if description == "" {
+		return ""
+	}
+	escaped := strings.ReplaceAll(description, `"""`, `\"""`)
+	if !strings.Contains(escaped, "\n") && len(escaped) <= 70 && !strings.HasSuffix(escaped, `"`) {
+		return indent + `"""` + escaped + `"""` + "\n"
+	}
+	lines := strings.Split(escaped, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = indent + line
+		}
+	}
+	return indent + `"""` + "\n" + strings.Join(lines, "\n") + "\n" + indent + `"""` + "\n"
+}
@@ -0,0 +1,38 @@
+package ggql
+
+import (
+	"github.com/tidwall/gjson"
+	"testing"
+)
+
+func TestSchemaFromIntrospectionRoundTrip(t *testing.T) {
+	introspection := gjson.Parse(`{"__schema": {
+		"queryType": {"name": "Query"},
+		"directives": [
+			{"name": "tag", "description": "Tags an \"element\"", "locations": ["FIELD_DEFINITION"],
+				"args": [{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}}],
+				"isRepeatable": true}
+		],
+		"types": [
+			{"kind": "OBJECT", "name": "Query", "description": "The \"root\"", "interfaces": [],
+				"fields": [{"name": "hello", "description": "Says \"hello\"", "args": [],
+					"type": {"kind": "SCALAR", "name": "String"}}]},
+			{"kind": "SCALAR", "name": "String"}
+		]
+	}}`)
+
+	schema, err := SchemaFromIntrospection(introspection)
+	if err != nil {
+		t.Fatalf("%v\n%s", err, PrintSDL(introspection))
+	}
+	tag := schema.Directives["tag"]
+	if tag == nil || !tag.IsRepeatable {
+		t.Errorf("directive @tag is not repeatable:\n%s", PrintSDL(introspection))
+	}
+	if description := schema.Types["Query"].Description; description != `The "root"` {
+		t.Errorf("got description %q, want %q", description, `The "root"`)
+	}
+	if description := schema.Types["Query"].Fields.ForName("hello").Description; description != `Says "hello"` {
+		t.Errorf("got description %q, want %q", description, `Says "hello"`)
+	}
+}
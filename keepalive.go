@@ -0,0 +1,83 @@
+package ggql
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// legacySubprotocol is the subprotocol of the legacy subscriptions-transport-ws protocol,
+// still served by older servers.
+const legacySubprotocol = "graphql-ws"
+
+// Message types of the legacy subscriptions-transport-ws protocol which differ from the
+// graphql-transport-ws ones.
+const (
+	legacyMessageStart           = "start"
+	legacyMessageData            = "data"
+	legacyMessageStop            = "stop"
+	legacyMessageKeepAlive       = "ka"
+	legacyMessageConnectionError = "connection_error"
+)
+
+// keepAlive holds the settings installed by WithKeepAlive.
+type keepAlive struct {
+	interval time.Duration
+	timeout  time.Duration
+}
+
+// WithKeepAlive checks the liveness of the WebSocket connections of the client's
+// subscriptions. A ping message is sent every interval, answered by a pong from live
+// servers, and connections on which no message at all is received for timeout are torn
+// down as failed, to be replaced if the client reconnects subscriptions, see WithReconnect.
+// This detects connections left open by dead peers or network changes, which would
+// otherwise never deliver data again. With the legacy subscriptions-transport-ws protocol,
+// which has no ping, the keep-alive messages sent by the server serve as liveness signal.
+// The timeout should exceed the interval, and defaults to twice the interval when lower.
+// A zero or negative interval disables the checks. The updated Client is returned.
+func (client *Client) WithKeepAlive(interval, timeout time.Duration) *Client {
+	if interval <= 0 {
+		client.keepAlive = nil
+		return client
+	}
+	if timeout <= interval {
+		timeout = 2 * interval
+	}
+	client.keepAlive = &keepAlive{interval: interval, timeout: timeout}
+	return client
+}
+
+// legacyOutgoing returns the legacy type of a message sent by the client.
+func legacyOutgoing(kind string) string {
+	switch kind {
+	case messageSubscribe:
+		return legacyMessageStart
+	case messageComplete:
+		return legacyMessageStop
+	default:
+		return kind
+	}
+}
+
+// legacyIncoming returns the graphql-transport-ws type of a legacy message sent by the
+// server. Keep-alive messages are mapped to pong, as both only signal liveness.
+func legacyIncoming(kind string) string {
+	switch kind {
+	case legacyMessageData:
+		return messageNext
+	case legacyMessageKeepAlive:
+		return messagePong
+	default:
+		return kind
+	}
+}
+
+// errorPayload normalizes the payload of an error message, a list of GraphQL errors, or a
+// single one with the legacy protocol.
+func errorPayload(payload []byte) []byte {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return []byte(fmt.Sprintf("[%s]", trimmed))
+	}
+	return payload
+}
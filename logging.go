@@ -0,0 +1,132 @@
+package ggql
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// redactedValue replaces the values hidden from the logs.
+const redactedValue = "[REDACTED]"
+
+// Header and variable names redacted by default by the logging middleware.
+var (
+	DefaultRedactedHeaders   = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+	DefaultRedactedVariables = []string{"password", "token", "secret", "apiKey"}
+)
+
+// LogOptions configures the logging middleware returned by Logging.
+type LogOptions struct {
+	// Logger receives the records. It defaults to slog.Default().
+	Logger *slog.Logger
+
+	// Level is the level of the records of successful requests. Requests whose response
+	// contains GraphQL errors are logged at slog.LevelWarn, failed requests at slog.LevelError.
+	Level slog.Level
+
+	// Headers and Variables add the outgoing headers and the variables to the records.
+	Headers   bool
+	Variables bool
+
+	// RedactHeaders and RedactVariables list the names, matched case-insensitively, whose
+	// values are replaced by "[REDACTED]". Variables are redacted at any depth. Nil slices
+	// fall back to DefaultRedactedHeaders and DefaultRedactedVariables.
+	RedactHeaders   []string
+	RedactVariables []string
+}
+
+// Logging returns a Middleware logging one structured record per request, with the operation
+// name, type and fingerprint (see Fingerprint), the endpoint, the duration, the HTTP status,
+// the number of GraphQL errors and the class of the error returned, if any (see ErrorClass).
+func Logging(options LogOptions) Middleware {
+	logger := options.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	redactHeaders := nameSet(options.RedactHeaders, DefaultRedactedHeaders)
+	redactVariables := nameSet(options.RedactVariables, DefaultRedactedVariables)
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, request Request) (Response, error) {
+			start := time.Now()
+			response, err := next(ctx, request)
+
+			attributes := []slog.Attr{
+				slog.String("operation", request.Name()),
+				slog.String("type", operationType(request.Request, request.Name())),
+				slog.String("fingerprint", request.Fingerprint()),
+				slog.String("endpoint", request.Endpoint),
+				slog.Duration("duration", time.Since(start)),
+				slog.Int("status", response.StatusCode),
+			}
+			if options.Headers {
+				headers := make(map[string]any)
+				for key, values := range request.header() {
+					value := strings.Join(values, ", ")
+					if redactHeaders[strings.ToLower(key)] {
+						value = redactedValue
+					}
+					headers[key] = value
+				}
+				attributes = append(attributes, slog.Any("headers", headers))
+			}
+			if options.Variables {
+				attributes = append(attributes, slog.Any("variables", redactVariableValues(request.Variables, redactVariables)))
+			}
+
+			level, message := options.Level, "graphql request"
+			switch {
+			case err != nil:
+				level, message = slog.LevelError, "graphql request failed"
+				attributes = append(attributes, slog.String("error_class", ErrorClass(err)), slog.String("error", err.Error()))
+			case response.HasErrors():
+				level, message = slog.LevelWarn, "graphql request returned errors"
+				attributes = append(attributes, slog.String("error_class", ErrorClassGraphQL))
+			}
+			if len(response.Errors) > 0 {
+				attributes = append(attributes, slog.Int("errors", len(response.Errors)))
+			}
+
+			logger.LogAttrs(ctx, level, message, attributes...)
+			return response, err
+		}
+	}
+}
+
+// nameSet returns the lower-cased set of names, or of fallback when names is nil.
+func nameSet(names, fallback []string) map[string]bool {
+	if names == nil {
+		names = fallback
+	}
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[strings.ToLower(name)] = true
+	}
+	return set
+}
+
+// redactVariableValues returns a copy of value in which the values of the redacted keys have
+// been replaced, at any depth.
+func redactVariableValues(value any, redact map[string]bool) any {
+	switch v := value.(type) {
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for key, item := range v {
+			if redact[strings.ToLower(key)] {
+				redacted[key] = redactedValue
+				continue
+			}
+			redacted[key] = redactVariableValues(item, redact)
+		}
+		return redacted
+	case []any:
+		redacted := make([]any, len(v))
+		for i, item := range v {
+			redacted[i] = redactVariableValues(item, redact)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
@@ -0,0 +1,126 @@
+package ggql
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"io"
+	"sort"
+)
+
+// ManifestOperation is an operation of a persisted query Manifest. ID is the hex-encoded
+// SHA-256 hash of Body, the document sent by the client, as used by Automatic Persisted
+// Queries. Type is "query", "mutation" or "subscription".
+type ManifestOperation struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Body string `json:"body"`
+}
+
+// Manifest is a persisted query manifest listing the documents an application sends, to be
+// registered with servers and gateways that only accept known operations, such as Apollo
+// Router with safelisting or Relay servers, or to configure the client itself (see
+// LoadTrustedDocuments and LoadAllowlist, which read the manifests written by Manifest).
+type Manifest struct {
+	operations map[string]ManifestOperation
+}
+
+// NewManifest returns an empty Manifest.
+func NewManifest() *Manifest {
+	return &Manifest{operations: make(map[string]ManifestOperation)}
+}
+
+// Add adds the document to the manifest, as sent by a request without registered
+// fragments. The document must be valid and define a single operation, so that its hash
+// matches the one of the requests sending it; documents holding several operations are
+// rejected. Adding a document again has no effect.
+func (manifest *Manifest) Add(document string) error {
+	return manifest.add(document, "")
+}
+
+// AddRequest adds the document sent by the request to the manifest: its document followed
+// by the registered fragments it references (see Client.RegisterFragment). Documents
+// holding several operations are accepted when the request selects one with OperationName.
+func (manifest *Manifest) AddRequest(request Request) error {
+	return manifest.add(request.client.withFragments(request.Request), request.operationName)
+}
+
+// add adds the document, whose executed operation is selected by name.
+func (manifest *Manifest) add(document, name string) error {
+	parsed, err := parser.ParseQuery(&ast.Source{Input: document})
+	if err != nil {
+		return fmt.Errorf("parsing document: %w", err)
+	}
+	var operation *ast.OperationDefinition
+	switch {
+	case name != "":
+		operation = parsed.Operations.ForName(name)
+		if operation == nil {
+			return fmt.Errorf("document defines no operation %s", name)
+		}
+	case len(parsed.Operations) == 1:
+		operation = parsed.Operations[0]
+	default:
+		return fmt.Errorf("document defines %d operations, exactly one is expected", len(parsed.Operations))
+	}
+
+	id := queryHash(document)
+	manifest.operations[id] = ManifestOperation{
+		ID:   id,
+		Name: operation.Name,
+		Type: string(operation.Operation),
+		Body: document,
+	}
+	return nil
+}
+
+// Operations returns the operations of the manifest, sorted by name then ID.
+func (manifest *Manifest) Operations() []ManifestOperation {
+	operations := make([]ManifestOperation, 0, len(manifest.operations))
+	for _, operation := range manifest.operations {
+		operations = append(operations, operation)
+	}
+	sort.Slice(operations, func(i, j int) bool {
+		if operations[i].Name != operations[j].Name {
+			return operations[i].Name < operations[j].Name
+		}
+		return operations[i].ID < operations[j].ID
+	})
+	return operations
+}
+
+// WriteApollo writes the manifest in the persisted query manifest format of Apollo, read by
+// Apollo Router and GraphOS:
+//
+//	{"format": "apollo-persisted-query-manifest", "version": 1, "operations": [{"id": ..., "name": ..., "type": ..., "body": ...}]}
+func (manifest *Manifest) WriteApollo(w io.Writer) error {
+	return writeManifest(w, struct {
+		Format     string              `json:"format"`
+		Version    int                 `json:"version"`
+		Operations []ManifestOperation `json:"operations"`
+	}{
+		Format:     "apollo-persisted-query-manifest",
+		Version:    1,
+		Operations: manifest.Operations(),
+	})
+}
+
+// WriteRelay writes the manifest in the persisted queries format of Relay, an object
+// mapping the ID of every document to its body.
+func (manifest *Manifest) WriteRelay(w io.Writer) error {
+	documents := make(map[string]string, len(manifest.operations))
+	for id, operation := range manifest.operations {
+		documents[id] = operation.Body
+	}
+	return writeManifest(w, documents)
+}
+
+// writeManifest writes the indented JSON encoding of the manifest v.
+func writeManifest(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
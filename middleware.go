@@ -0,0 +1,78 @@
+package ggql
+
+import "context"
+
+// Handler executes a request and returns its parsed response.
+type Handler func(ctx context.Context, request Request) (Response, error)
+
+// Middleware wraps a Handler to add behavior around the execution of a request. A middleware
+// can inspect or modify the outgoing request (query, variables, headers) before calling next,
+// and inspect or replace the response it returns. Middleware can also answer on its own,
+// without calling next at all, e.g. to serve a cached response.
+type Middleware func(next Handler) Handler
+
+// Use appends middleware to the client's chain. Middleware are applied in the order they
+// were added: the first one is the outermost and sees the request first and the response last.
+// The updated Client is returned.
+func (client *Client) Use(middleware ...Middleware) *Client {
+	client.middleware = append(client.middleware, middleware...)
+	return client
+}
+
+// handler composes the client's middleware chain around the execute handler. The built-in
+// stages enabled on the client sit between the user middleware and the execute handler.
+func (client *Client) handler() Handler {
+	handler := Handler(execute)
+	if client == nil {
+		return handler
+	}
+	if client.breaker != nil {
+		handler = client.breaker.middleware(handler)
+	}
+	if client.balancer != nil {
+		handler = client.balancer.middleware(handler)
+	}
+	if client.bulkhead != nil {
+		handler = client.bulkhead.middleware(handler)
+	}
+	if client.limiter != nil {
+		handler = client.limiter.middleware(handler)
+	}
+	if client.retry != nil {
+		handler = client.retry.middleware(handler)
+	}
+	if client.idempotency != nil {
+		handler = client.idempotency.middleware(handler)
+	}
+	if client.fingerprint != "" {
+		handler = client.fingerprint.middleware(handler)
+	}
+	if client.dedup != nil {
+		handler = deduplicate(client.dedup, handler)
+	}
+	if client.cache != nil {
+		handler = client.cache.middleware(handler)
+	}
+	if client.normalized != nil {
+		handler = client.normalized.middleware(handler)
+	}
+	if client.complexity != nil {
+		handler = client.complexity.middleware(handler)
+	}
+	if client.variableChecker != nil {
+		handler = client.variableChecker.middleware(handler)
+	}
+	if client.validator != nil {
+		handler = client.validator.middleware(handler)
+	}
+	if client.allowlist != nil {
+		handler = allowlisted(handler)
+	}
+	if len(client.requiredHeaders) > 0 {
+		handler = requireHeaders(handler)
+	}
+	for i := len(client.middleware) - 1; i >= 0; i-- {
+		handler = client.middleware[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,440 @@
+package ggql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/tidwall/gjson"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"sync"
+	"time"
+)
+
+// normalizedCache stores query results normalized by entity: every object of a response
+// identified by its __typename and id is stored once, and the cached queries reference it.
+// Fields are stored under their name and arguments, while the cached queries keep the
+// response keys of their fields, so that aliases and arguments don't mix up the results of
+// different queries.
+type normalizedCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	entities   map[string]map[string]any
+	references map[string]int
+	queries    map[string]normalizedQuery
+	nextSweep  time.Time
+}
+
+// normalizedQuery is a cached query result, whose entities are replaced by references, along
+// with the shape of the result.
+type normalizedQuery struct {
+	root     any
+	shape    any
+	entities map[string]bool
+	expires  time.Time
+}
+
+// entityRef references an entity of the cache.
+type entityRef struct {
+	key string
+}
+
+// objectShape lists the fields of an object returned for a query, in the order of the
+// response.
+type objectShape []shapeField
+
+// shapeField is a field of an object returned for a query: its response key, the name it is
+// stored under and the shape of its value.
+type shapeField struct {
+	key   string
+	name  string
+	shape any
+}
+
+// listShape holds the shapes of the items of a list returned for a query.
+type listShape []any
+
+// selectedField is a field selected by an operation under a response key, merged across the
+// fragments of the document: the name it is stored under and its sub-selections.
+type selectedField struct {
+	name       string
+	selections ast.SelectionSet
+}
+
+// fieldSelector resolves the fields selected by an operation.
+type fieldSelector struct {
+	document  *ast.QueryDocument
+	variables map[string]any
+}
+
+// WithNormalizedCache enables an in-memory cache normalizing the results of the queries by
+// entity, in the manner of Apollo's InMemoryCache. Objects carrying both a __typename and
+// an id field are stored once, keyed by type and id, and the fields returned by different
+// queries for the same entity are merged: a query or mutation updating an entity updates
+// every cached query referencing it. Fields are stored under their name and arguments, so
+// that queries selecting a field with other arguments, or under another alias, don't read
+// each other's values. Cached query results expire after ttl, and the entities no cached
+// query references anymore are dropped.
+// A cached query whose entities lack some of its fields, as may happen when another query
+// replaced a nested object, is considered missing and executed again.
+// Only the responses without GraphQL errors are cached, and requests authenticated with
+// their own credentials bypass the cache. A zero or negative ttl disables the cache. The
+// updated Client is returned.
+func (client *Client) WithNormalizedCache(ttl time.Duration) *Client {
+	if ttl <= 0 {
+		client.normalized = nil
+		return client
+	}
+	client.normalized = &normalizedCache{ttl: ttl}
+	client.normalized.purge()
+	return client
+}
+
+// InvalidateEntity removes from the client's normalized cache the entity identified by
+// typename and id, along with every cached query whose result contains it.
+func (client *Client) InvalidateEntity(typename string, id any) {
+	if client.normalized == nil {
+		return
+	}
+	key := entityKey(typename, gjson.Parse(mustMarshal(id)))
+	client.normalized.mu.Lock()
+	defer client.normalized.mu.Unlock()
+	delete(client.normalized.entities, key)
+	for queryKey, query := range client.normalized.queries {
+		if query.entities[key] {
+			client.normalized.removeQuery(queryKey)
+		}
+	}
+}
+
+// middleware returns a Handler serving the queries from the normalized cache and storing the
+// entities of the successful responses returned by next. Documents that can't be parsed are
+// not cached.
+func (cache *normalizedCache) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		kind := operationType(request.Request, request.operationName)
+		if request.noCache || request.auth != nil || kind == "subscription" {
+			return next(ctx, request)
+		}
+		selector, operation := newFieldSelector(request)
+		if operation == nil {
+			return next(ctx, request)
+		}
+		key, err := cacheKey(request)
+		if err != nil || kind == "mutation" {
+			key = ""
+		}
+
+		if key != "" {
+			if response, ok := cache.read(key); ok {
+				return response, nil
+			}
+		}
+
+		response, err := next(ctx, request)
+		if err == nil && !response.HasErrors() && response.StatusCode < 300 && response.Data.IsObject() {
+			cache.write(key, response.Data, selector, operation.SelectionSet)
+		}
+		return response, err
+	}
+}
+
+// newFieldSelector returns the selector of the fields of the operation executed by request,
+// and that operation, or nil when the document can't be parsed.
+func newFieldSelector(request Request) (fieldSelector, *ast.OperationDefinition) {
+	document, err := parser.ParseQuery(&ast.Source{Input: request.Request})
+	if err != nil {
+		return fieldSelector{}, nil
+	}
+	operation := selectOperation(document, request.operationName)
+	if operation == nil {
+		return fieldSelector{}, nil
+	}
+
+	variables := make(map[string]any, len(request.Variables))
+	for name, value := range request.Variables {
+		variables[name] = value
+	}
+	for _, definition := range operation.VariableDefinitions {
+		_, ok := variables[definition.Variable]
+		if !ok && definition.DefaultValue != nil {
+			variables[definition.Variable], _ = definition.DefaultValue.Value(nil)
+		}
+	}
+	return fieldSelector{document: document, variables: variables}, operation
+}
+
+// fields returns the fields of the selections by response key, following the fragments.
+// Type conditions are ignored: only the fields found in the response are looked up.
+func (selector fieldSelector) fields(selections ast.SelectionSet) map[string]*selectedField {
+	fields := make(map[string]*selectedField)
+	selector.collect(selections, fields, make(map[string]bool))
+	return fields
+}
+
+// collect adds the fields of the selections to fields, spreading the fragments not visited
+// yet.
+func (selector fieldSelector) collect(selections ast.SelectionSet, fields map[string]*selectedField, visited map[string]bool) {
+	for _, selection := range selections {
+		switch selection := selection.(type) {
+		case *ast.Field:
+			key := selection.Alias
+			if key == "" {
+				key = selection.Name
+			}
+			field, ok := fields[key]
+			if !ok {
+				field = &selectedField{name: selector.storeName(selection)}
+				fields[key] = field
+			}
+			field.selections = append(field.selections, selection.SelectionSet...)
+		case *ast.InlineFragment:
+			selector.collect(selection.SelectionSet, fields, visited)
+		case *ast.FragmentSpread:
+			fragment := selector.document.Fragments.ForName(selection.Name)
+			if fragment != nil && !visited[fragment.Name] {
+				visited[fragment.Name] = true
+				selector.collect(fragment.SelectionSet, fields, visited)
+			}
+		}
+	}
+}
+
+// storeName returns the name a field is stored under: its name, followed by the JSON
+// encoding of its arguments, resolved with the variables, when it has any, in the manner of
+// Apollo's storeFieldName, e.g. posts({"first":3}).
+func (selector fieldSelector) storeName(field *ast.Field) string {
+	if len(field.Arguments) == 0 {
+		return field.Name
+	}
+	arguments := make(map[string]any, len(field.Arguments))
+	for _, argument := range field.Arguments {
+		value, err := argument.Value.Value(selector.variables)
+		if err != nil {
+			value = argument.Value.String()
+		}
+		arguments[argument.Name] = value
+	}
+	return field.Name + "(" + mustMarshal(arguments) + ")"
+}
+
+// read rebuilds the cached result of the query stored under key.
+func (cache *normalizedCache) read(key string) (Response, bool) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	query, ok := cache.queries[key]
+	if !ok {
+		return Response{}, false
+	}
+	if time.Now().After(query.expires) {
+		cache.removeQuery(key)
+		return Response{}, false
+	}
+
+	var data bytes.Buffer
+	if !cache.denormalize(&data, query.root, query.shape) {
+		return Response{}, false
+	}
+	body := []byte(`{"data":` + data.String() + `}`)
+	response, err := parseResponse(body)
+	return response, err == nil
+}
+
+// write stores the entities of data, the result of the selections, and, when key is not
+// empty, the normalized result. Entities referenced by no cached query are dropped, as are
+// the expired queries once per ttl.
+func (cache *normalizedCache) write(key string, data gjson.Result, selector fieldSelector, selections ast.SelectionSet) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	entities := make(map[string]bool)
+	root, shape := cache.normalize(data, selector, selections, entities)
+	if key == "" {
+		for entity := range entities {
+			if cache.references[entity] == 0 {
+				delete(cache.entities, entity)
+			}
+		}
+	} else {
+		for entity := range entities {
+			cache.references[entity]++
+		}
+		cache.removeQuery(key)
+		cache.queries[key] = normalizedQuery{
+			root:     root,
+			shape:    shape,
+			entities: entities,
+			expires:  time.Now().Add(cache.ttl),
+		}
+	}
+	cache.sweep()
+}
+
+// removeQuery removes the query stored under key, along with the entities no other cached
+// query references. It must be called with the lock held.
+func (cache *normalizedCache) removeQuery(key string) {
+	query, ok := cache.queries[key]
+	if !ok {
+		return
+	}
+	delete(cache.queries, key)
+	for entity := range query.entities {
+		cache.references[entity]--
+		if cache.references[entity] <= 0 {
+			delete(cache.references, entity)
+			delete(cache.entities, entity)
+		}
+	}
+}
+
+// sweep removes the expired queries, at most once per ttl. It must be called with the lock
+// held.
+func (cache *normalizedCache) sweep() {
+	now := time.Now()
+	if now.Before(cache.nextSweep) {
+		return
+	}
+	cache.nextSweep = now.Add(cache.ttl)
+	for key, query := range cache.queries {
+		if now.After(query.expires) {
+			cache.removeQuery(key)
+		}
+	}
+}
+
+// invalidate removes the query stored under key.
+func (cache *normalizedCache) invalidate(key string) {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.removeQuery(key)
+}
+
+// purge removes every query and entity.
+func (cache *normalizedCache) purge() {
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.entities = make(map[string]map[string]any)
+	cache.references = make(map[string]int)
+	cache.queries = make(map[string]normalizedQuery)
+}
+
+// normalize converts value, the result of the selections, to its normalized form and
+// returns it along with its shape, storing the entities it contains and recording their
+// keys in entities.
+func (cache *normalizedCache) normalize(value gjson.Result, selector fieldSelector, selections ast.SelectionSet, entities map[string]bool) (any, any) {
+	switch {
+	case value.IsArray():
+		items := value.Array()
+		normalized := make([]any, len(items))
+		shape := make(listShape, len(items))
+		for i, item := range items {
+			normalized[i], shape[i] = cache.normalize(item, selector, selections, entities)
+		}
+		return normalized, shape
+	case value.IsObject():
+		fields := selector.fields(selections)
+		object := make(map[string]any)
+		var shape objectShape
+		value.ForEach(func(key, item gjson.Result) bool {
+			field := shapeField{key: key.String(), name: key.String()}
+			var nested ast.SelectionSet
+			selected, ok := fields[field.key]
+			if ok {
+				field.name = selected.name
+				nested = selected.selections
+			}
+			object[field.name], field.shape = cache.normalize(item, selector, nested, entities)
+			shape = append(shape, field)
+			return true
+		})
+
+		typename, _ := object["__typename"].(json.RawMessage)
+		id, _ := object["id"].(json.RawMessage)
+		if typename == nil || id == nil || gjson.ParseBytes(id).Type == gjson.Null {
+			return object, shape
+		}
+		key := entityKey(gjson.ParseBytes(typename).String(), gjson.ParseBytes(id))
+		entity, ok := cache.entities[key]
+		if !ok {
+			entity = make(map[string]any)
+			cache.entities[key] = entity
+		}
+		for name, item := range object {
+			entity[name] = item
+		}
+		entities[key] = true
+		return entityRef{key: key}, shape
+	default:
+		return json.RawMessage(value.Raw), nil
+	}
+}
+
+// denormalize writes the JSON encoding of node according to its shape, resolving entity
+// references. It reports false when a referenced entity or one of its fields is missing, or
+// when a list doesn't have the length of the cached result.
+func (cache *normalizedCache) denormalize(buf *bytes.Buffer, node any, shape any) bool {
+	switch n := node.(type) {
+	case []any:
+		items, ok := shape.(listShape)
+		if !ok || len(items) != len(n) {
+			return false
+		}
+		buf.WriteByte('[')
+		for i, item := range n {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if !cache.denormalize(buf, item, items[i]) {
+				return false
+			}
+		}
+		buf.WriteByte(']')
+	case map[string]any:
+		return cache.writeObject(buf, n, shape)
+	case entityRef:
+		entity, ok := cache.entities[n.key]
+		if !ok {
+			return false
+		}
+		return cache.writeObject(buf, entity, shape)
+	case json.RawMessage:
+		buf.Write(n)
+	}
+	return true
+}
+
+// writeObject writes the JSON object made of the fields of shape, whose values are stored in
+// values.
+func (cache *normalizedCache) writeObject(buf *bytes.Buffer, values map[string]any, shape any) bool {
+	fields, ok := shape.(objectShape)
+	if !ok {
+		return false
+	}
+	buf.WriteByte('{')
+	for i, field := range fields {
+		value, ok := values[field.name]
+		if !ok {
+			return false
+		}
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(mustMarshal(field.key))
+		buf.WriteByte(':')
+		if !cache.denormalize(buf, value, field.shape) {
+			return false
+		}
+	}
+	buf.WriteByte('}')
+	return true
+}
+
+// entityKey returns the key identifying an entity in the normalized cache.
+func entityKey(typename string, id gjson.Result) string {
+	return typename + ":" + id.String()
+}
+
+// mustMarshal returns the JSON encoding of a value that cannot fail to be encoded.
+func mustMarshal(value any) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
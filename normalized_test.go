@@ -0,0 +1,92 @@
+package ggql
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// postsServer answers the queries of the posts of a user with as many posts as requested by
+// the first argument, 1 or 3, under the response key "mine" when aliased so.
+func postsServer(t *testing.T, hits *atomic.Int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		body, _ := io.ReadAll(r.Body)
+		count := 3
+		if strings.Contains(string(body), "first: 1") {
+			count = 1
+		}
+		posts := make([]string, count)
+		for i := range posts {
+			posts[i] = fmt.Sprintf(`{"__typename":"Post","id":"%d","title":"Post %d"}`, i, i)
+		}
+		key := "posts"
+		if strings.Contains(string(body), "mine:") {
+			key = "mine"
+		}
+		fmt.Fprintf(w, `{"data":{"user":{"__typename":"User","id":"1","%s":[%s]}}}`, key, strings.Join(posts, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNormalizedCacheKeysFieldsOnArguments(t *testing.T) {
+	var hits atomic.Int32
+	client := NewClient(postsServer(t, &hits).URL).WithNormalizedCache(time.Minute)
+	three := client.NewRequest().Query(`{ user(id: 1) { __typename id posts(first: 3) { __typename id title } } }`)
+	one := client.NewRequest().Query(`{ user(id: 1) { __typename id posts(first: 1) { __typename id title } } }`)
+	aliased := client.NewRequest().Query(`{ user(id: 1) { __typename id mine: posts(first: 3) { __typename id title } } }`)
+
+	for _, test := range []struct {
+		request Request
+		path    string
+		count   int
+		hits    int32
+	}{
+		{three, "user.posts", 3, 1},
+		{one, "user.posts", 1, 2},
+		{three, "user.posts", 3, 2},
+		{one, "user.posts", 1, 2},
+		{aliased, "user.mine", 3, 3},
+		{aliased, "user.mine", 3, 3},
+	} {
+		response, err := test.request.ExecuteResponse(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		count := len(response.Data.Get(test.path).Array())
+		if count != test.count {
+			t.Errorf("%s: got %d posts, want %d", test.path, count, test.count)
+		}
+		if hits.Load() != test.hits {
+			t.Errorf("%s: got %d server hits, want %d", test.path, hits.Load(), test.hits)
+		}
+	}
+}
+
+func TestNormalizedCacheDropsUnreferencedEntities(t *testing.T) {
+	var hits atomic.Int32
+	client := NewClient(postsServer(t, &hits).URL).WithNormalizedCache(10 * time.Millisecond)
+	_, err := client.NewRequest().Query(`{ user(id: 1) { __typename id posts(first: 3) { __typename id title } } }`).ExecuteResponse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.normalized.entities) != 4 {
+		t.Fatalf("got %d entities, want 4", len(client.normalized.entities))
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	_, err = client.NewRequest().Query(`mutation { user: touch { __typename id } }`).ExecuteResponse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.normalized.queries) != 0 || len(client.normalized.entities) != 0 {
+		t.Errorf("got %d queries and %d entities after expiry, want none", len(client.normalized.queries), len(client.normalized.entities))
+	}
+}
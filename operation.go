@@ -0,0 +1,105 @@
+package ggql
+
+import (
+	"strings"
+	"unicode"
+)
+
+// operationType returns the type ("query", "mutation" or "subscription") of the operation
+// selected by name in the document, or of its first operation when name is empty.
+// Documents using the query shorthand are reported as queries. The document is scanned
+// lexically, ignoring comments, strings and selection sets, so it does not need to be valid.
+func operationType(document, name string) string {
+	kind, operationName := "", ""
+	expectName := false
+	depth, parens := 0, 0
+	for i := 0; i < len(document); {
+		char := document[i]
+		switch {
+		case char == '#':
+			for i < len(document) && document[i] != '\n' {
+				i++
+			}
+		case char == '"':
+			i = skipString(document, i)
+		case char == '(':
+			expectName = false
+			parens++
+			i++
+		case char == ')':
+			parens--
+			i++
+		case char == '{' && parens == 0:
+			if depth == 0 && kind != "fragment" {
+				if kind == "" {
+					kind = "query"
+				}
+				if name == "" || operationName == name {
+					return kind
+				}
+			}
+			if depth == 0 {
+				kind, operationName, expectName = "", "", false
+			}
+			depth++
+			i++
+		case char == '}' && parens == 0:
+			depth--
+			i++
+		case isNameStart(char):
+			start := i
+			for i < len(document) && isNameContinue(document[i]) {
+				i++
+			}
+			if depth != 0 || parens != 0 {
+				continue
+			}
+			word := document[start:i]
+			switch {
+			case kind == "" && (word == "query" || word == "mutation" || word == "subscription"):
+				kind, expectName = word, true
+			case kind == "" && word == "fragment":
+				kind = word
+			case expectName:
+				operationName, expectName = word, false
+			}
+		default:
+			if !unicode.IsSpace(rune(char)) && char != ',' {
+				expectName = false
+			}
+			i++
+		}
+	}
+	return "query"
+}
+
+// skipString returns the index following the string literal starting at index start,
+// handling both regular and block strings.
+func skipString(document string, start int) int {
+	if strings.HasPrefix(document[start:], `"""`) {
+		end := strings.Index(document[start+3:], `"""`)
+		if end < 0 {
+			return len(document)
+		}
+		return start + 3 + end + 3
+	}
+	for i := start + 1; i < len(document); i++ {
+		switch document[i] {
+		case '\\':
+			i++
+		case '"', '\n':
+			return i + 1
+		}
+	}
+	return len(document)
+}
+
+// isNameStart reports whether char can start a GraphQL name.
+func isNameStart(char byte) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+// isNameContinue reports whether char can appear in a GraphQL name after its first character.
+func isNameContinue(char byte) bool {
+	return isNameStart(char) || (char >= '0' && char <= '9')
+}
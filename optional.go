@@ -0,0 +1,119 @@
+package ggql
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Null is a variable value sent as an explicit null. It behaves like nil in variable maps,
+// but unlike nil it is kept by the omitempty option of VariablesFromStruct, so that a field
+// of type any can request the reset of a nullable input field.
+var Null any = nullValue{}
+
+// nullValue is the type of Null.
+type nullValue struct{}
+
+// MarshalJSON implements json.Marshaler.
+func (nullValue) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+// optional is implemented by Null and Optional, whose variables may be omitted from the
+// payload or replaced by another value.
+type optional interface {
+	omitted() bool
+	optionalValue() any
+}
+
+// omitted implements optional. Null is never omitted.
+func (nullValue) omitted() bool {
+	return false
+}
+
+// optionalValue implements optional. Null is sent as null.
+func (nullValue) optionalValue() any {
+	return nil
+}
+
+// Optional holds a value of a nullable input field that can be sent, sent as null or omitted
+// from the payload altogether. The zero value is omitted: variables and input fields holding
+// an unset Optional are left out of the request, while NullOptional sends an explicit null,
+// which servers usually interpret differently, e.g. clearing a field instead of leaving it
+// unchanged. Omission applies to the variables of a request, the maps they contain and the
+// structs converted by VariablesFromStruct; elsewhere, such as in slices or in structs
+// encoded by encoding/json, an unset Optional is encoded as null.
+type Optional[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Some returns an Optional holding value.
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, set: true}
+}
+
+// NullOptional returns an Optional sent as an explicit null.
+func NullOptional[T any]() Optional[T] {
+	return Optional[T]{set: true, null: true}
+}
+
+// IsSet reports whether the Optional holds a value or an explicit null.
+func (o Optional[T]) IsSet() bool {
+	return o.set
+}
+
+// IsNull reports whether the Optional holds an explicit null.
+func (o Optional[T]) IsNull() bool {
+	return o.null
+}
+
+// Get returns the value held by the Optional and whether it holds one.
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.set && !o.null
+}
+
+// MarshalJSON implements json.Marshaler. Unset and null Optionals are encoded as null.
+func (o Optional[T]) MarshalJSON() ([]byte, error) {
+	value, ok := o.Get()
+	if !ok {
+		return []byte("null"), nil
+	}
+	encoded, err := encodeScalars(value)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// UnmarshalJSON implements json.Unmarshaler, so that Optional can also tell apart null and
+// missing members when decoding responses: members absent from the data leave the Optional
+// unset.
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*o = NullOptional[T]()
+		return nil
+	}
+	var value T
+	err := json.Unmarshal(data, &value)
+	if err != nil {
+		return err
+	}
+	*o = Some(value)
+	return nil
+}
+
+// omitted implements optional. Unset Optionals are omitted.
+func (o Optional[T]) omitted() bool {
+	return !o.set
+}
+
+// optionalValue implements optional, returning the value held by the Optional, or nil for an
+// explicit null.
+func (o Optional[T]) optionalValue() any {
+	value, ok := o.Get()
+	if !ok {
+		return nil
+	}
+	return value
+}
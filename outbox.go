@@ -0,0 +1,412 @@
+package ggql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// OutboxEntry is a mutation queued in an Outbox. Variables hold the encoded JSON variables,
+// so that entries can be persisted and replayed as sent.
+type OutboxEntry struct {
+	ID            string            `json:"id"`
+	Endpoint      string            `json:"endpoint"`
+	Query         string            `json:"query"`
+	OperationName string            `json:"operationName,omitempty"`
+	Variables     json.RawMessage   `json:"variables,omitempty"`
+	Headers       map[string]string `json:"headers,omitempty"`
+	Queued        time.Time         `json:"queued"`
+}
+
+// OutboxStore stores the entries of an Outbox in the order they were appended.
+// Implementations must be safe for concurrent use.
+type OutboxStore interface {
+	Append(entry OutboxEntry) error
+	Entries() ([]OutboxEntry, error)
+	Remove(id string) error
+}
+
+// OutboxAction tells an Outbox what to do with an entry whose replay was rejected.
+type OutboxAction int
+
+// Actions returned by Outbox.OnConflict.
+const (
+	// OutboxDrop removes the entry and goes on with the next one.
+	OutboxDrop OutboxAction = iota
+	// OutboxKeep keeps the entry at the head of the queue and stops the replay.
+	OutboxKeep
+)
+
+// Outbox queues the mutations issued while their endpoint is unreachable and replays them
+// in order once it is reachable again. Mutations submitted while the queue is not empty
+// are queued as well, so that they are always applied in the order they were submitted.
+type Outbox struct {
+	client *Client
+	store  OutboxStore
+	mu     sync.Mutex
+
+	// OnReplay, if set, is called with the response of every replayed entry.
+	OnReplay func(entry OutboxEntry, response Response)
+
+	// OnConflict, if set, decides what to do with an entry whose replay reached the endpoint
+	// but failed, e.g. with GraphQL errors or a 4xx HTTP status. By default such entries are
+	// dropped.
+	OnConflict func(entry OutboxEntry, response Response, err error) OutboxAction
+}
+
+// NewOutbox returns an Outbox sending its mutations through client, and storing the queued
+// ones in store, such as NewMemoryOutboxStore or NewFileOutboxStore.
+func NewOutbox(client *Client, store OutboxStore) *Outbox {
+	return &Outbox{client: client, store: store}
+}
+
+// Submit sends the mutation, or queues it when the queue is not empty or the endpoint is
+// unreachable: when the request fails with a transport error, a timeout, an open circuit or
+// a 502, 503 or 504 HTTP status. queued reports whether the mutation was queued, in which
+// case the returned response is empty. Requests carrying uploads cannot be queued.
+func (outbox *Outbox) Submit(ctx context.Context, request Request) (response Response, queued bool, err error) {
+	if operationType(request.Request, request.operationName) != "mutation" {
+		return Response{}, false, errors.New("only mutations can be submitted to an outbox")
+	}
+	if _, uploads := extractUploads(request.Variables); len(uploads) > 0 {
+		return Response{}, false, errors.New("requests carrying uploads cannot be submitted to an outbox")
+	}
+	request.client = outbox.client
+
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+	entries, err := outbox.store.Entries()
+	if err != nil {
+		return Response{}, false, fmt.Errorf("reading outbox: %w", err)
+	}
+	if len(entries) == 0 {
+		response, err = request.ExecuteResponse(ctx)
+		if !isUnreachable(response, err) {
+			return response, false, err
+		}
+	}
+
+	entry, err := newOutboxEntry(request)
+	if err != nil {
+		return Response{}, false, err
+	}
+	err = outbox.store.Append(entry)
+	if err != nil {
+		return Response{}, false, fmt.Errorf("queuing mutation: %w", err)
+	}
+	return Response{}, true, nil
+}
+
+// Len returns the number of queued mutations.
+func (outbox *Outbox) Len() (int, error) {
+	entries, err := outbox.store.Entries()
+	return len(entries), err
+}
+
+// Flush replays the queued mutations in order. It stops at the first entry whose endpoint
+// is still unreachable, returning nil, or at the first entry kept by OnConflict.
+func (outbox *Outbox) Flush(ctx context.Context) error {
+	outbox.mu.Lock()
+	defer outbox.mu.Unlock()
+	entries, err := outbox.store.Entries()
+	if err != nil {
+		return fmt.Errorf("reading outbox: %w", err)
+	}
+
+	for _, entry := range entries {
+		request, err := entry.request(outbox.client)
+		if err != nil {
+			return err
+		}
+		response, err := request.ExecuteResponse(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if isUnreachable(response, err) {
+			return nil
+		}
+
+		if err != nil || response.HasErrors() {
+			if outbox.OnConflict != nil && outbox.OnConflict(entry, response, err) == OutboxKeep {
+				return nil
+			}
+		} else if outbox.OnReplay != nil {
+			outbox.OnReplay(entry, response)
+		}
+		err = outbox.store.Remove(entry.ID)
+		if err != nil {
+			return fmt.Errorf("removing replayed mutation: %w", err)
+		}
+	}
+	return nil
+}
+
+// Run flushes the outbox every interval until ctx is cancelled. Errors returned by Flush
+// are passed to onError when it is not nil.
+func (outbox *Outbox) Run(ctx context.Context, interval time.Duration, onError func(error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			err := outbox.Flush(ctx)
+			if err != nil && onError != nil && ctx.Err() == nil {
+				onError(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// isUnreachable reports whether the outcome of a request shows that its endpoint could not
+// process it.
+func isUnreachable(response Response, err error) bool {
+	var httpStatus *ErrHTTPStatus
+	if errors.As(err, &httpStatus) {
+		switch httpStatus.Code {
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	switch ErrorClass(err) {
+	case ErrorClassTransport, ErrorClassTimeout, ErrorClassCircuit:
+		return true
+	default:
+		return false
+	}
+}
+
+// newOutboxEntry captures the request in an OutboxEntry with a new random ID.
+func newOutboxEntry(request Request) (OutboxEntry, error) {
+	id := make([]byte, 16)
+	_, err := rand.Read(id)
+	if err != nil {
+		return OutboxEntry{}, fmt.Errorf("generating outbox entry ID: %w", err)
+	}
+	variables, err := json.Marshal(variables(request.Variables))
+	if err != nil {
+		return OutboxEntry{}, fmt.Errorf("encoding variables: %w", err)
+	}
+	return OutboxEntry{
+		ID:            hex.EncodeToString(id),
+		Endpoint:      request.Endpoint,
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     variables,
+		Headers:       copyHeaders(request.Headers, 0),
+		Queued:        time.Now(),
+	}, nil
+}
+
+// request rebuilds the request of the entry, bound to client.
+func (entry OutboxEntry) request(client *Client) (Request, error) {
+	request := client.NewRequest()
+	request.Endpoint = entry.Endpoint
+	request.Request = entry.Query
+	request.operationName = entry.OperationName
+	request.Headers = copyHeaders(entry.Headers, 0)
+	if len(entry.Variables) > 0 {
+		// Numbers are kept as json.Number, so that integers beyond 2^53, such as IDs, are
+		// sent back exactly.
+		decoder := json.NewDecoder(bytes.NewReader(entry.Variables))
+		decoder.UseNumber()
+		err := decoder.Decode(&request.Variables)
+		if err != nil {
+			return Request{}, fmt.Errorf("decoding variables of outbox entry %s: %w", entry.ID, err)
+		}
+	}
+	if request.Variables == nil {
+		request.Variables = make(map[string]any)
+	}
+	return request, nil
+}
+
+// memoryOutboxStore is the OutboxStore returned by NewMemoryOutboxStore.
+type memoryOutboxStore struct {
+	mu      sync.Mutex
+	entries []OutboxEntry
+}
+
+// NewMemoryOutboxStore returns an OutboxStore keeping the entries in memory. Queued
+// mutations are lost when the process exits.
+func NewMemoryOutboxStore() OutboxStore {
+	return &memoryOutboxStore{}
+}
+
+// Append implements OutboxStore.
+func (store *memoryOutboxStore) Append(entry OutboxEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	store.entries = append(store.entries, entry)
+	return nil
+}
+
+// Entries implements OutboxStore.
+func (store *memoryOutboxStore) Entries() ([]OutboxEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return append([]OutboxEntry(nil), store.entries...), nil
+}
+
+// Remove implements OutboxStore.
+func (store *memoryOutboxStore) Remove(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for i, entry := range store.entries {
+		if entry.ID == id {
+			store.entries = append(store.entries[:i:i], store.entries[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+// fileOutboxStore is the OutboxStore returned by NewFileOutboxStore.
+type fileOutboxStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileOutboxStore returns an OutboxStore keeping the entries in the file at path, one
+// JSON object per line, so that queued mutations survive restarts. The file is created if
+// it does not exist.
+func NewFileOutboxStore(path string) (OutboxStore, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	_ = file.Close()
+	return &fileOutboxStore{path: path}, nil
+}
+
+// Append implements OutboxStore.
+func (store *fileOutboxStore) Append(entry OutboxEntry) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(store.path, os.O_APPEND|os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return err
+	}
+	err = repairOutboxFile(file)
+	if err == nil {
+		_, err = file.Write(append(line, '\n'))
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	return errors.Join(err, file.Close())
+}
+
+// repairOutboxFile ends the file with a complete line before an entry is appended to it. The
+// partial line left by an Append interrupted by a crash is truncated, or terminated when it
+// holds a whole entry.
+func repairOutboxFile(file *os.File) error {
+	info, err := file.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	_, err = file.ReadAt(last, info.Size()-1)
+	if err != nil || last[0] == '\n' {
+		return err
+	}
+
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, info.Size()))
+	if err != nil {
+		return err
+	}
+	start := bytes.LastIndexByte(data, '\n') + 1
+	if json.Valid(data[start:]) {
+		_, err = file.Write([]byte("\n"))
+		return err
+	}
+	return file.Truncate(int64(start))
+}
+
+// Entries implements OutboxStore.
+func (store *fileOutboxStore) Entries() ([]OutboxEntry, error) {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.read()
+}
+
+// Remove implements OutboxStore. The file is rewritten atomically without the entry.
+func (store *fileOutboxStore) Remove(id string) error {
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	entries, err := store.read()
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(store.path), filepath.Base(store.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func(name string) {
+		_ = os.Remove(name)
+	}(temp.Name())
+	writer := bufio.NewWriter(temp)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range entries {
+		if entry.ID == id {
+			continue
+		}
+		err = encoder.Encode(entry)
+		if err != nil {
+			_ = temp.Close()
+			return err
+		}
+	}
+	err = writer.Flush()
+	if err == nil {
+		err = temp.Sync()
+	}
+	err = errors.Join(err, temp.Close())
+	if err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), store.path)
+}
+
+// read decodes the entries of the file. A partial last line, left by an Append interrupted
+// by a crash, is skipped.
+func (store *fileOutboxStore) read() ([]OutboxEntry, error) {
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []OutboxEntry
+	for len(data) > 0 {
+		line, rest, complete := bytes.Cut(data, []byte("\n"))
+		data = rest
+		if len(bytes.TrimSpace(line)) == 0 || (!complete && !json.Valid(line)) {
+			continue
+		}
+		var entry OutboxEntry
+		err = json.Unmarshal(line, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("decoding outbox file: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
@@ -0,0 +1,74 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOutboxReplaysLargeIntegersExactly(t *testing.T) {
+	var received string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		_, _ = w.Write([]byte(`{"data":{"delete":true}}`))
+	}))
+	defer server.Close()
+
+	store := NewMemoryOutboxStore()
+	err := store.Append(OutboxEntry{
+		ID:        "1",
+		Endpoint:  server.URL,
+		Query:     `mutation ($id: ID!) { delete(id: $id) }`,
+		Variables: json.RawMessage(`{"id":9007199254740993}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = NewOutbox(NewClient(server.URL), store).Flush(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(received, `"id":9007199254740993`) {
+		t.Errorf("server received %s, want the exact ID", received)
+	}
+}
+
+func TestFileOutboxStoreSkipsPartialLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox.jsonl")
+	store, err := NewFileOutboxStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Append(OutboxEntry{ID: "1", Query: `mutation { a }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a crash in the middle of an Append.
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = file.WriteString(`{"id":"2","query":"mut`)
+	_ = file.Close()
+
+	entries, err := store.Entries()
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("got %d entries and error %v, want 1 entry", len(entries), err)
+	}
+	err = store.Append(OutboxEntry{ID: "3", Query: `mutation { c }`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err = store.Entries()
+	if err != nil || len(entries) != 2 || entries[1].ID != "3" {
+		t.Fatalf("got entries %v and error %v, want entries 1 and 3", entries, err)
+	}
+}
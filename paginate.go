@@ -0,0 +1,128 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+)
+
+// Page represents one page of a Relay connection returned while paginating.
+// Nodes holds the "node" of every edge, or the members of the connection's "nodes"
+// field when the server does not return edges.
+type Page struct {
+	Response    Response
+	Connection  gjson.Result
+	Edges       []gjson.Result
+	Nodes       []gjson.Result
+	EndCursor   string
+	HasNextPage bool
+}
+
+// Paginator iterates over the pages of a Relay cursor connection by executing a request
+// repeatedly, passing the end cursor of each page as the cursor variable of the next one.
+// It follows the bufio.Scanner style: call Next until it returns false, read the current
+// page with Page, then check Err.
+type Paginator struct {
+	request        Request
+	path           string
+	cursorVariable string
+
+	page Page
+	done bool
+	err  error
+}
+
+// Paginate returns a Paginator for the connection found at path in the response body,
+// e.g. "data.repository.issues". The connection must expose pageInfo.endCursor and
+// pageInfo.hasNextPage, and the query must declare cursorVariable, typically "after".
+// If the request already sets cursorVariable, pagination starts from that cursor.
+func Paginate(request Request, path, cursorVariable string) *Paginator {
+	return &Paginator{
+		request:        request,
+		path:           path,
+		cursorVariable: cursorVariable,
+	}
+}
+
+// Next fetches the next page. It returns false once the last page has been consumed,
+// or when a request fails, in which case Err returns the error.
+func (paginator *Paginator) Next(ctx context.Context) bool {
+	if paginator.done {
+		return false
+	}
+
+	response, err := paginator.request.do(ctx)
+	if err != nil {
+		paginator.fail(err)
+		return false
+	}
+	if err = response.Err(); err != nil && !response.Data.Exists() {
+		paginator.fail(err)
+		return false
+	}
+
+	connection := response.Raw.Get(paginator.path)
+	if !connection.Exists() {
+		paginator.fail(&ErrDecode{Err: fmt.Errorf("no connection at path %q", paginator.path)})
+		return false
+	}
+
+	page := Page{
+		Response:    response,
+		Connection:  connection,
+		Edges:       connection.Get("edges").Array(),
+		EndCursor:   connection.Get("pageInfo.endCursor").String(),
+		HasNextPage: connection.Get("pageInfo.hasNextPage").Bool(),
+	}
+	if len(page.Edges) > 0 {
+		page.Nodes = make([]gjson.Result, len(page.Edges))
+		for i, edge := range page.Edges {
+			page.Nodes[i] = edge.Get("node")
+		}
+	} else {
+		page.Nodes = connection.Get("nodes").Array()
+	}
+	paginator.page = page
+
+	if !page.HasNextPage {
+		paginator.done = true
+		return true
+	}
+	if page.EndCursor == "" {
+		paginator.fail(&ErrDecode{Err: errors.New("connection has a next page but no end cursor")})
+		return true
+	}
+
+	paginator.request = paginator.request.AddVariable(paginator.cursorVariable, page.EndCursor)
+	return true
+}
+
+// Page returns the page fetched by the last call to Next.
+func (paginator *Paginator) Page() Page {
+	return paginator.page
+}
+
+// Err returns the error that stopped the pagination, if any.
+func (paginator *Paginator) Err() error {
+	return paginator.err
+}
+
+// All fetches every remaining page and returns the nodes of all pages flattened in order.
+func (paginator *Paginator) All(ctx context.Context) mo.Result[[]gjson.Result] {
+	var nodes []gjson.Result
+	for paginator.Next(ctx) {
+		nodes = append(nodes, paginator.page.Nodes...)
+	}
+	if paginator.err != nil {
+		return mo.Err[[]gjson.Result](paginator.err)
+	}
+	return mo.Ok(nodes)
+}
+
+// fail stops the pagination with the given error.
+func (paginator *Paginator) fail(err error) {
+	paginator.err = err
+	paginator.done = true
+}
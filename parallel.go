@@ -0,0 +1,117 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"sync"
+)
+
+// ParallelRequest represents several requests executed concurrently, each one in its own
+// HTTP call. Unlike BatchRequest, the requests may target different endpoints and clients.
+type ParallelRequest struct {
+	Requests []Request
+
+	concurrency int
+	failFast    bool
+}
+
+// Parallel groups the provided requests into a ParallelRequest. By default, every request
+// runs at once and all of them are executed even if some fail.
+func Parallel(requests ...Request) ParallelRequest {
+	return ParallelRequest{Requests: requests}
+}
+
+// All executes the requests concurrently and returns their results in the same order, see
+// ParallelRequest.Execute. Use Parallel to limit the concurrency or to fail fast.
+func All(ctx context.Context, requests ...Request) ([]gjson.Result, error) {
+	return Parallel(requests...).Execute(ctx)
+}
+
+// Concurrency limits the number of requests in flight at the same time. A zero or negative
+// limit runs every request at once. The modified ParallelRequest is returned.
+func (parallel ParallelRequest) Concurrency(limit int) ParallelRequest {
+	parallel.concurrency = limit
+	return parallel
+}
+
+// FailFast makes the execution stop at the first failed request: the requests in flight
+// are canceled, the pending ones are not sent, and only the first error is returned.
+// The modified ParallelRequest is returned.
+func (parallel ParallelRequest) FailFast() ParallelRequest {
+	parallel.failFast = true
+	return parallel
+}
+
+// Do executes the requests and returns their results in the same order as the requests.
+func (parallel ParallelRequest) Do() mo.Result[[]gjson.Result] {
+	return parallel.DoCtx(context.Background())
+}
+
+// DoCtx behaves like Do but binds the outgoing HTTP requests to the provided context.
+func (parallel ParallelRequest) DoCtx(ctx context.Context) mo.Result[[]gjson.Result] {
+	return mo.TupleToResult(parallel.Execute(ctx))
+}
+
+// Execute is the (value, error) counterpart of DoCtx. The results are always returned in
+// the same order as the requests, failed requests leaving an empty gjson.Result. Unless
+// FailFast is set, every request is executed and the returned error joins the errors of all
+// failed requests, each one prefixed with the index of its request.
+func (parallel ParallelRequest) Execute(ctx context.Context) ([]gjson.Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	limit := parallel.concurrency
+	if limit <= 0 || limit > len(parallel.Requests) {
+		limit = len(parallel.Requests)
+	}
+	slots := make(chan struct{}, limit)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+		results  = make([]gjson.Result, len(parallel.Requests))
+		errs     = make([]error, len(parallel.Requests))
+	)
+	for i, request := range parallel.Requests {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, request Request) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			result, err := request.Execute(ctx)
+			if err != nil {
+				errs[i] = fmt.Errorf("request %d: %w", i, err)
+				once.Do(func() {
+					firstErr = errs[i]
+					if parallel.failFast {
+						cancel()
+					}
+				})
+				return
+			}
+			results[i] = result
+		}(i, request)
+	}
+	wg.Wait()
+
+	switch {
+	case parallel.failFast && firstErr != nil:
+		return results, firstErr
+	case firstErr == nil && ctx.Err() != nil:
+		return results, ctx.Err()
+	default:
+		return results, errors.Join(errs...)
+	}
+}
@@ -0,0 +1,56 @@
+package ggql
+
+import (
+	"context"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+)
+
+// PartialResult holds both the data and the GraphQL errors of a response, for callers that
+// use whatever data the server could resolve while handling the errors of the other fields.
+// Partial reports whether the response holds data along with errors: the fields designated
+// by the paths of the errors are null (or missing) in Data, while the others were resolved.
+type PartialResult struct {
+	Data    gjson.Result
+	Errors  []GraphQLError
+	Partial bool
+}
+
+// Err returns the GraphQL errors of the result as an *ErrGraphQL, or nil if the result does
+// not contain any error.
+func (result PartialResult) Err() error {
+	if len(result.Errors) == 0 {
+		return nil
+	}
+	return &ErrGraphQL{Errors: result.Errors}
+}
+
+// Partial reports whether the response holds non-null data along with GraphQL errors, as
+// returned by servers that could resolve only part of the requested fields.
+func (response Response) Partial() bool {
+	return response.HasErrors() && response.Data.Exists() && response.Data.Type != gjson.Null
+}
+
+// DoPartial sends the request like DoResponse and returns its data along with its GraphQL
+// errors, so that partial data is not lost to the errors: the request does not fail when
+// the response holds data, even when FailOnGraphQLErrors is set. Responses holding errors
+// but no data fail with an *ErrGraphQL, as there is nothing to use.
+func (request Request) DoPartial(ctx context.Context) mo.Result[PartialResult] {
+	request.failOnErrors = false
+	response, err := request.do(ctx)
+	if err != nil {
+		return mo.Err[PartialResult](err)
+	}
+
+	result := PartialResult{Data: response.Data, Errors: response.Errors, Partial: response.Partial()}
+	if len(result.Errors) > 0 && !result.Partial {
+		return mo.Err[PartialResult](result.Err())
+	}
+	return mo.Ok(result)
+}
+
+// ExecutePartial is the (value, error) counterpart of DoPartial. Like Execute, it fails with
+// an ErrHTTPStatus when the endpoint answers with a non-2xx HTTP status.
+func (request Request) ExecutePartial(ctx context.Context) (PartialResult, error) {
+	return request.failingOnHTTPStatus().DoPartial(ctx).Get()
+}
@@ -0,0 +1,43 @@
+package ggql
+
+import (
+	"bytes"
+	"sync"
+	"unsafe"
+)
+
+// maxPooledBuffer is the capacity beyond which buffers are not returned to the pool, so that
+// an occasional large payload doesn't stay pinned in memory.
+const maxPooledBuffer = 1 << 20
+
+// buffers pools the buffers used to encode the request payloads and read the response
+// bodies, which saves growing a new buffer for every request.
+var buffers = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// getBuffer returns an empty buffer from the pool.
+func getBuffer() *bytes.Buffer {
+	return buffers.Get().(*bytes.Buffer)
+}
+
+// putBuffer resets buf and returns it to the pool. The content of buf must not be used
+// afterwards.
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	buf.Reset()
+	buffers.Put(buf)
+}
+
+// bytesString returns a string sharing its memory with b, which must not be modified
+// afterwards. It lets gjson results reference the response body without copying it.
+func bytesString(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return unsafe.String(unsafe.SliceData(b), len(b))
+}
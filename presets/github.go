@@ -0,0 +1,121 @@
+// Package presets provides ggql clients preconfigured for popular GraphQL APIs.
+//
+// Each preset returns a regular *ggql.Client that can be further customized, along with
+// helpers to read the API-specific metadata of the responses.
+package presets
+
+import (
+	"context"
+	"github.com/lance-free/ggql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GitHubEndpoint is the endpoint of the GitHub GraphQL API.
+const GitHubEndpoint = "https://api.github.com/graphql"
+
+// GitHubMaxRetryWait is the longest wait for a rate limit reset that GitHubRetry accepts.
+// Requests limited for longer fail immediately instead of blocking the caller.
+const GitHubMaxRetryWait = 2 * time.Minute
+
+// githubSecondaryWait is the wait recommended by GitHub after a secondary rate limit that
+// announces neither a Retry-After delay nor a reset time.
+const githubSecondaryWait = time.Minute
+
+// GitHubRateLimit is the rate limit status reported by the x-ratelimit-* headers of the
+// GitHub API responses.
+type GitHubRateLimit struct {
+	Limit     int
+	Remaining int
+	Used      int
+	Reset     time.Time
+	Resource  string
+}
+
+// GitHub returns a Client for the GitHub GraphQL API, authenticated with the given personal
+// access token or app token. Requests hitting the primary or secondary rate limits are
+// retried following GitHub's recommendations, see GitHubRetry.
+func GitHub(token string) *ggql.Client {
+	return ggql.NewClient(GitHubEndpoint).
+		WithBearerToken(func(context.Context) (string, error) {
+			return token, nil
+		}).
+		WithRetry(ggql.RetryPolicy{
+			MaxAttempts: 3,
+			MinBackoff:  time.Second,
+			MaxBackoff:  time.Minute,
+			Retry:       GitHubRetry,
+		})
+}
+
+// ParseGitHubRateLimit reads the rate limit status from the headers of a GitHub API
+// response, such as ggql.Response.Header. Missing headers leave their field zero.
+func ParseGitHubRateLimit(header http.Header) GitHubRateLimit {
+	limit := GitHubRateLimit{
+		Limit:     headerInt(header, "X-Ratelimit-Limit"),
+		Remaining: headerInt(header, "X-Ratelimit-Remaining"),
+		Used:      headerInt(header, "X-Ratelimit-Used"),
+		Resource:  header.Get("X-Ratelimit-Resource"),
+	}
+	if reset := headerInt(header, "X-Ratelimit-Reset"); reset > 0 {
+		limit.Reset = time.Unix(int64(reset), 0)
+	}
+	return limit
+}
+
+// GitHubRetry is the retry decision function of the GitHub preset. Secondary rate limits,
+// reported with a 403 or 429 status, are retried after the Retry-After delay, the reset of
+// the exhausted limit, or one minute otherwise. Primary rate limits, reported with a
+// RATE_LIMITED GraphQL error, are retried at the reset of the limit. Waits longer than
+// GitHubMaxRetryWait are not retried. Other failures follow ggql.DefaultRetry.
+func GitHubRetry(attempt int, response ggql.Response, err error) (bool, time.Duration) {
+	limit := ParseGitHubRateLimit(response.Header)
+	status := response.StatusCode
+
+	var delay time.Duration
+	switch {
+	case status == http.StatusForbidden || status == http.StatusTooManyRequests:
+		switch {
+		case ggql.RetryAfter(response.Header) > 0:
+			delay = ggql.RetryAfter(response.Header)
+		case response.Header.Get("X-Ratelimit-Remaining") == "0" && !limit.Reset.IsZero():
+			delay = time.Until(limit.Reset)
+		case status == http.StatusTooManyRequests || strings.Contains(strings.ToLower(string(response.Body)), "secondary rate limit"):
+			delay = githubSecondaryWait
+		default:
+			return false, 0
+		}
+	case githubRateLimited(response):
+		if limit.Reset.IsZero() {
+			return false, 0
+		}
+		delay = time.Until(limit.Reset)
+	default:
+		return ggql.DefaultRetry(attempt, response, err)
+	}
+
+	if delay > GitHubMaxRetryWait {
+		return false, 0
+	}
+	// A positive delay is required to override the backoff of the policy.
+	return true, max(delay, time.Second)
+}
+
+// githubRateLimited reports whether the response carries a RATE_LIMITED GraphQL error.
+// GitHub reports the kind of its errors in a top-level "type" member.
+func githubRateLimited(response ggql.Response) bool {
+	for _, kind := range response.Raw.Get("errors.#.type").Array() {
+		if kind.String() == "RATE_LIMITED" {
+			return true
+		}
+	}
+	return false
+}
+
+// headerInt returns the integer value of a header, or zero when it is missing or invalid.
+func headerInt(header http.Header, key string) int {
+	value, _ := strconv.Atoi(header.Get(key))
+	return value
+}
@@ -0,0 +1,96 @@
+package presets
+
+import (
+	"github.com/lance-free/ggql"
+	"strings"
+	"time"
+)
+
+// Headers understood by Hasura GraphQL Engine.
+const (
+	HasuraAdminSecretHeader = "x-hasura-admin-secret"
+	HasuraRoleHeader        = "x-hasura-role"
+	HasuraUserIDHeader      = "x-hasura-user-id"
+)
+
+// HasuraRetryableCodes lists the Hasura error codes, reported in the "code" extension of
+// GraphQL errors, whose requests are worth retrying: internal and database failures that
+// may be transient. Validation, permission and constraint errors are never retried.
+var HasuraRetryableCodes = []string{"unexpected", "postgres-error"}
+
+// HasuraOptions configures the Hasura preset.
+type HasuraOptions struct {
+	// AdminSecret authenticates the requests as admin. When set along with Role, the
+	// requests are executed with the permissions of the role instead.
+	AdminSecret string
+
+	// Role and UserID set the x-hasura-role and x-hasura-user-id session variables.
+	Role   string
+	UserID string
+
+	// SessionVariables sets additional session variables. The "x-hasura-" prefix is added
+	// to the names lacking it.
+	SessionVariables map[string]string
+
+	// MaxAttempts is the maximum number of attempts of a request, see ggql.RetryPolicy.
+	// It defaults to 3.
+	MaxAttempts int
+}
+
+// Hasura returns a Client for a Hasura GraphQL Engine endpoint, sending the admin secret and
+// session variables of options as headers. Failed requests are retried according to
+// HasuraRetry.
+func Hasura(endpoint string, options HasuraOptions) *ggql.Client {
+	client := ggql.NewClient(endpoint)
+	if options.AdminSecret != "" {
+		client.AddHeader(HasuraAdminSecretHeader, options.AdminSecret)
+	}
+	if options.Role != "" {
+		client.AddHeader(HasuraRoleHeader, options.Role)
+	}
+	if options.UserID != "" {
+		client.AddHeader(HasuraUserIDHeader, options.UserID)
+	}
+	for name, value := range options.SessionVariables {
+		if !strings.HasPrefix(strings.ToLower(name), "x-hasura-") {
+			name = "x-hasura-" + name
+		}
+		client.AddHeader(strings.ToLower(name), value)
+	}
+
+	maxAttempts := options.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = 3
+	}
+	return client.WithRetry(ggql.RetryPolicy{
+		MaxAttempts: maxAttempts,
+		Retry:       HasuraRetry,
+	})
+}
+
+// HasuraErrorCode returns the Hasura error code of the first GraphQL error of the response,
+// or an empty string when the response has no error code.
+func HasuraErrorCode(response ggql.Response) string {
+	for _, err := range response.Errors {
+		if code, ok := err.Extensions["code"].(string); ok {
+			return code
+		}
+	}
+	return ""
+}
+
+// HasuraRetry is the retry decision function of the Hasura preset. Responses whose GraphQL
+// errors carry one of HasuraRetryableCodes are retried; other GraphQL errors are not. Other
+// failures follow ggql.DefaultRetry.
+func HasuraRetry(attempt int, response ggql.Response, err error) (bool, time.Duration) {
+	if !response.HasErrors() {
+		return ggql.DefaultRetry(attempt, response, err)
+	}
+	code := HasuraErrorCode(response)
+	for _, retryable := range HasuraRetryableCodes {
+		if code == retryable {
+			return true, 0
+		}
+	}
+	return false, 0
+}
@@ -0,0 +1,142 @@
+package presets
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/lance-free/ggql"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShopifyAccessTokenHeader is the header authenticating requests to the Shopify Admin API.
+const ShopifyAccessTokenHeader = "X-Shopify-Access-Token"
+
+// shopifyDefaultCost is the cost assumed for operations whose cost is not known yet.
+const shopifyDefaultCost = 50
+
+// ShopifyCost is the query cost reported in the "cost" extension of the Shopify Admin API
+// responses, along with the status of the leaky bucket throttling the requests.
+type ShopifyCost struct {
+	RequestedQueryCost float64 `json:"requestedQueryCost"`
+	ActualQueryCost    float64 `json:"actualQueryCost"`
+	ThrottleStatus     struct {
+		MaximumAvailable   float64 `json:"maximumAvailable"`
+		CurrentlyAvailable float64 `json:"currentlyAvailable"`
+		RestoreRate        float64 `json:"restoreRate"`
+	} `json:"throttleStatus"`
+}
+
+// ParseShopifyCost reads the query cost from the extensions of a Shopify Admin API response.
+// It reports false when the response has no valid cost extension.
+func ParseShopifyCost(response ggql.Response) (ShopifyCost, bool) {
+	var cost ShopifyCost
+	extension := response.Extensions.Get("cost")
+	if !extension.Exists() {
+		return cost, false
+	}
+	err := json.Unmarshal([]byte(extension.Raw), &cost)
+	if err != nil {
+		return cost, false
+	}
+	return cost, true
+}
+
+// Shopify returns a Client for the Shopify Admin GraphQL API of the given shop
+// ("my-shop" or "my-shop.myshopify.com"), pinned to the given API version such as "2024-07".
+// The client tracks the throttle status reported by every response and paces the requests so
+// that their estimated cost stays below the available points of the bucket. Requests
+// throttled anyway are retried once enough points have been restored.
+func Shopify(shop, version, accessToken string) *ggql.Client {
+	if !strings.Contains(shop, ".") {
+		shop += ".myshopify.com"
+	}
+	throttle := &shopifyThrottle{costs: make(map[string]float64)}
+	return ggql.NewClient("https://"+shop+"/admin/api/"+version+"/graphql.json").
+		AddHeader(ShopifyAccessTokenHeader, accessToken).
+		Use(throttle.middleware).
+		WithRetry(ggql.RetryPolicy{
+			MaxAttempts: 3,
+			Retry:       throttle.retry,
+		})
+}
+
+// shopifyThrottle estimates the points available in the bucket of a shop from the last
+// throttle status received, and the cost of the operations from their last execution.
+type shopifyThrottle struct {
+	mu          sync.Mutex
+	available   float64
+	maximum     float64
+	restoreRate float64
+	observed    time.Time
+	costs       map[string]float64
+}
+
+// middleware waits until the bucket holds enough points for the request, then records the
+// throttle status of the response.
+func (throttle *shopifyThrottle) middleware(next ggql.Handler) ggql.Handler {
+	return func(ctx context.Context, request ggql.Request) (ggql.Response, error) {
+		timer := time.NewTimer(throttle.wait(request.Request))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ggql.Response{}, ctx.Err()
+		}
+
+		response, err := next(ctx, request)
+		if cost, ok := ParseShopifyCost(response); ok {
+			throttle.observe(request.Request, cost)
+		}
+		return response, err
+	}
+}
+
+// wait returns the time needed for the bucket to hold the estimated cost of document.
+func (throttle *shopifyThrottle) wait(document string) time.Duration {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	if throttle.observed.IsZero() || throttle.restoreRate <= 0 {
+		return 0
+	}
+	cost, ok := throttle.costs[document]
+	if !ok {
+		cost = shopifyDefaultCost
+	}
+	cost = math.Min(cost, throttle.maximum)
+	available := math.Min(throttle.maximum, throttle.available+time.Since(throttle.observed).Seconds()*throttle.restoreRate)
+	if available >= cost {
+		return 0
+	}
+	return time.Duration((cost - available) / throttle.restoreRate * float64(time.Second))
+}
+
+// observe records the throttle status and the requested cost of document.
+func (throttle *shopifyThrottle) observe(document string, cost ShopifyCost) {
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	throttle.available = cost.ThrottleStatus.CurrentlyAvailable
+	throttle.maximum = cost.ThrottleStatus.MaximumAvailable
+	throttle.restoreRate = cost.ThrottleStatus.RestoreRate
+	throttle.observed = time.Now()
+	if cost.RequestedQueryCost > 0 {
+		throttle.costs[document] = cost.RequestedQueryCost
+	}
+}
+
+// retry retries the requests failing with a THROTTLED error once the bucket is expected to
+// hold their cost. Other failures follow ggql.DefaultRetry.
+func (throttle *shopifyThrottle) retry(attempt int, response ggql.Response, err error) (bool, time.Duration) {
+	for _, graphQLError := range response.Errors {
+		if graphQLError.Extensions["code"] == "THROTTLED" {
+			cost, ok := ParseShopifyCost(response)
+			if !ok || cost.ThrottleStatus.RestoreRate <= 0 {
+				return true, 0
+			}
+			missing := cost.RequestedQueryCost - cost.ThrottleStatus.CurrentlyAvailable
+			return true, time.Duration(math.Max(missing, 1) / cost.ThrottleStatus.RestoreRate * float64(time.Second))
+		}
+	}
+	return ggql.DefaultRetry(attempt, response, err)
+}
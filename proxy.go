@@ -0,0 +1,67 @@
+package ggql
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// WithProxy sends the requests of the client, and the WebSocket connections of its
+// subscriptions, through the proxy at proxyURL, whose scheme is http, https or socks5,
+// regardless of the proxy settings of the environment. Credentials can be given in the
+// user info of the URL. An empty URL makes the client connect directly. Like WithTLS, it
+// installs an *http.Client with a clone of the current transport. The updated Client is
+// returned, or an error if the URL is invalid.
+func (client *Client) WithProxy(proxyURL string) (*Client, error) {
+	if proxyURL == "" {
+		return client, client.withProxy(nil)
+	}
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return client, fmt.Errorf("parsing proxy URL: %w", err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return client, fmt.Errorf("unsupported proxy scheme %q", parsed.Scheme)
+	}
+	return client, client.withProxy(http.ProxyURL(parsed))
+}
+
+// WithProxyFromEnvironment makes the client use the proxies set by the HTTP_PROXY,
+// HTTPS_PROXY and NO_PROXY environment variables when enabled, which is the default of
+// http.DefaultTransport, or connect directly otherwise. The updated Client is returned.
+func (client *Client) WithProxyFromEnvironment(enabled bool) (*Client, error) {
+	if enabled {
+		return client, client.withProxy(http.ProxyFromEnvironment)
+	}
+	return client, client.withProxy(nil)
+}
+
+// withProxy installs the proxy function of the client's transport and WebSocket dialer.
+func (client *Client) withProxy(proxy func(*http.Request) (*url.URL, error)) error {
+	err := client.configureTransport(func(transport *http.Transport) {
+		transport.Proxy = proxy
+	})
+	if err != nil {
+		return err
+	}
+	if proxy == nil {
+		proxy = directProxy
+	}
+	client.proxy = proxy
+	return nil
+}
+
+// directProxy is the proxy function of clients connecting directly.
+func directProxy(*http.Request) (*url.URL, error) {
+	return nil, nil
+}
+
+// websocketProxy returns the proxy function of the WebSocket connections of the client.
+func (client *Client) websocketProxy() func(*http.Request) (*url.URL, error) {
+	if client == nil || client.proxy == nil {
+		return http.ProxyFromEnvironment
+	}
+	return client.proxy
+}
@@ -0,0 +1,64 @@
+package ggql
+
+import (
+	"context"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiter holds the token bucket shared by every request of a client.
+type rateLimiter struct {
+	limiter  *rate.Limiter
+	failFast bool
+}
+
+// WithRateLimit limits the requests sent by the client to rps per second on average, with
+// bursts of up to burst requests, using a token bucket. Requests exceeding the limit wait
+// for a token, or until their context is done. Responses served from the client's cache
+// don't consume tokens, and a batch takes a single token (see Batch). Subscriptions are not
+// limited. A zero or negative rps disables the limit. The updated Client is returned.
+func (client *Client) WithRateLimit(rps float64, burst int) *Client {
+	return client.withRateLimit(rps, burst, false)
+}
+
+// WithRateLimitNoWait behaves like WithRateLimit, but requests exceeding the limit fail
+// immediately with ErrRateLimited instead of waiting for a token. The updated Client is
+// returned.
+func (client *Client) WithRateLimitNoWait(rps float64, burst int) *Client {
+	return client.withRateLimit(rps, burst, true)
+}
+
+// withRateLimit installs the token bucket used by WithRateLimit and WithRateLimitNoWait.
+func (client *Client) withRateLimit(rps float64, burst int, failFast bool) *Client {
+	if rps <= 0 {
+		client.limiter = nil
+		return client
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	client.limiter = &rateLimiter{
+		limiter:  rate.NewLimiter(rate.Limit(rps), burst),
+		failFast: failFast,
+	}
+	return client
+}
+
+// middleware returns a Handler taking a token from the bucket before calling next.
+func (limiter *rateLimiter) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		if limiter.failFast {
+			reservation := limiter.limiter.Reserve()
+			if delay := reservation.Delay(); delay > 0 {
+				reservation.Cancel()
+				return Response{}, &ErrRateLimited{Delay: delay}
+			}
+			return next(ctx, request)
+		}
+
+		err := limiter.limiter.Wait(ctx)
+		if err != nil {
+			return Response{}, &ErrRateLimited{Err: err}
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,165 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+)
+
+// WithMaxResponseSize caps the size of the response bodies read by the client's requests.
+// Reading a body beyond size bytes, once decompressed, fails with ErrResponseTooLarge.
+// A zero or negative size removes the limit. The updated Client is returned.
+func (client *Client) WithMaxResponseSize(size int64) *Client {
+	client.maxResponseSize = size
+	return client
+}
+
+// WithMaxResponseSize caps the size of the response body of the request, overriding the
+// limit of the parent Client, see Client.WithMaxResponseSize. The modified Request is returned.
+func (request Request) WithMaxResponseSize(size int64) Request {
+	request.maxSize = size
+	return request
+}
+
+// resolveMaxResponseSize returns the response size limit applied to the request: its own,
+// or the parent Client's.
+func (request Request) resolveMaxResponseSize() int64 {
+	if request.maxSize > 0 {
+		return request.maxSize
+	}
+	if request.client != nil {
+		return request.client.maxResponseSize
+	}
+	return 0
+}
+
+// limitBody returns a reader failing with ErrResponseTooLarge once more than limit bytes
+// have been read from reader. A zero or negative limit returns reader unchanged.
+func limitBody(reader io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return reader
+	}
+	return &limitedBody{reader: reader, limit: limit, remaining: limit}
+}
+
+// limitedBody is the reader returned by limitBody.
+type limitedBody struct {
+	reader    io.Reader
+	limit     int64
+	remaining int64
+}
+
+// Read implements io.Reader.
+func (body *limitedBody) Read(p []byte) (int, error) {
+	if body.remaining < 0 {
+		return 0, &ErrResponseTooLarge{Limit: body.limit}
+	}
+	if int64(len(p)) > body.remaining+1 {
+		p = p[:body.remaining+1]
+	}
+	n, err := body.reader.Read(p)
+	body.remaining -= int64(n)
+	if body.remaining < 0 {
+		return n + int(body.remaining), &ErrResponseTooLarge{Limit: body.limit}
+	}
+	return n, err
+}
+
+// rawBody is the body returned by DoRaw. Closing it closes the response body and releases
+// the context of the request. The request is tracked by the lifecycle of the client until
+// the body is closed or exhausted, or its context is done.
+type rawBody struct {
+	reader  io.Reader
+	body    io.Closer
+	release func()
+}
+
+// Read implements io.Reader.
+func (raw *rawBody) Read(p []byte) (int, error) {
+	n, err := raw.reader.Read(p)
+	if errors.Is(err, io.EOF) {
+		raw.release()
+	}
+	return n, err
+}
+
+// Close implements io.Closer.
+func (raw *rawBody) Close() error {
+	defer raw.release()
+	return raw.body.Close()
+}
+
+// DoRaw sends the request and returns its response body without buffering nor parsing it,
+// for callers streaming very large responses, e.g. with a json.Decoder. The body is
+// decompressed and limited like the bodies read by Do; it must be closed by the caller.
+// The request does not go through the client's middleware chain, and the HTTP status of the
+// response is not checked. Until the body is closed or read to the end, the request counts
+// as in flight for Client.Close, which cancels it when it gives up waiting.
+func (request Request) DoRaw(ctx context.Context) (io.ReadCloser, error) {
+	if request.Request == "" {
+		return nil, errors.New("no query/mutation provided")
+	}
+	err := request.checkRequiredHeaders()
+	if err != nil {
+		return nil, err
+	}
+	request.Request = request.client.withFragments(request.Request)
+	err = request.checkAllowlist(request.Request)
+	if err != nil {
+		return nil, err
+	}
+	ctx, end, err := request.client.begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var cancel context.CancelFunc
+	if timeout := request.resolveTimeout(); timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cancel()
+			end()
+		})
+	}
+
+	req, err := request.newHTTPRequest(ctx, content{
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     request.Variables,
+	})
+	if err != nil {
+		release()
+		return nil, err
+	}
+	err = request.authorize(ctx, req.Header)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	err = request.sign(ctx, req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	acceptEncoding(req.Header)
+
+	res, err := request.resolveHTTPClient().Do(req)
+	if err != nil {
+		release()
+		return nil, &ErrTransport{Op: "sending request", Err: err}
+	}
+	body, err := decodeBody(res)
+	if err != nil {
+		release()
+		_ = res.Body.Close()
+		return nil, err
+	}
+	context.AfterFunc(ctx, release)
+	return &rawBody{reader: limitBody(body, request.resolveMaxResponseSize()), body: body, release: release}, nil
+}
@@ -0,0 +1,93 @@
+package ggql
+
+import (
+	"context"
+	"github.com/tidwall/gjson"
+	"time"
+)
+
+// ReconnectPolicy configures the reconnection of subscriptions whose WebSocket connection
+// fails, see Client.WithReconnect.
+type ReconnectPolicy struct {
+	// MaxAttempts is the maximum number of consecutive reconnection attempts, reset once a
+	// connection is acknowledged by the server. Zero allows unlimited attempts.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the randomized exponential backoff between attempts,
+	// like in RetryPolicy. They default to 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// OnConnected, if set, is called with the endpoint each time a connection is
+	// acknowledged by the server, including the first one.
+	OnConnected func(endpoint string)
+
+	// OnDisconnected, if set, is called with the endpoint and the cause of the disconnection
+	// each time an acknowledged connection ends. The error is nil when the connection ends
+	// because its subscriptions are completed or cancelled.
+	OnDisconnected func(endpoint string, err error)
+}
+
+// WithReconnect makes the subscriptions of the client reconnect automatically when their
+// connection fails, instead of reporting a transport error: the operation is subscribed
+// again on the new connection, with the variables returned by the hook set with
+// Request.Resume if any. GraphQL errors and completions sent by the server end the
+// subscription as usual. The updated Client is returned.
+func (client *Client) WithReconnect(policy ReconnectPolicy) *Client {
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = 500 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 30 * time.Second
+	}
+	client.reconnect = &policy
+	return client
+}
+
+// Resume sets the hook called before the subscription is resumed on a new connection, see
+// Client.WithReconnect. It receives a copy of the variables of the previous subscription and
+// the last result it delivered, which is the zero gjson.Result when none was, and returns the
+// variables of the new subscription, e.g. with a cursor set after the last event received.
+// The modified Request is returned.
+func (request Request) Resume(resume func(variables map[string]any, last gjson.Result) map[string]any) Request {
+	request.resume = resume
+	return request
+}
+
+// reconnectPolicy returns the reconnection policy of the parent client, or nil.
+func (request Request) reconnectPolicy() *ReconnectPolicy {
+	if request.client == nil {
+		return nil
+	}
+	return request.client.reconnect
+}
+
+// connected calls the OnConnected hook of the policy, if any.
+func (policy *ReconnectPolicy) connected(endpoint string) {
+	if policy != nil && policy.OnConnected != nil {
+		policy.OnConnected(endpoint)
+	}
+}
+
+// disconnected calls the OnDisconnected hook of the policy, if any.
+func (policy *ReconnectPolicy) disconnected(endpoint string, err error) {
+	if policy != nil && policy.OnDisconnected != nil {
+		policy.OnDisconnected(endpoint, err)
+	}
+}
+
+// wait sleeps for the backoff preceding the reconnection attempt, and reports whether the
+// attempt should be made.
+func (policy *ReconnectPolicy) wait(ctx context.Context, attempt int) bool {
+	if policy.MaxAttempts > 0 && attempt > policy.MaxAttempts {
+		return false
+	}
+	timer := time.NewTimer(jitteredBackoff(policy.MinBackoff, policy.MaxBackoff, attempt))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
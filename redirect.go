@@ -0,0 +1,63 @@
+package ggql
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultRedirectStrippedHeaders lists the headers removed from redirected requests when
+// RedirectPolicy.StripHeaders is nil.
+var DefaultRedirectStrippedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "X-Api-Key"}
+
+// RedirectPolicy controls how the client follows the HTTP redirects of the endpoint. The
+// zero value follows no redirect.
+type RedirectPolicy struct {
+	// MaxRedirects caps the number of redirects followed by a request.
+	MaxRedirects int
+
+	// AllowCrossHost allows redirects to another host than the one of the original request.
+	AllowCrossHost bool
+
+	// StripHeaders lists the headers, matched case-insensitively, removed from the redirected
+	// requests, whatever their host. Nil falls back to DefaultRedirectStrippedHeaders; an
+	// empty slice keeps every header, net/http still removing the sensitive ones when
+	// redirecting to another domain.
+	StripHeaders []string
+}
+
+// WithRedirectPolicy sets how the client follows the HTTP redirects of the endpoint, which
+// net/http otherwise follows up to 10 times, to any host and with most headers: following
+// a redirect with an Authorization header is risky for endpoints behind load balancers.
+// Redirects forbidden by the policy fail with an ErrRedirect. The policy replaces the
+// CheckRedirect function of the *http.Client used. The updated Client is returned.
+func (client *Client) WithRedirectPolicy(policy RedirectPolicy) *Client {
+	client.redirects = &policy
+	return client
+}
+
+// withRedirects returns httpClient, or a shallow copy of it following the redirect policy
+// of the client.
+func (client *Client) withRedirects(httpClient *http.Client) *http.Client {
+	if client == nil || client.redirects == nil {
+		return httpClient
+	}
+	policy := client.redirects
+	strip := nameSet(policy.StripHeaders, DefaultRedirectStrippedHeaders)
+	clone := *httpClient
+	clone.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		location := req.URL.Redacted()
+		if len(via) > policy.MaxRedirects {
+			return &ErrRedirect{Location: location, Reason: "too many redirects"}
+		}
+		if !policy.AllowCrossHost && !strings.EqualFold(req.URL.Host, via[0].URL.Host) {
+			return &ErrRedirect{Location: location, Reason: "cross-host redirect"}
+		}
+		for key := range req.Header {
+			if strip[strings.ToLower(key)] {
+				req.Header.Del(key)
+			}
+		}
+		return nil
+	}
+	return &clone
+}
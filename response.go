@@ -0,0 +1,97 @@
+package ggql
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"net/http"
+	"strings"
+)
+
+// Response represents a GraphQL response returned by an endpoint. Raw holds the whole
+// parsed response body, Data the "data" member and Errors the entries of the "errors" array.
+// Extensions holds the "extensions" member, where servers report metadata such as tracing
+// data, query cost or rate limit status.
+// The HTTP metadata of the exchange is exposed through StatusCode, Header and Body, which
+// gives access to rate-limit headers or request IDs sent by the server, and through Timings
+// when the request traces its timings (see Request.TraceTimings). Body shares its memory
+// with Raw, Data and Extensions and must not be modified.
+type Response struct {
+	Raw        gjson.Result
+	Data       gjson.Result
+	Errors     []GraphQLError
+	Extensions gjson.Result
+
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Timings    Timings
+
+	codec Codec
+}
+
+// GraphQLError represents a single entry of the "errors" array of a GraphQL response,
+// as described by the GraphQL specification.
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Location represents a position in the GraphQL document associated with an error.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error implements the error interface. The message is prefixed with the error's path
+// when the server reported one.
+func (err GraphQLError) Error() string {
+	if len(err.Path) == 0 {
+		return err.Message
+	}
+	segments := make([]string, len(err.Path))
+	for i, segment := range err.Path {
+		segments[i] = fmt.Sprint(segment)
+	}
+	return strings.Join(segments, ".") + ": " + err.Message
+}
+
+// HasErrors reports whether the response contains at least one GraphQL error.
+func (response Response) HasErrors() bool {
+	return len(response.Errors) > 0
+}
+
+// Err returns the GraphQL errors of the response as an *ErrGraphQL,
+// or nil if the response does not contain any error.
+func (response Response) Err() error {
+	if !response.HasErrors() {
+		return nil
+	}
+	return &ErrGraphQL{Errors: response.Errors}
+}
+
+// parseResponse parses a raw response body into a Response. It returns an *ErrDecode if the
+// "errors" member is present but does not match the shape defined by the specification.
+// The results of the Response reference body without copying it, so body must not be
+// modified afterwards.
+func parseResponse(body []byte) (Response, error) {
+	raw := gjson.Parse(bytesString(body))
+	response := Response{
+		Raw:        raw,
+		Data:       raw.Get("data"),
+		Extensions: raw.Get("extensions"),
+		Body:       body,
+	}
+
+	errs := raw.Get("errors")
+	if errs.Exists() && errs.IsArray() {
+		err := json.Unmarshal([]byte(errs.Raw), &response.Errors)
+		if err != nil {
+			return response, &ErrDecode{Err: fmt.Errorf("decoding errors: %w", err)}
+		}
+	}
+
+	return response, nil
+}
@@ -0,0 +1,183 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures the retries of failed requests, see Client.WithRetry.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts of a request, including the first one.
+	// A value lower than 2 disables the retries.
+	MaxAttempts int
+
+	// MinBackoff and MaxBackoff bound the exponential backoff between attempts. The delay
+	// before the attempt n+1 is drawn at random between zero and MinBackoff*2^(n-1), capped
+	// at MaxBackoff. They default to 100ms and 10s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// RetryMutations allows the retry of mutations, which may not be idempotent. Queries are
+	// always retried. See WithIdempotencyKeys to retry mutations safely. Requests uploading
+	// files are never retried, as their files can't be read again.
+	RetryMutations bool
+
+	// Retry decides whether the failed attempt should be retried, given its number starting
+	// at 1. Attempts are failed when they return an error, a non-2xx HTTP status or GraphQL
+	// errors. A positive delay overrides the backoff. It defaults to DefaultRetry.
+	Retry func(attempt int, response Response, err error) (retry bool, delay time.Duration)
+
+	// RetryCodes lists GraphQL error codes, reported in the "code" extension of the errors,
+	// whose responses are retried even when Retry declines them, as many servers report
+	// transient failures as GraphQL errors in 200 responses: e.g. "UNAVAILABLE", Hasura's
+	// "start-failed" or Shopify's "THROTTLED". Codes are matched exactly. The attempts they
+	// trigger wait for the Retry-After delay when present, or for the backoff.
+	RetryCodes []string
+}
+
+// DefaultRetry is the default decision function of a RetryPolicy. It retries transport
+// errors, timeouts of single attempts, and the 429, 502, 503 and 504 HTTP statuses, waiting
+// for the delay announced by the Retry-After header when present. GraphQL errors are not
+// retried, unless their code is listed by RetryPolicy.RetryCodes, nor are requests canceled
+// by the caller.
+func DefaultRetry(attempt int, response Response, err error) (bool, time.Duration) {
+	if errors.Is(err, context.Canceled) {
+		return false, 0
+	}
+
+	status := response.StatusCode
+	var httpStatus *ErrHTTPStatus
+	if errors.As(err, &httpStatus) {
+		status = httpStatus.Code
+	}
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, RetryAfter(response.Header)
+	}
+
+	switch ErrorClass(err) {
+	case ErrorClassTransport, ErrorClassTimeout:
+		return true, 0
+	default:
+		return false, 0
+	}
+}
+
+// RetryAfter returns the delay announced by the Retry-After header, given either in seconds
+// or as an HTTP date, or zero when the header is missing or invalid.
+func RetryAfter(header http.Header) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// WithRetry makes the client retry the failed requests according to policy. Each attempt
+// goes through the client's circuit breaker and rate limit, while cached responses are
+// served without attempts. The deadline set with WithTimeout bounds the whole sequence of
+// attempts. The updated Client is returned.
+func (client *Client) WithRetry(policy RetryPolicy) *Client {
+	client.retry = policy.normalized()
+	return client
+}
+
+// normalized returns a copy of the policy with its defaults applied, or nil when the policy
+// disables the retries.
+func (policy RetryPolicy) normalized() *RetryPolicy {
+	if policy.MaxAttempts < 2 {
+		return nil
+	}
+	if policy.MinBackoff <= 0 {
+		policy.MinBackoff = 100 * time.Millisecond
+	}
+	if policy.MaxBackoff <= 0 {
+		policy.MaxBackoff = 10 * time.Second
+	}
+	if policy.Retry == nil {
+		policy.Retry = DefaultRetry
+	}
+	return &policy
+}
+
+// middleware returns a Handler calling next until the attempt succeeds, the policy declines
+// to retry it, or the attempts are exhausted. The result of the last attempt is returned.
+func (policy *RetryPolicy) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		kind := operationType(request.Request, request.operationName)
+		if kind == "subscription" || (kind == "mutation" && !policy.RetryMutations) {
+			return next(ctx, request)
+		}
+		// The files of uploads are consumed by the first attempt: a retry would send them
+		// empty.
+		_, uploads := extractUploads(request.Variables)
+		if len(uploads) > 0 {
+			return next(ctx, request)
+		}
+
+		for attempt := 1; ; attempt++ {
+			response, err := next(ctx, request)
+			if attempt >= policy.MaxAttempts || (err == nil && response.StatusCode < 300 && !response.HasErrors()) {
+				return response, err
+			}
+			retry, delay := policy.Retry(attempt, response, err)
+			if !retry && policy.retryableCode(response) {
+				retry, delay = true, RetryAfter(response.Header)
+			}
+			if !retry {
+				return response, err
+			}
+			if delay <= 0 {
+				delay = policy.backoff(attempt)
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return response, err
+			}
+		}
+	}
+}
+
+// retryableCode reports whether a GraphQL error of the response carries one of the
+// policy's RetryCodes.
+func (policy *RetryPolicy) retryableCode(response Response) bool {
+	for _, graphQLError := range response.Errors {
+		code, ok := graphQLError.Extensions["code"].(string)
+		if ok && slices.Contains(policy.RetryCodes, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns the randomized exponential delay before the attempt following attempt.
+func (policy *RetryPolicy) backoff(attempt int) time.Duration {
+	return jitteredBackoff(policy.MinBackoff, policy.MaxBackoff, attempt)
+}
+
+// jitteredBackoff returns a delay drawn at random between zero and min*2^(attempt-1),
+// capped at max.
+func jitteredBackoff(min, max time.Duration, attempt int) time.Duration {
+	ceiling := max
+	if shift := attempt - 1; shift < 32 {
+		if exponential := min << shift; exponential > 0 && exponential < ceiling {
+			ceiling = exponential
+		}
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
@@ -0,0 +1,37 @@
+package ggql
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRetrySkipsUploads(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client := NewClient(server.URL).WithRetry(RetryPolicy{MaxAttempts: 3, RetryMutations: true, MinBackoff: 1, MaxBackoff: 1})
+
+	_, err := client.NewRequest().
+		Query(`mutation ($file: Upload!) { upload(file: $file) }`).
+		AddVariable("file", Upload{File: strings.NewReader("contents"), FileName: "a.txt"}).
+		ExecuteResponse(context.Background())
+	if err == nil {
+		t.Fatal("got no error from a failing upload")
+	}
+	if hits.Load() != 1 {
+		t.Errorf("upload sent %d times, want 1", hits.Load())
+	}
+
+	hits.Store(0)
+	_, _ = client.NewRequest().Query(`mutation { touch }`).ExecuteResponse(context.Background())
+	if hits.Load() != 3 {
+		t.Errorf("mutation sent %d times, want 3", hits.Load())
+	}
+}
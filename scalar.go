@@ -0,0 +1,141 @@
+package ggql
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// scalarMarshalers maps Go types to the function serializing them into the representation
+// expected by the server for the matching custom scalar.
+var scalarMarshalers = struct {
+	sync.RWMutex
+	byType map[reflect.Type]func(any) (any, error)
+}{byType: make(map[reflect.Type]func(any) (any, error))}
+
+func init() {
+	RegisterScalar(func(value time.Time) (any, error) {
+		return value.Format(time.RFC3339Nano), nil
+	})
+}
+
+// RegisterScalar registers the function used to serialize values of type T found in the
+// Variables of every request, replacing any function previously registered for T.
+// The returned value is encoded as JSON in place of the original one, which lets custom
+// scalars follow the server's expected format instead of the default JSON encoding of T.
+// Registered types are looked up in variable maps and slices, behind pointers and in
+// slices of T, but not inside struct fields.
+// time.Time is registered by default and serialized as an RFC 3339 string. Types implementing
+// encoding.TextMarshaler or json.Marshaler, such as UUID or decimal types, are already
+// encoded through those interfaces and only need to be registered to change their format.
+func RegisterScalar[T any](marshal func(T) (any, error)) {
+	scalarMarshalers.Lock()
+	defer scalarMarshalers.Unlock()
+	scalarMarshalers.byType[reflect.TypeOf((*T)(nil)).Elem()] = func(value any) (any, error) {
+		return marshal(value.(T))
+	}
+}
+
+// scalarMarshaler returns the function registered for the given type, if any.
+func scalarMarshaler(t reflect.Type) (func(any) (any, error), bool) {
+	scalarMarshalers.RLock()
+	defer scalarMarshalers.RUnlock()
+	marshal, ok := scalarMarshalers.byType[t]
+	return marshal, ok
+}
+
+// variables is the map of variables of a payload. It applies the registered scalar
+// marshalers when encoded as JSON.
+type variables map[string]any
+
+// MarshalJSON implements json.Marshaler.
+func (v variables) MarshalJSON() ([]byte, error) {
+	if v == nil {
+		return []byte("null"), nil
+	}
+	encoded, err := encodeScalars(map[string]any(v))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(encoded)
+}
+
+// encodeScalars returns a copy of value in which every value of a registered type has been
+// replaced by its serialized form, Null and Optional values by the value they hold, and
+// unset Optional values of maps are removed. Values without any registered type are
+// returned as is.
+func encodeScalars(value any) (any, error) {
+	if value == nil {
+		return nil, nil
+	}
+	if o, ok := value.(optional); ok {
+		return encodeScalars(o.optionalValue())
+	}
+	if marshal, ok := scalarMarshaler(reflect.TypeOf(value)); ok {
+		encoded, err := marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("encoding scalar %T: %w", value, err)
+		}
+		return encoded, nil
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		encoded := make(map[string]any, len(v))
+		for key, item := range v {
+			if o, ok := item.(optional); ok && o.omitted() {
+				continue
+			}
+			item, err := encodeScalars(item)
+			if err != nil {
+				return nil, err
+			}
+			encoded[key] = item
+		}
+		return encoded, nil
+	case []any:
+		encoded := make([]any, len(v))
+		for i, item := range v {
+			item, err := encodeScalars(item)
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = item
+		}
+		return encoded, nil
+	}
+
+	reflected := reflect.ValueOf(value)
+	switch reflected.Kind() {
+	case reflect.Pointer:
+		if reflected.IsNil() {
+			return value, nil
+		}
+		if _, ok := scalarMarshaler(reflected.Type().Elem()); ok {
+			return encodeScalars(reflected.Elem().Interface())
+		}
+	case reflect.Slice, reflect.Array:
+		element := reflected.Type().Elem()
+		if element.Kind() == reflect.Pointer {
+			element = element.Elem()
+		}
+		if _, ok := scalarMarshaler(element); !ok {
+			return value, nil
+		}
+		if reflected.Kind() == reflect.Slice && reflected.IsNil() {
+			return value, nil
+		}
+		encoded := make([]any, reflected.Len())
+		for i := range encoded {
+			item, err := encodeScalars(reflected.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			encoded[i] = item
+		}
+		return encoded, nil
+	}
+	return value, nil
+}
@@ -0,0 +1,77 @@
+package ggql
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// RequestSigner signs the HTTP requests sent by a client, e.g. by adding a signature header
+// required by an internal gateway. Sign is invoked once every other header is set, with the
+// final body of the request as sent on the wire, compressed if WithCompression applies, or
+// an empty body for GET requests.
+type RequestSigner interface {
+	Sign(ctx context.Context, req *http.Request, body []byte) error
+}
+
+// RequestSignerFunc is a function implementing RequestSigner.
+type RequestSignerFunc func(ctx context.Context, req *http.Request, body []byte) error
+
+// Sign implements RequestSigner.
+func (sign RequestSignerFunc) Sign(ctx context.Context, req *http.Request, body []byte) error {
+	return sign(ctx, req, body)
+}
+
+// HMACSigner returns a RequestSigner setting header to "sha256=" followed by the hex-encoded
+// HMAC-SHA256 of the request body computed with key, the format of signatures such as
+// X-Signature or X-Hub-Signature-256.
+func HMACSigner(header string, key []byte) RequestSigner {
+	return RequestSignerFunc(func(_ context.Context, req *http.Request, body []byte) error {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		req.Header.Set(header, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	})
+}
+
+// WithRequestSigner sets the RequestSigner invoked on every HTTP request sent by the client,
+// after the signature of WithSigV4, if any, so that the signer can cover its headers. Batches
+// are signed once. Subscriptions and requests executed by a custom Transport are not signed.
+// Passing nil removes the signer. The updated Client is returned.
+func (client *Client) WithRequestSigner(signer RequestSigner) *Client {
+	client.requestSigner = signer
+	return client
+}
+
+// signBody invokes the RequestSigner of the parent client, if any, with the body of req.
+func (request Request) signBody(ctx context.Context, req *http.Request) error {
+	if request.client == nil || request.client.requestSigner == nil {
+		return nil
+	}
+	body, err := requestBody(req)
+	if err != nil {
+		return fmt.Errorf("reading body to sign: %w", err)
+	}
+	err = request.client.requestSigner.Sign(ctx, req, body)
+	if err != nil {
+		return fmt.Errorf("signing request: %w", err)
+	}
+	return nil
+}
+
+// requestBody returns a copy of the body of req, read through GetBody so that req can still be
+// sent, or an empty body when req has none.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return []byte{}, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(body)
+}
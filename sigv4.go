@@ -0,0 +1,162 @@
+package ggql
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sigV4Algorithm identifies the AWS Signature Version 4 signing algorithm.
+const sigV4Algorithm = "AWS4-HMAC-SHA256"
+
+// AWSCredentials are the IAM credentials used to sign requests with AWS Signature Version 4.
+// SessionToken is only set for temporary credentials.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// AWSCredentialsProvider returns the credentials used to sign a request. It is invoked for
+// every request, so implementations can cache and refresh temporary credentials.
+type AWSCredentialsProvider func(ctx context.Context) (AWSCredentials, error)
+
+// StaticAWSCredentials returns an AWSCredentialsProvider always returning credentials.
+func StaticAWSCredentials(credentials AWSCredentials) AWSCredentialsProvider {
+	return func(context.Context) (AWSCredentials, error) {
+		return credentials, nil
+	}
+}
+
+// sigV4Signer signs requests with AWS Signature Version 4.
+type sigV4Signer struct {
+	service     string
+	region      string
+	credentials AWSCredentialsProvider
+}
+
+// WithSigV4 signs every request executed through the client with AWS Signature Version 4,
+// using the credentials returned by provider, so that the client can call AWS services
+// authorizing their callers with IAM, such as AWS AppSync (service "appsync").
+// The signature replaces any Authorization header set on the request. Subscriptions are not
+// signed. The updated Client is returned.
+func (client *Client) WithSigV4(service, region string, provider AWSCredentialsProvider) *Client {
+	client.signer = &sigV4Signer{service: service, region: region, credentials: provider}
+	return client
+}
+
+// sign signs req with the parent client's SigV4 signer, then its RequestSigner, if any. It
+// must be called once every signed header of req is set.
+func (request Request) sign(ctx context.Context, req *http.Request) error {
+	if request.client == nil {
+		return nil
+	}
+	if request.client.signer != nil {
+		credentials, err := request.client.signer.credentials(ctx)
+		if err != nil {
+			return fmt.Errorf("getting AWS credentials: %w", err)
+		}
+		err = request.client.signer.sign(req, credentials, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return request.signBody(ctx, req)
+}
+
+// sign adds the X-Amz-Date, X-Amz-Security-Token and Authorization headers to req.
+func (signer *sigV4Signer) sign(req *http.Request, credentials AWSCredentials, now time.Time) error {
+	payload, err := requestBody(req)
+	if err != nil {
+		return fmt.Errorf("reading body to sign: %w", err)
+	}
+	payloadHash := sha256.Sum256(payload)
+
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		headers["host"] = req.Host
+	}
+	for key, values := range req.Header {
+		name := strings.ToLower(key)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.Join(strings.Fields(strings.Join(values, ",")), " ")
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	scope := date + "/" + signer.region + "/" + signer.service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := sigV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	for _, part := range []string{signer.region, signer.service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		sigV4Algorithm, credentials.AccessKeyID, scope, signedHeaders, signature))
+	return nil
+}
+
+// canonicalQuery returns the query parameters sorted and encoded as required by
+// AWS Signature Version 4.
+func canonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, sigV4Escape(key)+"="+sigV4Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// sigV4Escape percent-encodes every byte but the unreserved characters of RFC 3986.
+func sigV4Escape(value string) string {
+	return strings.ReplaceAll(url.QueryEscape(value), "+", "%20")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
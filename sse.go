@@ -0,0 +1,158 @@
+package ggql
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"io"
+	"mime"
+	"strings"
+)
+
+// Event types defined by the GraphQL over Server-Sent Events protocol.
+const (
+	sseEventNext     = "next"
+	sseEventComplete = "complete"
+)
+
+// maxSSELine is the size of the longest line of an event stream, and so of the largest result.
+const maxSSELine = 16 << 20
+
+// UseSSE makes Subscribe stream the results of the subscription over Server-Sent Events
+// instead of a WebSocket, following the "distinct connections" mode of the GraphQL over SSE
+// protocol: the operation is sent in a POST request accepting text/event-stream, and the
+// endpoint answers with a stream of "next" events carrying the results, terminated by a
+// "complete" event. Every subscription gets its own HTTP request, which goes through the
+// authentication and signing of the client but not its middleware chain, and is closed
+// when the client is closed, see Client.Close. The modified Request is returned.
+func (request Request) UseSSE() Request {
+	request.sse = true
+	return request
+}
+
+// subscribeSSE runs a subscription over Server-Sent Events to completion, delivering the
+// execution results on results. Endpoints answering with a single JSON response, typically
+// to report request errors, have it delivered as the only result.
+func (request Request) subscribeSSE(ctx context.Context, query string, results chan<- gjson.Result) error {
+	ctx, end, err := request.client.beginSubscription(ctx)
+	if err != nil {
+		return err
+	}
+	defer end()
+
+	req, err := request.newHTTPRequest(ctx, content{
+		Query:         query,
+		OperationName: request.operationName,
+		Variables:     request.Variables,
+	})
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	err = request.authorize(ctx, req.Header)
+	if err != nil {
+		return err
+	}
+	err = request.sign(ctx, req)
+	if err != nil {
+		return err
+	}
+	acceptEncoding(req.Header)
+
+	res, err := request.resolveHTTPClient().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil
+		}
+		return &ErrTransport{Op: "sending request", Err: err}
+	}
+	defer res.Body.Close()
+	body, err := decodeBody(res)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != "text/event-stream" {
+		data, err := io.ReadAll(limitBody(body, request.resolveMaxResponseSize()))
+		if err != nil {
+			return &ErrTransport{Op: "reading response", Err: err}
+		}
+		if res.StatusCode < 200 || res.StatusCode >= 300 {
+			return &ErrHTTPStatus{Code: res.StatusCode, Body: data}
+		}
+		if !gjson.ValidBytes(data) {
+			return &ErrDecode{Err: fmt.Errorf("unexpected content type %q", res.Header.Get("Content-Type"))}
+		}
+		return deliver(ctx, results, gjson.ParseBytes(data))
+	}
+
+	err = readEvents(body, func(event string, data []byte) (bool, error) {
+		switch event {
+		case sseEventComplete:
+			return false, nil
+		case sseEventNext, "":
+			if !gjson.ValidBytes(data) {
+				return false, &ErrDecode{Err: fmt.Errorf("invalid event data %q", data)}
+			}
+			return true, deliver(ctx, results, gjson.ParseBytes(data))
+		}
+		return true, nil
+	})
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// deliver sends result on results, unless the context is cancelled first.
+func deliver(ctx context.Context, results chan<- gjson.Result, result gjson.Result) error {
+	select {
+	case results <- result:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// readEvents reads the Server-Sent Events of the stream and passes the type and data of
+// each one to handle, until handle returns false or an error, or the stream ends. Comments
+// and fields other than event and data are ignored.
+func readEvents(stream io.Reader, handle func(event string, data []byte) (bool, error)) error {
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxSSELine)
+	var event string
+	var data bytes.Buffer
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if data.Len() == 0 && event == "" {
+				continue
+			}
+			more, err := handle(event, bytes.TrimSuffix(data.Bytes(), []byte("\n")))
+			if err != nil || !more {
+				return err
+			}
+			event = ""
+			data.Reset()
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		}
+	}
+	err := scanner.Err()
+	if err != nil {
+		return &ErrTransport{Op: "reading events", Err: err}
+	}
+	return nil
+}
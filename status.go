@@ -0,0 +1,67 @@
+package ggql
+
+import "net/http"
+
+// statusPolicy tells whether a non-2xx HTTP status fails a request.
+type statusPolicy int8
+
+// Policies of a request regarding non-2xx HTTP statuses. The default policy defers to the
+// parent Client for Do, DoCtx and DoResponse, while the Execute family fails.
+const (
+	statusDefault statusPolicy = iota
+	statusFail
+	statusIgnore
+)
+
+// FailOnHTTPStatus makes Do, DoCtx and DoResponse fail with an ErrHTTPStatus when the
+// endpoint answers the client's requests with a non-2xx HTTP status. The Execute family
+// always does so unless IgnoreHTTPStatus is set on the request. The updated Client is
+// returned.
+func (client *Client) FailOnHTTPStatus() *Client {
+	client.failOnStatus = true
+	return client
+}
+
+// FailOnHTTPStatus makes the request fail with an ErrHTTPStatus, carrying the status code
+// and the response body, when the endpoint answers with a non-2xx HTTP status. It is the
+// default behavior of Execute, ExecuteResponse, ExecuteInto and ExecuteIncremental; Do,
+// DoCtx and DoResponse parse the body whatever the status unless this option or
+// Client.FailOnHTTPStatus is set. The modified Request is returned.
+func (request Request) FailOnHTTPStatus() Request {
+	request.status = statusFail
+	return request
+}
+
+// IgnoreHTTPStatus makes the request parse the response body whatever the HTTP status,
+// including with the Execute family. The modified Request is returned.
+func (request Request) IgnoreHTTPStatus() Request {
+	request.status = statusIgnore
+	return request
+}
+
+// failingOnHTTPStatus returns the request with the status policy of the Execute family:
+// non-2xx statuses fail unless the request ignores them explicitly.
+func (request Request) failingOnHTTPStatus() Request {
+	if request.status == statusDefault {
+		request.status = statusFail
+	}
+	return request
+}
+
+// checkHTTPStatus returns an ErrHTTPStatus when the response has a non-2xx status and the
+// request is configured to fail on such statuses.
+func (request Request) checkHTTPStatus(res *http.Response, body []byte) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	switch request.status {
+	case statusFail:
+	case statusDefault:
+		if request.client == nil || !request.client.failOnStatus {
+			return nil
+		}
+	default:
+		return nil
+	}
+	return &ErrHTTPStatus{Code: res.StatusCode, Body: body}
+}
@@ -0,0 +1,583 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/tidwall/gjson"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// subprotocol is the WebSocket subprotocol negotiated for subscriptions.
+const subprotocol = "graphql-transport-ws"
+
+// Message types defined by the graphql-transport-ws protocol.
+const (
+	messageConnectionInit = "connection_init"
+	messageConnectionAck  = "connection_ack"
+	messagePing           = "ping"
+	messagePong           = "pong"
+	messageSubscribe      = "subscribe"
+	messageNext           = "next"
+	messageError          = "error"
+	messageComplete       = "complete"
+)
+
+// message represents a single frame exchanged over a graphql-transport-ws connection.
+type message struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// InitPayload sets the payload sent in the connection_init message when the request is
+// used as a subscription. Servers commonly expect authentication tokens there since
+// browsers cannot set headers on WebSocket handshakes. The modified Request is returned.
+func (request Request) InitPayload(payload map[string]any) Request {
+	request.initPayload = payload
+	return request
+}
+
+// Subscribe subscribes to the request's operation over a WebSocket connection to the
+// request's endpoint using the graphql-transport-ws protocol, or the legacy
+// subscriptions-transport-ws protocol when the server only supports that one, or over
+// Server-Sent Events when UseSSE is set.
+// Each execution result pushed by the server is delivered on the first channel.
+// The second channel receives at most one error, reported when the connection fails or the
+// server terminates the operation with an error. Failed connections are replaced when the
+// client reconnects subscriptions, see Client.WithReconnect. Both channels are closed once
+// the server completes the subscription or the context is cancelled, in which case a
+// complete message is sent to the server.
+// The subscriptions of requests created from a Client share a single connection per
+// endpoint, headers and init payload, opened by the first one and closed after the last
+// one ends, so that servers limiting the number of connections per client are respected.
+// Results are queued until received, so that subscriptions sharing a connection can be
+// consumed independently.
+func (request Request) Subscribe(ctx context.Context) (<-chan gjson.Result, <-chan error) {
+	results := make(chan gjson.Result)
+	errs := make(chan error, 1)
+
+	go func() {
+		defer close(errs)
+		defer close(results)
+
+		err := request.subscribe(ctx, results)
+		if err != nil {
+			errs <- err
+		}
+	}()
+
+	return results, errs
+}
+
+// subscribe runs a subscription to completion, delivering the execution results on results.
+func (request Request) subscribe(ctx context.Context, results chan<- gjson.Result) error {
+	if request.Request == "" {
+		return errors.New("no subscription provided")
+	}
+	if request.client.isClosed() {
+		return ErrClientClosed
+	}
+	err := request.checkRequiredHeaders()
+	if err != nil {
+		return err
+	}
+	query := request.client.withFragments(request.Request)
+	err = request.checkAllowlist(query)
+	if err != nil {
+		return err
+	}
+	_, err = json.Marshal(variables(request.Variables))
+	if err != nil {
+		return fmt.Errorf("encoding subscription: %w", err)
+	}
+	if request.sse {
+		return request.subscribeSSE(ctx, query, results)
+	}
+	sub := &wsSubscription{
+		ctx:     ctx,
+		request: request,
+		query:   query,
+		signal:  make(chan struct{}, 1),
+		done:    make(chan error, 1),
+	}
+
+	var pool *subscriptionPool
+	if request.client != nil && request.auth == nil {
+		pool = request.client.subscriptions
+	}
+	conn, err := pool.attach(request, sub)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-sub.signal:
+			if !sub.deliver(results) {
+				conn.detach(sub)
+				return nil
+			}
+		case err = <-sub.done:
+			sub.deliver(results)
+			return err
+		case <-ctx.Done():
+			conn.detach(sub)
+			return nil
+		}
+	}
+}
+
+// subscriptionPool shares WebSocket connections among the subscriptions of a client.
+type subscriptionPool struct {
+	mu     sync.Mutex
+	conns  map[string]*wsConnection
+	closed bool
+}
+
+// newSubscriptionPool returns an empty subscriptionPool.
+func newSubscriptionPool() *subscriptionPool {
+	return &subscriptionPool{conns: make(map[string]*wsConnection)}
+}
+
+// attach adds the subscription to the connection shared by the subscriptions of request,
+// opening it if needed, and returns the connection. A nil pool opens a dedicated connection.
+func (pool *subscriptionPool) attach(request Request, sub *wsSubscription) (*wsConnection, error) {
+	if pool == nil {
+		conn := newWSConnection(nil, "", request)
+		conn.add(sub)
+		go conn.run()
+		return conn, nil
+	}
+
+	payload, err := json.Marshal(request.initPayload)
+	if err != nil {
+		return nil, fmt.Errorf("encoding init payload: %w", err)
+	}
+	key := request.Endpoint + "\n" + headerKey(request.header()) + "\n" + string(payload)
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	if pool.closed {
+		return nil, ErrClientClosed
+	}
+	conn := pool.conns[key]
+	if conn == nil {
+		conn = newWSConnection(pool, key, request)
+		pool.conns[key] = conn
+		go conn.run()
+	}
+	conn.add(sub)
+	return conn, nil
+}
+
+// wsSubscription is an operation subscribed over a wsConnection. Results are queued by
+// the connection and delivered by the goroutine running the subscription, so that a
+// subscription whose results are not received doesn't hold back the others.
+type wsSubscription struct {
+	id      string
+	ctx     context.Context
+	request Request
+	query   string
+	done    chan error
+
+	mu     sync.Mutex
+	queue  []gjson.Result
+	signal chan struct{}
+
+	// last is the last result received, only accessed by the goroutine running the
+	// connection.
+	last gjson.Result
+}
+
+// push queues a result received by the connection.
+func (sub *wsSubscription) push(result gjson.Result) {
+	sub.last = result
+	sub.mu.Lock()
+	sub.queue = append(sub.queue, result)
+	sub.mu.Unlock()
+	select {
+	case sub.signal <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the queued results on results, and reports whether it did before the
+// context of the subscription was done.
+func (sub *wsSubscription) deliver(results chan<- gjson.Result) bool {
+	sub.mu.Lock()
+	queue := sub.queue
+	sub.queue = nil
+	sub.mu.Unlock()
+	for _, result := range queue {
+		select {
+		case results <- result:
+		case <-sub.ctx.Done():
+			return false
+		}
+	}
+	return true
+}
+
+// wsConnection is a graphql-transport-ws connection multiplexing subscriptions, each one
+// identified by a unique ID. It is replaced according to the reconnection policy of the
+// client when it fails, and closed once its last subscription ends.
+type wsConnection struct {
+	pool    *subscriptionPool
+	key     string
+	request Request
+	policy  *ReconnectPolicy
+	ctx     context.Context
+	cancel  context.CancelFunc
+
+	mu     sync.Mutex
+	subs   map[string]*wsSubscription
+	nextID uint64
+	ws     *websocket.Conn
+	legacy bool
+	ready  bool
+	closed bool
+
+	writeMu sync.Mutex
+}
+
+// newWSConnection returns a connection, not yet dialed, for the subscriptions of request.
+func newWSConnection(pool *subscriptionPool, key string, request Request) *wsConnection {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &wsConnection{
+		pool:    pool,
+		key:     key,
+		request: request,
+		policy:  request.reconnectPolicy(),
+		ctx:     ctx,
+		cancel:  cancel,
+		subs:    make(map[string]*wsSubscription),
+	}
+}
+
+// add registers the subscription under a new ID, subscribing to it right away if the
+// connection is acknowledged.
+func (conn *wsConnection) add(sub *wsSubscription) {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	conn.nextID++
+	sub.id = strconv.FormatUint(conn.nextID, 10)
+	conn.subs[sub.id] = sub
+	if conn.ready {
+		conn.start(sub)
+	}
+}
+
+// start sends the subscribe message of sub. Failures surface through the read loop, which
+// fails when the connection is broken. It must be called with conn.mu held.
+func (conn *wsConnection) start(sub *wsSubscription) {
+	encoded, err := content{
+		Query:         sub.query,
+		OperationName: sub.request.operationName,
+		Variables:     sub.request.Variables,
+	}.encoded()
+	var payload []byte
+	if err == nil {
+		payload, err = marshal(sub.request.resolveCodec(), encoded)
+	}
+	if err != nil {
+		delete(conn.subs, sub.id)
+		sub.done <- fmt.Errorf("encoding subscription: %w", err)
+		return
+	}
+	_ = conn.write(message{ID: sub.id, Type: messageSubscribe, Payload: payload})
+}
+
+// detach removes a subscription cancelled by its caller, sending a complete message to the
+// server, and closes the connection if it was the last one.
+func (conn *wsConnection) detach(sub *wsSubscription) {
+	conn.mu.Lock()
+	if conn.subs[sub.id] == sub {
+		delete(conn.subs, sub.id)
+		if conn.ready {
+			_ = conn.write(message{ID: sub.id, Type: messageComplete})
+		}
+	}
+	conn.mu.Unlock()
+	conn.release()
+}
+
+// finish ends the subscription with the given ID, reporting err to its caller.
+func (conn *wsConnection) finish(id string, err error) {
+	conn.mu.Lock()
+	sub := conn.subs[id]
+	delete(conn.subs, id)
+	conn.mu.Unlock()
+	if sub != nil {
+		sub.done <- err
+		conn.release()
+	}
+}
+
+// release closes the connection if it has no subscription left.
+func (conn *wsConnection) release() {
+	if conn.pool != nil {
+		conn.pool.mu.Lock()
+		defer conn.pool.mu.Unlock()
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	if len(conn.subs) == 0 {
+		conn.close()
+	}
+}
+
+// fail closes the connection and ends all of its subscriptions with err.
+func (conn *wsConnection) fail(err error) {
+	if conn.pool != nil {
+		conn.pool.mu.Lock()
+		defer conn.pool.mu.Unlock()
+	}
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	for id, sub := range conn.subs {
+		sub.done <- err
+		delete(conn.subs, id)
+	}
+	conn.close()
+}
+
+// close removes the connection from its pool and closes it. It must be called with the
+// pool's mutex, if any, and conn.mu held.
+func (conn *wsConnection) close() {
+	if conn.closed {
+		return
+	}
+	conn.closed = true
+	if conn.pool != nil && conn.pool.conns[conn.key] == conn {
+		delete(conn.pool.conns, conn.key)
+	}
+	conn.cancel()
+	if conn.ws != nil {
+		_ = conn.ws.Close()
+	}
+}
+
+// setWebSocket replaces the current WebSocket connection. It must be called with conn.mu
+// held, so that conn.ws can be read while holding either conn.mu or conn.writeMu.
+func (conn *wsConnection) setWebSocket(ws *websocket.Conn) {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	conn.ws = ws
+	conn.legacy = ws != nil && ws.Subprotocol() == legacySubprotocol
+}
+
+// write sends a message over the current WebSocket connection, translated to the legacy
+// protocol if negotiated.
+func (conn *wsConnection) write(msg message) error {
+	conn.writeMu.Lock()
+	defer conn.writeMu.Unlock()
+	ws := conn.ws
+	if ws == nil {
+		return errors.New("not connected")
+	}
+	if conn.legacy {
+		msg.Type = legacyOutgoing(msg.Type)
+	}
+	return ws.WriteJSON(msg)
+}
+
+// ping sends a ping message every interval until done is closed. The legacy protocol has no
+// ping message, its servers send keep-alive messages on their own.
+func (conn *wsConnection) ping(interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			conn.writeMu.Lock()
+			legacy := conn.legacy
+			conn.writeMu.Unlock()
+			if !legacy {
+				_ = conn.write(message{Type: messagePing})
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// run serves the connection until it is closed, replacing it according to the reconnection
+// policy when it fails.
+func (conn *wsConnection) run() {
+	attempt := 0
+	for {
+		connected := false
+		err := conn.serve(func() {
+			connected = true
+			attempt = 0
+			conn.policy.connected(conn.request.Endpoint)
+		})
+		if conn.ctx.Err() != nil {
+			if connected {
+				conn.policy.disconnected(conn.request.Endpoint, nil)
+			}
+			return
+		}
+		if connected {
+			conn.policy.disconnected(conn.request.Endpoint, err)
+		}
+		if conn.policy == nil || ErrorClass(err) != ErrorClassTransport {
+			conn.fail(err)
+			return
+		}
+		attempt++
+		if !conn.policy.wait(conn.ctx, attempt) {
+			conn.fail(err)
+			return
+		}
+		conn.resume()
+	}
+}
+
+// resume updates the variables of the subscriptions with their Resume hooks before they are
+// subscribed again on a new connection.
+func (conn *wsConnection) resume() {
+	conn.mu.Lock()
+	defer conn.mu.Unlock()
+	for _, sub := range conn.subs {
+		if sub.request.resume != nil {
+			sub.request.Variables = sub.request.resume(copyVariables(sub.request.Variables, 0), sub.last)
+		}
+	}
+}
+
+// serve dials the endpoint and dispatches the messages of the server to the subscriptions
+// until the connection fails or is closed. onAck is called when the server acknowledges the
+// connection, after which every subscription is subscribed.
+func (conn *wsConnection) serve(onAck func()) error {
+	dialer := websocket.Dialer{
+		Proxy:           conn.request.client.websocketProxy(),
+		TLSClientConfig: conn.request.client.websocketTLSConfig(),
+		Jar:             conn.request.client.httpClient().Jar,
+		Subprotocols:    []string{subprotocol, legacySubprotocol},
+	}
+	header := conn.request.header()
+	err := conn.request.authorize(conn.ctx, header)
+	if err != nil {
+		return err
+	}
+	dial, endpoint := unixDialer(conn.request.Endpoint)
+	dialer.NetDialContext = dial
+	ws, _, err := dialer.DialContext(conn.ctx, websocketURL(endpoint), header)
+	if err != nil {
+		return &ErrTransport{Op: "dialing endpoint", Err: err}
+	}
+
+	conn.mu.Lock()
+	if conn.closed {
+		conn.mu.Unlock()
+		_ = ws.Close()
+		return nil
+	}
+	conn.setWebSocket(ws)
+	conn.mu.Unlock()
+	defer func() {
+		conn.mu.Lock()
+		conn.setWebSocket(nil)
+		conn.ready = false
+		conn.mu.Unlock()
+		_ = ws.Close()
+	}()
+
+	payload, err := json.Marshal(conn.request.initPayload)
+	if err != nil {
+		return fmt.Errorf("encoding init payload: %w", err)
+	}
+	err = conn.write(message{Type: messageConnectionInit, Payload: payload})
+	if err != nil {
+		return &ErrTransport{Op: "sending connection_init", Err: err}
+	}
+
+	var keepAlive *keepAlive
+	if conn.request.client != nil {
+		keepAlive = conn.request.client.keepAlive
+	}
+	if keepAlive != nil {
+		done := make(chan struct{})
+		defer close(done)
+		go conn.ping(keepAlive.interval, done)
+	}
+
+	for {
+		if keepAlive != nil {
+			_ = ws.SetReadDeadline(time.Now().Add(keepAlive.timeout))
+		}
+		var msg message
+		err = ws.ReadJSON(&msg)
+		if err != nil {
+			var netErr net.Error
+			if keepAlive != nil && errors.As(err, &netErr) && netErr.Timeout() {
+				err = fmt.Errorf("no message received for %s: %w", keepAlive.timeout, err)
+			}
+			return &ErrTransport{Op: "reading message", Err: err}
+		}
+		if conn.legacy {
+			msg.Type = legacyIncoming(msg.Type)
+		}
+
+		switch msg.Type {
+		case messageConnectionAck:
+			conn.mu.Lock()
+			if conn.ready {
+				conn.mu.Unlock()
+				continue
+			}
+			conn.ready = true
+			for _, sub := range conn.subs {
+				conn.start(sub)
+			}
+			conn.mu.Unlock()
+			onAck()
+		case messagePing:
+			err = conn.write(message{Type: messagePong})
+			if err != nil {
+				return &ErrTransport{Op: "sending pong", Err: err}
+			}
+		case messageNext:
+			conn.mu.Lock()
+			sub := conn.subs[msg.ID]
+			conn.mu.Unlock()
+			if sub == nil {
+				continue
+			}
+			sub.push(gjson.ParseBytes(msg.Payload))
+		case legacyMessageConnectionError:
+			return fmt.Errorf("connection rejected: %s", msg.Payload)
+		case messageError:
+			var errs []GraphQLError
+			err = json.Unmarshal(errorPayload(msg.Payload), &errs)
+			if err != nil {
+				conn.finish(msg.ID, &ErrDecode{Err: fmt.Errorf("decoding error payload: %w", err)})
+				continue
+			}
+			conn.finish(msg.ID, Response{Errors: errs}.Err())
+		case messageComplete:
+			conn.finish(msg.ID, nil)
+		}
+	}
+}
+
+// websocketURL converts an HTTP endpoint into the equivalent WebSocket URL.
+// Endpoints already using the ws or wss scheme are returned unchanged.
+func websocketURL(endpoint string) string {
+	switch {
+	case strings.HasPrefix(endpoint, "https://"):
+		return "wss://" + strings.TrimPrefix(endpoint, "https://")
+	case strings.HasPrefix(endpoint, "http://"):
+		return "ws://" + strings.TrimPrefix(endpoint, "http://")
+	default:
+		return endpoint
+	}
+}
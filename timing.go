@@ -0,0 +1,134 @@
+package ggql
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings is the breakdown of the duration of the HTTP exchange of a request, collected with
+// net/http/httptrace when TraceTimings is set. DNS, Connect and TLSHandshake are zero when
+// the request reused a pooled connection, or when the step didn't happen, e.g. no DNS lookup
+// for an IP endpoint or no handshake for plain HTTP. FirstByte is the time from the start of
+// the exchange to the first byte of the response, and includes the steps before it and the
+// server processing time. BodyRead is the time spent reading the response body after the
+// first byte, and Total the duration of the whole exchange.
+type Timings struct {
+	DNS          time.Duration
+	Connect      time.Duration
+	TLSHandshake time.Duration
+	FirstByte    time.Duration
+	BodyRead     time.Duration
+	Total        time.Duration
+
+	// ConnectionReused reports whether the request was sent over a pooled connection.
+	ConnectionReused bool
+}
+
+// TraceTimings makes every request of the client record the timing breakdown of its HTTP
+// exchange in Response.Timings, for performance debugging of GraphQL gateways. The
+// updated Client is returned.
+func (client *Client) TraceTimings() *Client {
+	client.timings = true
+	return client
+}
+
+// TraceTimings makes the request record the timing breakdown of its HTTP exchange in
+// Response.Timings. When the request is retried or resent as part of the persisted query
+// protocol, the timings are those of the last exchange. Requests executed in-process or by
+// a custom Transport have no timings. The modified Request is returned.
+func (request Request) TraceTimings() Request {
+	request.timings = true
+	return request
+}
+
+// tracesTimings reports whether the request records its timings, on its own or through
+// its parent client.
+func (request Request) tracesTimings() bool {
+	return request.timings || (request.client != nil && request.client.timings)
+}
+
+// timingTrace collects the timings of one HTTP exchange.
+type timingTrace struct {
+	mu        sync.Mutex
+	start     time.Time
+	dnsStart  time.Time
+	dialStart time.Time
+	tlsStart  time.Time
+	firstByte time.Time
+	timings   Timings
+}
+
+// traceTimings returns the HTTP request carrying a trace collecting its timings, and the
+// trace, or the request unchanged and a nil trace when the request doesn't record timings.
+func (request Request) traceTimings(req *http.Request) (*http.Request, *timingTrace) {
+	if !request.tracesTimings() {
+		return req, nil
+	}
+	trace := &timingTrace{start: time.Now()}
+	clientTrace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			trace.record(func() { trace.timings.ConnectionReused = info.Reused })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			trace.record(func() { trace.dnsStart = time.Now() })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			trace.record(func() { trace.timings.DNS = time.Since(trace.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			trace.record(func() {
+				if trace.dialStart.IsZero() {
+					trace.dialStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			trace.record(func() {
+				if err == nil {
+					trace.timings.Connect = time.Since(trace.dialStart)
+				}
+			})
+		},
+		TLSHandshakeStart: func() {
+			trace.record(func() { trace.tlsStart = time.Now() })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			trace.record(func() { trace.timings.TLSHandshake = time.Since(trace.tlsStart) })
+		},
+		GotFirstResponseByte: func() {
+			trace.record(func() {
+				trace.firstByte = time.Now()
+				trace.timings.FirstByte = trace.firstByte.Sub(trace.start)
+			})
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), clientTrace)), trace
+}
+
+// record runs update with the lock of the trace held, as the hooks of an exchange can run on
+// the goroutines of the transport.
+func (trace *timingTrace) record(update func()) {
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	update()
+}
+
+// result returns the timings of the exchange, once the response body has been read, or zero
+// timings for a nil trace, so that send doesn't need to check whether timings are enabled.
+func (trace *timingTrace) result() Timings {
+	if trace == nil {
+		return Timings{}
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	end := time.Now()
+	timings := trace.timings
+	if !trace.firstByte.IsZero() {
+		timings.BodyRead = end.Sub(trace.firstByte)
+	}
+	timings.Total = end.Sub(trace.start)
+	return timings
+}
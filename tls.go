@@ -0,0 +1,128 @@
+package ggql
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+// TLSOptions configures the TLS connections of a client, see Client.WithTLS.
+type TLSOptions struct {
+	// CertFile and KeyFile are the paths of the PEM encoded client certificate and private
+	// key presented to servers requiring mutual TLS. Certificates may hold certificates
+	// loaded by other means.
+	CertFile     string
+	KeyFile      string
+	Certificates []tls.Certificate
+
+	// CAFile is the path of a PEM bundle of the certificate authorities trusted to verify
+	// the certificates of the servers, and CAPEM a bundle given in memory. When neither is
+	// set, the certificate authorities of the system are trusted.
+	CAFile string
+	CAPEM  []byte
+
+	// MinVersion is the minimum TLS version accepted, such as tls.VersionTLS13. It defaults
+	// to TLS 1.2.
+	MinVersion uint16
+
+	// ServerName overrides the name used to verify the certificates of the servers, e.g. when
+	// reaching them through an IP address.
+	ServerName string
+
+	// InsecureSkipVerify disables the verification of the certificates of the servers. It
+	// should only be used in tests.
+	InsecureSkipVerify bool
+}
+
+// config builds the tls.Config described by the options.
+func (options TLSOptions) config() (*tls.Config, error) {
+	config := &tls.Config{
+		Certificates:       append([]tls.Certificate(nil), options.Certificates...),
+		MinVersion:         options.MinVersion,
+		ServerName:         options.ServerName,
+		InsecureSkipVerify: options.InsecureSkipVerify,
+	}
+	if config.MinVersion == 0 {
+		config.MinVersion = tls.VersionTLS12
+	}
+
+	if options.CertFile != "" || options.KeyFile != "" {
+		certificate, err := tls.LoadX509KeyPair(options.CertFile, options.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		config.Certificates = append(config.Certificates, certificate)
+	}
+
+	if options.CAFile != "" || len(options.CAPEM) > 0 {
+		bundle := append([]byte(nil), options.CAPEM...)
+		if options.CAFile != "" {
+			file, err := os.ReadFile(options.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("reading CA bundle: %w", err)
+			}
+			bundle = append(append(bundle, '\n'), file...)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(bundle) {
+			return nil, errors.New("CA bundle contains no certificate")
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// WithTLS configures the TLS connections of the client: client certificates for mutual
+// TLS, trusted certificate authorities and minimum TLS version. It installs an
+// *http.Client whose transport is a clone of the transport of the current one, or of
+// http.DefaultTransport, with the TLS configuration applied; the WebSocket connections of
+// subscriptions use it as well. Transports other than *http.Transport cannot be configured
+// and make WithTLS fail. The updated Client is returned, or an error if the certificates
+// cannot be loaded.
+func (client *Client) WithTLS(options TLSOptions) (*Client, error) {
+	config, err := options.config()
+	if err != nil {
+		return client, err
+	}
+
+	err = client.configureTransport(func(transport *http.Transport) {
+		transport.TLSClientConfig = config
+	})
+	if err != nil {
+		return client, err
+	}
+	client.tlsConfig = config
+	return client, nil
+}
+
+// configureTransport installs an *http.Client whose transport is a clone of the transport of
+// the current one, or of http.DefaultTransport, modified by configure. It fails when the
+// transport is not an *http.Transport.
+func (client *Client) configureTransport(configure func(transport *http.Transport)) error {
+	httpClient := *client.httpClient()
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("cannot configure transport %T", base)
+	}
+	transport = transport.Clone()
+	configure(transport)
+	httpClient.Transport = transport
+	client.HTTPClient = &httpClient
+	return nil
+}
+
+// websocketTLSConfig returns the TLS configuration of the WebSocket connections of the
+// client, or nil for the default one.
+func (client *Client) websocketTLSConfig() *tls.Config {
+	if client == nil || client.tlsConfig == nil {
+		return nil
+	}
+	return client.tlsConfig.Clone()
+}
@@ -0,0 +1,103 @@
+package ggql
+
+import (
+	"context"
+	"net/http"
+)
+
+// Operation is a GraphQL operation handed to a Transport by the innermost stage of the
+// client's middleware chain. Header holds the headers of the request merged with the
+// default headers of its client.
+type Operation struct {
+	Endpoint      string
+	Query         string
+	OperationName string
+	Variables     map[string]any
+	Header        http.Header
+
+	// request is the request the operation was built from, carrying the settings of the
+	// HTTP transport. It is the zero Request for operations built by other means.
+	request Request
+}
+
+// Type returns the type of the operation: "query", "mutation" or "subscription".
+func (operation Operation) Type() string {
+	return operationType(operation.Query, operation.OperationName)
+}
+
+// Transport executes GraphQL operations. The HTTP transport, HTTPTransport, is used by
+// default; alternative transports can be installed with Client.WithTransport. Transports
+// are safe for concurrent use.
+type Transport interface {
+	Execute(ctx context.Context, operation Operation) (Response, error)
+}
+
+// TransportFunc adapts a function to the Transport interface.
+type TransportFunc func(ctx context.Context, operation Operation) (Response, error)
+
+// Execute implements Transport.
+func (f TransportFunc) Execute(ctx context.Context, operation Operation) (Response, error) {
+	return f(ctx, operation)
+}
+
+// HTTPTransport is the default Transport, sending operations as JSON over HTTP. It honours
+// the settings of the request the operation was built from and of its client, such as GET
+// queries, persisted queries, trusted documents, compression, authentication and response
+// size limits. Changes made to the operation by a wrapping Transport, e.g. to its headers,
+// take precedence.
+type HTTPTransport struct{}
+
+// Execute implements Transport.
+func (HTTPTransport) Execute(ctx context.Context, operation Operation) (Response, error) {
+	request := operation.request
+	request.Endpoint = operation.Endpoint
+	request.Request = operation.Query
+	request.operationName = operation.OperationName
+	request.Variables = operation.Variables
+	request.fixedHeader = operation.Header
+
+	c := content{
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     request.Variables,
+	}
+	if response, trusted, err := request.sendTrusted(ctx, c); trusted || err != nil {
+		return response, err
+	}
+	if request.usesPersistedQuery() {
+		return request.sendPersisted(ctx, c)
+	}
+	return request.send(ctx, c)
+}
+
+// WithTransport makes the client execute its queries and mutations with transport instead
+// of HTTPTransport, once they have gone through the client's middleware chain and built-in
+// stages. Batches, subscriptions, incremental delivery and DoRaw are specific to HTTP and
+// WebSocket and keep using them. A nil transport restores HTTPTransport. The updated Client
+// is returned.
+func (client *Client) WithTransport(transport Transport) *Client {
+	client.transport = transport
+	return client
+}
+
+// operation returns the Operation executing the request.
+func (request Request) operation() Operation {
+	return Operation{
+		Endpoint:      request.Endpoint,
+		Query:         request.Request,
+		OperationName: request.operationName,
+		Variables:     request.Variables,
+		Header:        request.header(),
+		request:       request,
+	}
+}
+
+// execute is the innermost Handler of every middleware chain. It executes the request with
+// the transport of its client, HTTPTransport by default.
+func execute(ctx context.Context, request Request) (Response, error) {
+	var transport Transport = HTTPTransport{}
+	if request.client != nil && request.client.transport != nil {
+		transport = request.client.transport
+	}
+	return transport.Execute(ctx, request.operation())
+}
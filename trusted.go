@@ -0,0 +1,105 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// TrustedDocuments is a manifest of the documents allowed by a locked-down server, indexed
+// by document ID. It is used by the trusted documents workflow, see Client.WithTrustedDocuments.
+type TrustedDocuments struct {
+	byDocument map[string]string
+	strict     bool
+}
+
+// NewTrustedDocuments returns a manifest holding the given documents, keyed by ID.
+func NewTrustedDocuments(documents map[string]string) *TrustedDocuments {
+	byDocument := make(map[string]string, len(documents))
+	for id, document := range documents {
+		byDocument[document] = id
+	}
+	return &TrustedDocuments{byDocument: byDocument}
+}
+
+// LoadTrustedDocuments reads a manifest in JSON format, either an object mapping document
+// IDs to documents, or an Apollo persisted query manifest whose operations list their
+// "id" and "body".
+func LoadTrustedDocuments(reader io.Reader) (*TrustedDocuments, error) {
+	var manifest map[string]json.RawMessage
+	err := json.NewDecoder(reader).Decode(&manifest)
+	if err != nil {
+		return nil, fmt.Errorf("decoding manifest: %w", err)
+	}
+
+	documents := make(map[string]string, len(manifest))
+	if operations, ok := manifest["operations"]; ok {
+		var entries []struct {
+			ID   string `json:"id"`
+			Body string `json:"body"`
+		}
+		err = json.Unmarshal(operations, &entries)
+		if err != nil {
+			return nil, fmt.Errorf("decoding manifest operations: %w", err)
+		}
+		for _, entry := range entries {
+			documents[entry.ID] = entry.Body
+		}
+		return NewTrustedDocuments(documents), nil
+	}
+
+	for id, raw := range manifest {
+		var document string
+		err = json.Unmarshal(raw, &document)
+		if err != nil {
+			return nil, fmt.Errorf("decoding manifest document %q: %w", id, err)
+		}
+		documents[id] = document
+	}
+	return NewTrustedDocuments(documents), nil
+}
+
+// WithTrustedDocuments enables the trusted documents workflow: requests whose document is
+// part of the manifest are sent with its ID in the "documentId" member instead of the full
+// document. When strict is set, requests whose document is not part of the manifest fail
+// with ErrUntrustedDocument without reaching the endpoint; otherwise they are sent in full.
+// Trusted documents take precedence over Automatic Persisted Queries. The updated Client
+// is returned.
+func (client *Client) WithTrustedDocuments(documents *TrustedDocuments, strict bool) *Client {
+	if documents == nil {
+		client.trusted = nil
+		return client
+	}
+	client.trusted = &TrustedDocuments{byDocument: documents.byDocument, strict: strict}
+	return client
+}
+
+// trustedDocument replaces the document of the payload by its ID when the parent client
+// uses trusted documents, and reports whether it did.
+func (request Request) trustedDocument(c *content) (bool, error) {
+	if request.client == nil || request.client.trusted == nil {
+		return false, nil
+	}
+	id, ok := request.client.trusted.byDocument[c.Query]
+	if !ok {
+		if request.client.trusted.strict {
+			return false, &ErrUntrustedDocument{OperationName: c.OperationName}
+		}
+		return false, nil
+	}
+	c.Query = ""
+	c.DocumentID = id
+	return true, nil
+}
+
+// sendTrusted sends the payload with the document replaced by its ID if it is trusted,
+// and reports whether it did.
+func (request Request) sendTrusted(ctx context.Context, c content) (Response, bool, error) {
+	trusted, err := request.trustedDocument(&c)
+	if err != nil || !trusted {
+		return Response{}, false, err
+	}
+	response, err := request.send(ctx, c)
+	return response, true, err
+}
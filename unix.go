@@ -0,0 +1,136 @@
+package ggql
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// unixScheme prefixes the endpoints reached through a Unix domain socket.
+const unixScheme = "unix://"
+
+// UnixHost is the pseudo-host sent in the Host header of the requests sent through a Unix
+// domain socket.
+const UnixHost = "localhost"
+
+// parseUnixEndpoint splits an endpoint of the form unix:///path/to.sock or
+// unix:///path/to.sock:/graphql into the path of the socket and the path of the HTTP
+// requests, "/" by default. ok is false for other endpoints.
+func parseUnixEndpoint(endpoint string) (socket, path string, ok bool) {
+	if !strings.HasPrefix(endpoint, unixScheme) {
+		return "", "", false
+	}
+	socket = strings.TrimPrefix(endpoint, unixScheme)
+	if i := strings.IndexAny(socket, "?#"); i >= 0 {
+		socket = socket[:i]
+	}
+	path = "/"
+	if before, after, found := strings.Cut(socket, ":/"); found {
+		socket, path = before, "/"+after
+	}
+	return socket, path, true
+}
+
+// unixTransports caches the transports dialing Unix domain sockets, by base transport, so
+// that their connections are reused.
+var unixTransports sync.Map
+
+// unixSocketKey is the context key holding the path of the socket dialed by a transport
+// returned by unixTransport.
+type unixSocketKey struct{}
+
+// unixTransport returns a clone of base dialing the socket found in the context of each
+// request. Other round trippers than *http.Transport don't expose their dialing, so they
+// are replaced by one failing every request rather than being silently bypassed.
+func unixTransport(base http.RoundTripper) http.RoundTripper {
+	transport, ok := base.(*http.Transport)
+	if !ok {
+		return unsupportedUnixTransport{transport: base}
+	}
+	if cached, ok := unixTransports.Load(transport); ok {
+		return cached.(http.RoundTripper)
+	}
+	base = transport
+	transport = transport.Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{}
+	transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+		socket, _ := ctx.Value(unixSocketKey{}).(string)
+		return dialer.DialContext(ctx, "unix", socket)
+	}
+	cached, _ := unixTransports.LoadOrStore(base, http.RoundTripper(transport))
+	return cached.(http.RoundTripper)
+}
+
+// unixRoundTripper sends the requests for unix:// endpoints through their socket.
+type unixRoundTripper struct {
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper. The URL of the request is rewritten to an HTTP URL
+// whose host is unique to the socket, so that connections to distinct sockets are not
+// pooled together, while the Host header is set to UnixHost.
+func (roundTripper unixRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	socket, path, ok := parseUnixEndpoint(req.URL.String())
+	if !ok {
+		return roundTripper.transport.RoundTrip(req)
+	}
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(socket))
+
+	ctx := context.WithValue(req.Context(), unixSocketKey{}, socket)
+	rewritten := req.Clone(ctx)
+	rewritten.URL.Scheme = "http"
+	rewritten.URL.Host = fmt.Sprintf("unix-%x", hash.Sum64())
+	rewritten.URL.Path = path
+	rewritten.URL.RawPath = ""
+	rewritten.URL.Opaque = ""
+	rewritten.Host = UnixHost
+	return roundTripper.transport.RoundTrip(rewritten)
+}
+
+// unsupportedUnixTransport fails the requests for unix:// endpoints configured with a round
+// tripper that can't dial a socket.
+type unsupportedUnixTransport struct {
+	transport http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper unsupportedUnixTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	return nil, fmt.Errorf("unix:// endpoints require an *http.Transport, got a %T", roundTripper.transport)
+}
+
+// withUnixTransport returns httpClient unchanged for network endpoints, or a copy sending
+// the requests through the socket of a unix:// endpoint.
+func withUnixTransport(httpClient *http.Client, endpoint string) *http.Client {
+	if !strings.HasPrefix(endpoint, unixScheme) {
+		return httpClient
+	}
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	clone := *httpClient
+	clone.Transport = unixRoundTripper{transport: unixTransport(base)}
+	return &clone
+}
+
+// unixDialer returns the function dialing the socket of a unix:// endpoint for WebSocket
+// connections, and the ws:// URL to dial, or nil and the URL unchanged for other endpoints.
+func unixDialer(endpoint string) (func(ctx context.Context, network, address string) (net.Conn, error), string) {
+	socket, path, ok := parseUnixEndpoint(endpoint)
+	if !ok {
+		return nil, endpoint
+	}
+	dialer := &net.Dialer{}
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		return dialer.DialContext(ctx, "unix", socket)
+	}, "ws://" + UnixHost + path
+}
@@ -0,0 +1,57 @@
+package ggql
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// wrappingRoundTripper is a RoundTripper wrapping another one, whose dialing it hides.
+type wrappingRoundTripper struct {
+	next http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (roundTripper wrappingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return roundTripper.next.RoundTrip(req)
+}
+
+func TestUnixEndpoint(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ggql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "graphql.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":{"path":"` + r.URL.Path + `"}}`))
+	})}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	defer server.Close()
+	client := NewClient("unix://" + socket + ":/graphql")
+
+	response, err := client.NewRequest().Query(`{ path }`).ExecuteResponse(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.Data.Get("path").String() != "/graphql" {
+		t.Errorf("got path %s, want /graphql", response.Data.Get("path"))
+	}
+
+	_, err = client.NewRequest().Query(`{ path }`).
+		WithRoundTripper(wrappingRoundTripper{next: http.DefaultTransport}).
+		ExecuteResponse(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "require an *http.Transport") {
+		t.Errorf("got error %v with a wrapping round tripper, want an unsupported transport error", err)
+	}
+}
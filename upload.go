@@ -0,0 +1,204 @@
+package ggql
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Upload represents a file sent as a variable of a mutation, following the GraphQL
+// multipart request specification. Variables holding an Upload, an *Upload or a plain
+// io.Reader make the request switch to a multipart/form-data body automatically.
+type Upload struct {
+	File        io.Reader
+	FileName    string
+	ContentType string
+}
+
+// fileUpload associates an Upload with the dotted path of the variable it replaces.
+type fileUpload struct {
+	path   string
+	upload Upload
+}
+
+// extractUploads returns a copy of the variables in which every upload is replaced by nil,
+// along with the uploads found and their object paths, e.g. "variables.files.0".
+// When no upload is found, the variables are returned unchanged.
+func extractUploads(variables map[string]any) (map[string]any, []fileUpload) {
+	var uploads []fileUpload
+	replaced := walkUploads("variables", variables, &uploads)
+	if len(uploads) == 0 {
+		return variables, nil
+	}
+	return replaced.(map[string]any), uploads
+}
+
+// walkUploads recursively replaces uploads nested in maps and slices, recording them in uploads.
+func walkUploads(path string, value any, uploads *[]fileUpload) any {
+	switch v := value.(type) {
+	case Upload:
+		*uploads = append(*uploads, fileUpload{path: path, upload: v})
+		return nil
+	case *Upload:
+		if v == nil {
+			return nil
+		}
+		*uploads = append(*uploads, fileUpload{path: path, upload: *v})
+		return nil
+	case io.Reader:
+		*uploads = append(*uploads, fileUpload{path: path, upload: Upload{File: v}})
+		return nil
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		replaced := make(map[string]any, len(v))
+		for _, key := range keys {
+			replaced[key] = walkUploads(path+"."+key, v[key], uploads)
+		}
+		return replaced
+	case []any:
+		replaced := make([]any, len(v))
+		for i, item := range v {
+			replaced[i] = walkUploads(path+"."+strconv.Itoa(i), item, uploads)
+		}
+		return replaced
+	case []Upload:
+		replaced := make([]any, len(v))
+		for i, item := range v {
+			replaced[i] = walkUploads(path+"."+strconv.Itoa(i), item, uploads)
+		}
+		return replaced
+	case []*Upload:
+		replaced := make([]any, len(v))
+		for i, item := range v {
+			replaced[i] = walkUploads(path+"."+strconv.Itoa(i), item, uploads)
+		}
+		return replaced
+	default:
+		return value
+	}
+}
+
+// uploadPlaceholder is the content rendered in place of the files of uploads by Dump.
+const uploadPlaceholder = "[file contents omitted]"
+
+// placeholderUploads returns a copy of the variables in which the file of every upload is
+// replaced by uploadPlaceholder, keeping its name and content type, so that the payload can
+// be encoded without consuming the files. When no upload is found, the variables are
+// returned unchanged.
+func placeholderUploads(variables map[string]any) map[string]any {
+	replaced, uploads := extractUploads(variables)
+	for _, upload := range uploads {
+		segments := strings.Split(upload.path, ".")[1:]
+		var container any = replaced
+		for _, segment := range segments[:len(segments)-1] {
+			container = uploadContainerItem(container, segment)
+		}
+		placeholder := Upload{
+			File:        strings.NewReader(uploadPlaceholder),
+			FileName:    upload.upload.FileName,
+			ContentType: upload.upload.ContentType,
+		}
+		last := segments[len(segments)-1]
+		switch container := container.(type) {
+		case map[string]any:
+			container[last] = placeholder
+		case []any:
+			index, _ := strconv.Atoi(last)
+			container[index] = placeholder
+		}
+	}
+	return replaced
+}
+
+// uploadContainerItem returns the item of the map or slice built by walkUploads found under
+// the given path segment.
+func uploadContainerItem(container any, segment string) any {
+	switch container := container.(type) {
+	case map[string]any:
+		return container[segment]
+	case []any:
+		index, _ := strconv.Atoi(segment)
+		return container[index]
+	}
+	return nil
+}
+
+// encodeMultipart writes the payload as a multipart/form-data body made of the "operations"
+// part, the "map" part associating each file with its variable path, and one part per file.
+// It returns the content type of the body, including the multipart boundary.
+func encodeMultipart(buf *bytes.Buffer, c content, uploads []fileUpload, codec Codec) (string, error) {
+	writer := multipart.NewWriter(buf)
+
+	encoded, err := c.encoded()
+	if err != nil {
+		return "", fmt.Errorf("encoding operations: %w", err)
+	}
+	operations, err := marshal(codec, encoded)
+	if err != nil {
+		return "", fmt.Errorf("encoding operations: %w", err)
+	}
+	err = writer.WriteField("operations", string(operations))
+	if err != nil {
+		return "", fmt.Errorf("writing operations: %w", err)
+	}
+
+	fileMap := make(map[string][]string, len(uploads))
+	for i, upload := range uploads {
+		fileMap[strconv.Itoa(i)] = []string{upload.path}
+	}
+	encodedMap, err := json.Marshal(fileMap)
+	if err != nil {
+		return "", fmt.Errorf("encoding map: %w", err)
+	}
+	err = writer.WriteField("map", string(encodedMap))
+	if err != nil {
+		return "", fmt.Errorf("writing map: %w", err)
+	}
+
+	for i, upload := range uploads {
+		part, err := writer.CreatePart(uploadHeader(strconv.Itoa(i), upload.upload))
+		if err != nil {
+			return "", fmt.Errorf("creating file part: %w", err)
+		}
+		_, err = io.Copy(part, upload.upload.File)
+		if err != nil {
+			return "", fmt.Errorf("writing file %s: %w", upload.path, err)
+		}
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", fmt.Errorf("closing multipart body: %w", err)
+	}
+	return writer.FormDataContentType(), nil
+}
+
+// uploadHeader builds the MIME header of the part holding the given upload.
+func uploadHeader(name string, upload Upload) textproto.MIMEHeader {
+	fileName := upload.FileName
+	if fileName == "" {
+		fileName = name
+	}
+	contentType := upload.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	escape := strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition",
+		fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escape.Replace(name), escape.Replace(fileName)))
+	header.Set("Content-Type", contentType)
+	return header
+}
@@ -0,0 +1,81 @@
+package ggql
+
+import (
+	"context"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+// modulePath is the path of the module, looked up in the build information to report its
+// version in the User-Agent header.
+const modulePath = "github.com/lance-free/ggql"
+
+// libraryAgent returns the product token identifying the library in the User-Agent header:
+// "ggql", followed by the version of the module when the build information records it.
+var libraryAgent = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "ggql"
+	}
+	modules := append([]*debug.Module{&info.Main}, info.Deps...)
+	for _, module := range modules {
+		if module.Path == modulePath && module.Version != "" && module.Version != "(devel)" {
+			return "ggql/" + module.Version
+		}
+	}
+	return "ggql"
+})
+
+// WithUserAgent sets the User-Agent header of the client's requests to the product token of
+// the application, "product/version", followed by the one of the library, such as
+// "inventory-sync/1.4.0 ggql/v1.2.0", so that endpoints can tell apart the applications
+// sharing the library. An empty version omits the slash; an empty product leaves the
+// library token alone. Like other client headers, it can be overridden per request. The
+// updated Client is returned.
+func (client *Client) WithUserAgent(product, version string) *Client {
+	agent := libraryAgent()
+	if product != "" {
+		if version != "" {
+			product += "/" + version
+		}
+		agent = product + " " + agent
+	}
+	return client.AddHeader("User-Agent", agent)
+}
+
+// RequireHeaders makes the client fail fast, without sending anything, the requests missing
+// one of the given headers, such as an API version header mandated by the endpoint, with an
+// ErrMissingHeader. Headers are looked up among the ones set on the client, on the request
+// and by the middleware, before authentication (see WithBearerToken and WithBasicAuth) and
+// signing. Successive calls add to the required headers. The updated Client is returned.
+func (client *Client) RequireHeaders(names ...string) *Client {
+	client.requiredHeaders = append(client.requiredHeaders, names...)
+	return client
+}
+
+// checkRequiredHeaders returns an ErrMissingHeader for the first header required by the
+// parent client that the request lacks.
+func (request Request) checkRequiredHeaders() error {
+	if request.client == nil || len(request.client.requiredHeaders) == 0 {
+		return nil
+	}
+	header := request.header()
+	for _, name := range request.client.requiredHeaders {
+		if strings.TrimSpace(header.Get(name)) == "" {
+			return &ErrMissingHeader{Name: name}
+		}
+	}
+	return nil
+}
+
+// requireHeaders is the Handler stage failing the requests that lack a required header.
+func requireHeaders(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		err := request.checkRequiredHeaders()
+		if err != nil {
+			return Response{}, err
+		}
+		return next(ctx, request)
+	}
+}
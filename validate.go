@@ -0,0 +1,76 @@
+package ggql
+
+import (
+	"context"
+	"errors"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+)
+
+// validator checks the documents of the requests before they are sent.
+type validator struct {
+	schema *ast.Schema
+}
+
+// WithValidation makes the client validate the document of every request before sending it,
+// failing with an ErrValidation that locates the problems in the document instead of
+// reaching the endpoint. Without schema, only the syntax of the document is checked; with
+// a schema, loaded for instance with gqlparser.LoadSchema, the document is also validated
+// against it. The updated Client is returned.
+func (client *Client) WithValidation(schema *ast.Schema) *Client {
+	client.validator = &validator{schema: schema}
+	return client
+}
+
+// Validate checks the request's document without sending it, and returns an ErrValidation
+// describing its syntax errors or, when schema is not nil, its validation errors.
+func (request Request) Validate(schema *ast.Schema) error {
+	if request.Request == "" {
+		return errors.New("no query/mutation provided")
+	}
+
+	var errs gqlerror.List
+	if schema == nil {
+		_, err := parser.ParseQuery(&ast.Source{Input: request.Request})
+		var syntax *gqlerror.Error
+		switch {
+		case errors.As(err, &syntax):
+			errs = gqlerror.List{syntax}
+		case err != nil:
+			errs = gqlerror.List{gqlerror.Wrap(err)}
+		}
+	} else {
+		_, errs = gqlparser.LoadQuery(schema, request.Request)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	validation := &ErrValidation{Errors: make([]GraphQLError, len(errs))}
+	for i, err := range errs {
+		validation.Errors[i] = GraphQLError{Message: err.Message, Extensions: err.Extensions}
+		for _, location := range err.Locations {
+			validation.Errors[i].Locations = append(validation.Errors[i].Locations, Location{Line: location.Line, Column: location.Column})
+		}
+		if err.Rule != "" {
+			if validation.Errors[i].Extensions == nil {
+				validation.Errors[i].Extensions = make(map[string]any)
+			}
+			validation.Errors[i].Extensions["rule"] = err.Rule
+		}
+	}
+	return validation
+}
+
+// middleware returns a Handler validating the requests before calling next.
+func (validator *validator) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		err := request.Validate(validator.schema)
+		if err != nil {
+			return Response{}, err
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,223 @@
+package ggql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/tidwall/gjson"
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/parser"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// SchemaFromIntrospection builds the schema described by an introspection result, such as a
+// cached result of Client.Introspect, for WithValidation and WithVariableChecking.
+func SchemaFromIntrospection(introspection gjson.Result) (*ast.Schema, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "introspection", Input: PrintSDL(introspection)})
+	if err != nil {
+		return nil, fmt.Errorf("loading introspected schema: %w", err)
+	}
+	return schema, nil
+}
+
+// variableChecker checks the variables of the requests against a schema before they are sent.
+type variableChecker struct {
+	schema *ast.Schema
+}
+
+// WithVariableChecking makes the client check the variables of every request against the
+// variable definitions of its operation and the given schema before sending it, failing
+// with an ErrValidation instead of reaching the endpoint. See Request.CheckVariables. The
+// updated Client is returned.
+func (client *Client) WithVariableChecking(schema *ast.Schema) *Client {
+	client.variableChecker = &variableChecker{schema: schema}
+	return client
+}
+
+// CheckVariables checks the variables of the request against the variable definitions of the
+// operation it executes and the types of schema, without sending it. It returns an
+// ErrValidation listing the required variables that are missing or null and the values that
+// don't match their type: scalars of the wrong kind, Int values that are not 32-bit
+// integers, unknown enum values, and input objects with unknown or missing required fields.
+// Each error carries the path of the offending value, starting with "variable". Custom
+// scalars accept any value, and types missing from the schema aren't checked. Variables
+// are checked in their JSON encoding, registered scalars included (see RegisterScalar).
+func (request Request) CheckVariables(schema *ast.Schema) error {
+	if request.Request == "" {
+		return errors.New("no query/mutation provided")
+	}
+	document, err := parser.ParseQuery(&ast.Source{Input: request.Request})
+	if err != nil {
+		return request.Validate(nil)
+	}
+	operation := selectOperation(document, request.operationName)
+	if operation == nil {
+		return nil
+	}
+
+	encoded, err := json.Marshal(variables(request.Variables))
+	if err != nil {
+		return fmt.Errorf("encoding variables: %w", err)
+	}
+	values := gjson.ParseBytes(encoded)
+
+	checker := variableChecker{schema: schema}
+	var errs []GraphQLError
+	for _, definition := range operation.VariableDefinitions {
+		value := values.Get(gjson.Escape(definition.Variable))
+		path := []any{"variable", definition.Variable}
+		if !value.Exists() && definition.DefaultValue != nil {
+			continue
+		}
+		if !value.Exists() && definition.Type.NonNull {
+			errs = append(errs, variableError(path, "missing required value of type %s", definition.Type))
+			continue
+		}
+		errs = append(errs, checker.check(value, definition.Type, path)...)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return &ErrValidation{Errors: errs}
+}
+
+// selectOperation returns the operation of the document executed under the given name, or
+// the only operation of the document when the name is empty.
+func selectOperation(document *ast.QueryDocument, name string) *ast.OperationDefinition {
+	if name == "" {
+		if len(document.Operations) == 1 {
+			return document.Operations[0]
+		}
+		return nil
+	}
+	return document.Operations.ForName(name)
+}
+
+// check returns the errors of value against the type t, found at path.
+func (checker variableChecker) check(value gjson.Result, t *ast.Type, path []any) []GraphQLError {
+	if !value.Exists() || value.Type == gjson.Null {
+		if t.NonNull {
+			return []GraphQLError{variableError(path, "expected %s, found null", t)}
+		}
+		return nil
+	}
+	if t.Elem != nil {
+		if !value.IsArray() {
+			// A single value is coerced to a list of one element.
+			return checker.check(value, t.Elem, path)
+		}
+		var errs []GraphQLError
+		for i, element := range value.Array() {
+			errs = append(errs, checker.check(element, t.Elem, append(path[:len(path):len(path)], i))...)
+		}
+		return errs
+	}
+
+	definition := checker.schema.Types[t.NamedType]
+	if definition == nil {
+		return nil
+	}
+	switch definition.Kind {
+	case ast.Scalar:
+		return checkScalar(value, t.NamedType, path)
+	case ast.Enum:
+		if value.Type == gjson.String && definition.EnumValues.ForName(value.Str) != nil {
+			return nil
+		}
+		return []GraphQLError{variableError(path, "expected a value of enum %s, found %s", t.NamedType, value.Raw)}
+	case ast.InputObject:
+		if !value.IsObject() {
+			return []GraphQLError{variableError(path, "expected an input object %s, found %s", t.NamedType, jsonKind(value))}
+		}
+		var errs []GraphQLError
+		value.ForEach(func(key, _ gjson.Result) bool {
+			if definition.Fields.ForName(key.Str) == nil {
+				errs = append(errs, variableError(append(path[:len(path):len(path)], key.Str), "unknown field of input object %s", t.NamedType))
+			}
+			return true
+		})
+		for _, field := range definition.Fields {
+			fieldPath := append(path[:len(path):len(path)], field.Name)
+			member := value.Get(gjson.Escape(field.Name))
+			switch {
+			case !member.Exists() && field.DefaultValue != nil:
+			case !member.Exists() && field.Type.NonNull:
+				errs = append(errs, variableError(fieldPath, "missing required field of type %s", field.Type))
+			default:
+				errs = append(errs, checker.check(member, field.Type, fieldPath)...)
+			}
+		}
+		return errs
+	}
+	return nil
+}
+
+// checkScalar returns the error of value against the built-in scalar name, found at path.
+// Custom scalars accept any value.
+func checkScalar(value gjson.Result, name string, path []any) []GraphQLError {
+	valid := true
+	switch name {
+	case "Int":
+		valid = value.Type == gjson.Number && isInt32(value.Raw)
+	case "Float":
+		valid = value.Type == gjson.Number
+	case "String":
+		valid = value.Type == gjson.String
+	case "Boolean":
+		valid = value.IsBool()
+	case "ID":
+		valid = value.Type == gjson.String || (value.Type == gjson.Number && isInteger(value.Raw))
+	}
+	if valid {
+		return nil
+	}
+	return []GraphQLError{variableError(path, "expected %s, found %s %s", name, jsonKind(value), value.Raw)}
+}
+
+// isInteger reports whether the JSON number raw is an integer.
+func isInteger(raw string) bool {
+	return !strings.ContainsAny(raw, ".eE")
+}
+
+// isInt32 reports whether the JSON number raw is an integer representable by the 32-bit Int
+// scalar.
+func isInt32(raw string) bool {
+	if !isInteger(raw) {
+		return false
+	}
+	n, err := strconv.ParseInt(raw, 10, 64)
+	return err == nil && n >= math.MinInt32 && n <= math.MaxInt32
+}
+
+// variableError returns the error of the value of a variable found at path, whose message
+// starts with the location of the value, such as "variable $filter.ids[2]".
+func variableError(path []any, format string, args ...any) GraphQLError {
+	location := "variable $" + path[1].(string)
+	for _, element := range path[2:] {
+		if index, ok := element.(int); ok {
+			location += "[" + strconv.Itoa(index) + "]"
+		} else {
+			location += "." + element.(string)
+		}
+	}
+	return GraphQLError{
+		Message:    location + ": " + fmt.Sprintf(format, args...),
+		Path:       path,
+		Extensions: map[string]any{"rule": "VariableValues"},
+	}
+}
+
+// middleware returns a Handler checking the variables of the requests before calling next.
+func (checker *variableChecker) middleware(next Handler) Handler {
+	return func(ctx context.Context, request Request) (Response, error) {
+		err := request.CheckVariables(checker.schema)
+		if err != nil {
+			return Response{}, err
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,158 @@
+package ggql
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// VariablesFromStruct adds the exported fields of the struct v, or of the struct pointed to
+// by v, to the variables of the request, so that callers can keep a typed model of their
+// inputs. Variables are named after the `graphql` tag of each field, falling back to its
+// `json` tag and then to its name; fields tagged "-" are skipped, and the fields of embedded
+// structs are promoted like with encoding/json. With the omitempty option, fields holding
+// the zero value of their type are omitted; otherwise nil pointers, slices and maps are sent
+// as null. Fields holding an unset Optional are always omitted, and Null is always sent.
+// Nested structs are converted the same way, so that their tags are honoured too.
+// Registered scalars, uploads and types implementing json.Marshaler or encoding.TextMarshaler
+// are kept as is. The modified Request is returned, or an error if v is not a struct.
+func (request Request) VariablesFromStruct(v any) (Request, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return request, fmt.Errorf("variables must be a struct, got %T", v)
+	}
+
+	fields := make(map[string]any)
+	structFields(value, fields)
+	return request.AddVariables(fields), nil
+}
+
+// structFields adds the variables converted from the fields of the struct value to fields.
+func structFields(value reflect.Value, fields map[string]any) {
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options := variableTag(field)
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		fieldValue := value.Field(i)
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				structFields(embedded, fields)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if o, ok := fieldValue.Interface().(optional); ok {
+			if o.omitted() {
+				continue
+			}
+		} else if strings.Contains(options, "omitempty") && fieldValue.IsZero() {
+			continue
+		}
+		fields[name] = variableValue(fieldValue)
+	}
+}
+
+// variableTag returns the name and options of the `graphql` tag of field, or of its `json`
+// tag when it has none.
+func variableTag(field reflect.StructField) (string, string) {
+	tag, ok := field.Tag.Lookup("graphql")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	return name, options
+}
+
+// variableValue converts value into the maps and slices held by variables. Values of types
+// with their own encoding are returned as is.
+func variableValue(value reflect.Value) any {
+	if !value.IsValid() {
+		return nil
+	}
+	if keepsEncoding(value.Type()) {
+		return value.Interface()
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return nil
+		}
+		return variableValue(value.Elem())
+	case reflect.Struct:
+		fields := make(map[string]any)
+		structFields(value, fields)
+		return fields
+	case reflect.Slice:
+		if value.IsNil() {
+			return nil
+		}
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return value.Interface()
+		}
+		fallthrough
+	case reflect.Array:
+		elements := make([]any, value.Len())
+		for i := range elements {
+			elements[i] = variableValue(value.Index(i))
+		}
+		return elements
+	case reflect.Map:
+		if value.IsNil() {
+			return nil
+		}
+		if value.Type().Key().Kind() != reflect.String {
+			return value.Interface()
+		}
+		entries := make(map[string]any, value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			entries[iterator.Key().String()] = variableValue(iterator.Value())
+		}
+		return entries
+	default:
+		return value.Interface()
+	}
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	readerType        = reflect.TypeOf((*io.Reader)(nil)).Elem()
+)
+
+// keepsEncoding reports whether values of type t are encoded by other means than their
+// fields: registered scalars, uploads and types implementing json.Marshaler or
+// encoding.TextMarshaler.
+func keepsEncoding(t reflect.Type) bool {
+	if _, ok := scalarMarshaler(t); ok {
+		return true
+	}
+	if t.Kind() == reflect.Interface {
+		return false
+	}
+	return t == reflect.TypeOf(Upload{}) || t.Implements(readerType) ||
+		t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType)
+}
@@ -0,0 +1,56 @@
+package ggql
+
+import (
+	"context"
+	"crypto/sha256"
+	"github.com/samber/mo"
+	"github.com/tidwall/gjson"
+	"time"
+)
+
+// Watch polls the query every interval and emits its result on the returned channel each
+// time the "data" member of the response changes, as detected by comparing hashes of its
+// raw JSON. The first result is always emitted. Failed executions are emitted as errors
+// without stopping the polling, and do not reset the last known data.
+// The requests bypass the client's response caches. The channel is closed once ctx is
+// cancelled; results not received by then are dropped.
+func (request Request) Watch(ctx context.Context, interval time.Duration) <-chan mo.Result[gjson.Result] {
+	results := make(chan mo.Result[gjson.Result])
+	request = request.NoCache()
+
+	go func() {
+		defer close(results)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last [sha256.Size]byte
+		first := true
+		for {
+			result := request.DoCtx(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+
+			emit := result.IsError()
+			if response, err := result.Get(); err == nil {
+				hash := sha256.Sum256([]byte(response.Get("data").Raw))
+				emit = first || hash != last
+				first, last = false, hash
+			}
+			if emit {
+				select {
+				case results <- result:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return results
+}